@@ -13,6 +13,7 @@ const (
 	CodeEmptyPubKeyWithSequence1 ErrorCode = 100004
 	CodeUnauthorizedTx           ErrorCode = 100005
 	CodeInvalidFee               ErrorCode = 100006
+	CodeValidatorSetUnavailable  ErrorCode = 100007
 
 	// ReserveFund Errors
 	CodeReserveFundCheckFailed   ErrorCode = 101001
@@ -39,4 +40,32 @@ const (
 	CodeInvalidStake            ErrorCode = 106002
 	CodeInsufficientStake       ErrorCode = 106003
 	CodeNotEnoughBalanceToStake ErrorCode = 106004
+
+	// Slash Errors
+	CodeSlashReservedFundTooOld         ErrorCode = 107001
+	CodeSlashDuplicateReserveSequence   ErrorCode = 107002
+	CodeSlashConflictedProposer         ErrorCode = 107003
+	CodeSlashWrongReserveSequence       ErrorCode = 107004
+	CodeSlashProofTimestampOutOfSkew    ErrorCode = 107005
+	CodeSlashProtectedAddress           ErrorCode = 107006
+	CodeSlashReservedFundTooNew         ErrorCode = 107007
+	CodeSlashInvalidSnapshot            ErrorCode = 107008
+	CodeSlashPausedAddress              ErrorCode = 107009
+	CodeSlashNotOverspent               ErrorCode = 107010
+	CodeSlashProofExpired               ErrorCode = 107011
+	CodeSlashZeroCollateral             ErrorCode = 107012
+	CodeSlashReserveTxHashMismatch      ErrorCode = 107013
+	CodeSlashReasonMismatch             ErrorCode = 107014
+	CodeSlashAlreadySlashed             ErrorCode = 107015
+	CodeSlashVerificationBudgetExceeded ErrorCode = 107016
+	CodeSlashUnsupportedSignatureScheme ErrorCode = 107017
+	CodeSlashServiceTypeMismatch        ErrorCode = 107018
+	CodeSlashSelfSlash                  ErrorCode = 107019
+	CodeSlashVerificationAborted        ErrorCode = 107020
+	CodeSlashAccountNotFound            ErrorCode = 107021
+	CodeSlashReservedFundNotFound       ErrorCode = 107022
+	CodeSlashNonValidatorProposer       ErrorCode = 107023
+	CodeSlashInvalidProposerSignature   ErrorCode = 107024
+	CodeSlashInvalidProof               ErrorCode = 107025
+	CodeSlashNoEvidence                 ErrorCode = 107026
 )
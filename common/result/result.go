@@ -21,6 +21,13 @@ func (res Result) IsError() bool {
 	return res.Code != CodeOK
 }
 
+// IsRetryable indicates if the execution failed transiently, e.g. a dependency was temporarily
+// unavailable, so that the same input could succeed later
+func (res Result) IsRetryable() bool {
+	return res.Code == CodeValidatorSetUnavailable || res.Code == CodeSlashVerificationBudgetExceeded ||
+		res.Code == CodeSlashVerificationAborted
+}
+
 // String returns the string representation of the result
 func (res Result) String() string {
 	return fmt.Sprintf("Result{code:%v, message:%v}", res.Code, res.Message)
@@ -366,7 +366,7 @@ func (e *ConsensusEngine) handleBlock(block *core.Block) {
 		}).Error("Failed to reset state to parent.StateHash")
 		return
 	}
-	result = e.ledger.ApplyBlockTxs(block.Txs, block.StateHash)
+	result = e.ledger.ApplyBlockTxs(block)
 	if result.IsError() {
 		e.logger.WithFields(log.Fields{
 			"error":           result.String(),
@@ -638,6 +638,14 @@ func (e *ConsensusEngine) GetLastFinalizedBlock() *core.ExtendedBlock {
 	return e.state.GetLastFinalizedBlock()
 }
 
+// CurrentProposer returns the address of the proposer of the current epoch on top of the last finalized block.
+// It reflects the local state of the node, e.g. for reporting, and must not be used to execute transactions,
+// which all the nodes must execute alike.
+func (e *ConsensusEngine) CurrentProposer() common.Address {
+	lfb := e.GetLastFinalizedBlock()
+	return e.validatorManager.GetProposer(lfb.Hash(), e.GetEpoch()).Address
+}
+
 func (e *ConsensusEngine) processCCBlock(ccBlock *core.ExtendedBlock) {
 	if ccBlock.Height <= e.state.GetHighestCCBlock().Height {
 		return
@@ -749,7 +757,7 @@ func (e *ConsensusEngine) createProposal() (core.Proposal, error) {
 	block.HCC.Votes = e.chain.FindVotesByHash(block.HCC.BlockHash).UniqueVoter()
 
 	// Add Txs.
-	newRoot, txs, result := e.ledger.ProposeBlockTxs()
+	newRoot, txs, result := e.ledger.ProposeBlockTxs(block)
 	if result.IsError() {
 		err := fmt.Errorf("Failed to collect Txs for block proposal: %v", result.String())
 		return core.Proposal{}, err
@@ -221,6 +221,21 @@ func TestSingleBlockValidation(t *testing.T) {
 	require.False(ce.validateBlock(invalidBlock, chain.Root()), "Missing timestamp")
 }
 
+func TestCurrentProposer(t *testing.T) {
+	require := require.New(t)
+
+	privKey, _, _ := crypto.GenerateKeyPair()
+	validatorManager := MockValidatorManager{PrivKey: privKey}
+
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.CreateTestBlock("a0", "")
+	root.ChainID = "testchain"
+	chain := blockchain.NewChain("testchain", store, root)
+
+	ce := NewConsensusEngine(nil, store, chain, nil, validatorManager)
+	require.Equal(privKey.PublicKey().Address(), ce.CurrentProposer())
+}
+
 func TestValidParent(t *testing.T) {
 	require := require.New(t)
 
@@ -223,6 +223,19 @@ func (sig *Signature) IsEmpty() bool {
 	return len(sig.data) == 0
 }
 
+// SignatureLength is the length of a secp256k1 recoverable signature in the [R || S || V] format, the only
+// signature scheme supported
+const SignatureLength = 65
+
+// HasSupportedScheme indicates whether the signature is a secp256k1 recoverable signature, i.e. whether
+// its signer can be recovered at all
+func (sig *Signature) HasSupportedScheme() bool {
+	if sig == nil || len(sig.data) != SignatureLength {
+		return false
+	}
+	return sig.data[SignatureLength-1] < 4 // the recovery id
+}
+
 // RecoverSignerAddress recovers the address of the signer for the given message
 func (sig *Signature) RecoverSignerAddress(msg common.Bytes) (common.Address, error) {
 	msgHash := keccak256(msg)
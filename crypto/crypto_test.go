@@ -266,3 +266,28 @@ func TestSignatureVerification2(t *testing.T) {
 	assert.False(pubKeyA.VerifySignature(msg2, sig2B))
 	assert.False(pubKeyB.VerifySignature(msg2, sig2A))
 }
+
+func TestSignatureScheme(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, _, err := TEST_GenerateKeyPairWithSeed("test_seed_A")
+	assert.Nil(err)
+	sig, err := privKey.Sign(common.Bytes("ABCD has four letters"))
+	assert.Nil(err)
+	assert.True(sig.HasSupportedScheme())
+
+	sigBytes := sig.ToBytes()
+	shortSig, _ := SignatureFromBytes(sigBytes[:SignatureLength-1])
+	assert.False(shortSig.HasSupportedScheme())
+
+	longSig, _ := SignatureFromBytes(append(common.Bytes{}, append(sigBytes, 0x00)...))
+	assert.False(longSig.HasSupportedScheme())
+
+	badRecoveryIDBytes := append(common.Bytes{}, sigBytes...)
+	badRecoveryIDBytes[SignatureLength-1] = 27
+	badRecoveryIDSig, _ := SignatureFromBytes(badRecoveryIDBytes)
+	assert.False(badRecoveryIDSig.HasSupportedScheme())
+
+	var nilSig *Signature
+	assert.False(nilSig.HasSupportedScheme())
+}
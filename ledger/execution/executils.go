@@ -4,6 +4,8 @@ import (
 	"encoding/hex"
 	"math/big"
 
+	"github.com/pkg/errors"
+
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
@@ -34,18 +36,116 @@ import (
 // 	return
 // }
 
-// getValidatorAddresses returns validators' addresses
-func getValidatorAddresses(consensus core.ConsensusEngine, valMgr core.ValidatorManager) []common.Address {
-	extBlk := consensus.GetLastFinalizedBlock()
-	validators := valMgr.GetValidatorSet(extBlk.Hash()).Validators()
+// ValidatorSetProvider supplies the validator set the transactions are checked against, e.g. to verify the
+// proposer of a coinbase or slash transaction is a validator. The validator set is the one effective for the
+// block whose transactions are executed against the view, i.e. the block at the height of the view on top of
+// the parent block of the view. A lookup that fails transiently returns an error caused by
+// ErrValidatorSetUnavailable, any other error is permanent
+type ValidatorSetProvider interface {
+	GetValidatorSet(blockHeight uint64, parentBlockHash common.Hash) (*core.ValidatorSet, error)
+}
+
+// ErrValidatorSetUnavailable indicates the validator set is temporarily unavailable, e.g. while the
+// validator manager cannot retrieve the validator candidate pool, the lookup can be retried later
+var ErrValidatorSetUnavailable = errors.New("The validator set is temporarily unavailable")
+
+var _ ValidatorSetProvider = (*consensusValidatorSetProvider)(nil)
+
+// consensusValidatorSetProvider provides the validator set the validator manager selects for the child of
+// the parent block, the same set the consensus engine checks the proposer of that child against
+type consensusValidatorSetProvider struct {
+	consensus core.ConsensusEngine
+	valMgr    core.ValidatorManager
+}
+
+// NewValidatorSetProvider creates a ValidatorSetProvider for the validator set the validator manager selects
+// for a block on top of the parent block
+func NewValidatorSetProvider(consensus core.ConsensusEngine, valMgr core.ValidatorManager) ValidatorSetProvider {
+	return &consensusValidatorSetProvider{
+		consensus: consensus,
+		valMgr:    valMgr,
+	}
+}
+
+// GetValidatorSet fails permanently if the consensus engine or the validator manager is not set. The set
+// only depends on the parent block, so all the nodes agree on it. A view not tied to a block has no parent
+// block, in which case the set of the last finalized block is used, unless the block height precedes that
+// block, as the validator set might have rotated since. The validator manager panics if it fails to retrieve
+// the validator candidate pool, which is reported as a transient failure
+func (p *consensusValidatorSetProvider) GetValidatorSet(blockHeight uint64, parentBlockHash common.Hash) (validatorSet *core.ValidatorSet, err error) {
+	if p.consensus == nil {
+		return nil, errors.New("The consensus engine is not set")
+	}
+	if p.valMgr == nil {
+		return nil, errors.New("The validator manager is not set")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			validatorSet, err = nil, errors.Wrapf(ErrValidatorSetUnavailable, "%v", r)
+		}
+	}()
+
+	if !parentBlockHash.IsEmpty() {
+		validatorSet = p.valMgr.GetNextValidatorSet(parentBlockHash)
+		if validatorSet == nil {
+			return nil, errors.Wrapf(ErrValidatorSetUnavailable, "no validator set for the child of block %v", parentBlockHash.Hex())
+		}
+		return validatorSet, nil
+	}
+
+	extBlk := p.consensus.GetLastFinalizedBlock()
+	if extBlk == nil {
+		return nil, errors.Wrap(ErrValidatorSetUnavailable, "no finalized block")
+	}
+	if extBlk.Block != nil && blockHeight < extBlk.Height {
+		return nil, errors.Errorf("the validator set at block height %v is superseded by the one finalized at %v",
+			blockHeight, extBlk.Height)
+	}
+	validatorSet = p.valMgr.GetValidatorSet(extBlk.Hash())
+	if validatorSet == nil {
+		return nil, errors.Wrapf(ErrValidatorSetUnavailable, "no validator set for block %v", extBlk.Hash().Hex())
+	}
+	return validatorSet, nil
+}
+
+// getValidatorAddresses returns the addresses of the validators effective for the block executed against the
+// view. If the validator set is not available, the returned result tells whether the lookup can be retried
+// (see Result.IsRetryable)
+func getValidatorAddresses(valSetProvider ValidatorSetProvider, view *state.StoreView) ([]common.Address, result.Result) {
+	validators, res := getValidators(valSetProvider, view)
+	if res.IsError() {
+		return nil, res
+	}
 	validatorAddresses := make([]common.Address, len(validators))
 	for i, v := range validators {
 		validatorAddresses[i] = v.Address
 	}
-	return validatorAddresses
+	return validatorAddresses, result.OK
+}
+
+// getValidators returns the validators effective for the block executed against the view, along with their
+// stakes
+func getValidators(valSetProvider ValidatorSetProvider, view *state.StoreView) ([]core.Validator, result.Result) {
+	if valSetProvider == nil {
+		return nil, result.Error("The validator set provider is not set")
+	}
+	validatorSet, err := valSetProvider.GetValidatorSet(view.Height(), view.ParentBlockHash())
+	if err != nil {
+		res := result.Error("The validator set is not available: %v", err)
+		if errors.Cause(err) == ErrValidatorSetUnavailable {
+			res = res.WithErrorCode(result.CodeValidatorSetUnavailable)
+		}
+		return nil, res
+	}
+	if validatorSet == nil {
+		return nil, result.Error("The validator set is not available")
+	}
+	return validatorSet.Validators(), result.OK
 }
 
-func isAValidator(address common.Address, validatorAddresses []common.Address) result.Result {
+// isAValidator verifies the address is one of the validators effective at the given block height
+func isAValidator(address common.Address, validatorAddresses []common.Address, blockHeight uint64) result.Result {
 	proposerIsAValidator := false
 	for _, validatorAddr := range validatorAddresses {
 		if address == validatorAddr {
@@ -54,7 +154,7 @@ func isAValidator(address common.Address, validatorAddresses []common.Address) r
 		}
 	}
 	if !proposerIsAValidator {
-		return result.Error("The coinbaseTx proposer is not a validator")
+		return result.Error("The proposer %v is not a validator at block height %v", address.Hex(), blockHeight)
 	}
 
 	return result.OK
@@ -262,6 +362,6 @@ func chargeFee(account *types.Account, fee types.Coins) bool {
 		return false
 	}
 
-	account.Balance = account.Balance.Minus(fee)
+	account.Balance = account.Balance.Plus(fee.Negate())
 	return true
 }
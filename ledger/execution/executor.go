@@ -1,6 +1,7 @@
 package execution
 
 import (
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/thetatoken/theta/common"
@@ -13,12 +14,27 @@ import (
 var logger *log.Entry = log.WithFields(log.Fields{"prefix": "ledger"})
 
 //
-// TxExecutor defines the interface of the transaction executors
+// TxExecutor defines the interface of the transaction executors. The sanityCheck
+// method receives a read-only view (see StoreView.ReadOnlyView), it must not
+// mutate the state
 //
 type TxExecutor interface {
 	sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result
 	process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result)
 	getTxInfo(transaction types.Tx) *core.TxInfo
+	getFee(transaction types.Tx) types.Coins // the fee charged for executing the transaction
+}
+
+// ErrorCondition describes a class of errors a TxExecutor can produce
+type ErrorCondition struct {
+	Code        result.ErrorCode
+	Description string
+}
+
+// ErrorConditionEnumerator is implemented by the TxExecutors that can enumerate the error conditions they
+// produce, e.g. for documentation tooling to generate error references
+type ErrorConditionEnumerator interface {
+	ErrorConditions() []ErrorCondition
 }
 
 //
@@ -37,40 +53,74 @@ type Executor struct {
 	servicePaymentTxExec *ServicePaymentTxExecutor
 	splitRuleTxExec      *SplitRuleTxExecutor
 	//smartContractTxExec  *SmartContractTxExecutor
-	depositStakeTxExec  *DepositStakeExecutor
-	withdrawStakeTxExec *WithdrawStakeExecutor
+	depositStakeTxExec   *DepositStakeExecutor
+	withdrawStakeTxExec  *WithdrawStakeExecutor
+	slashEvidenceTxExec  *SlashEvidenceTxExecutor
+	batchSlashTxExec     *BatchSlashTxExecutor
+	multiFundSlashTxExec *MultiFundSlashTxExecutor
 
 	skipSanityCheck bool
 }
 
 // NewExecutor creates a new instance of Executor
 func NewExecutor(state *st.LedgerState, consensus core.ConsensusEngine, valMgr core.ValidatorManager) *Executor {
+	slashTxExec := NewSlashTxExecutor(consensus, valMgr)
 	executor := &Executor{
 		state:                state,
 		consensus:            consensus,
 		valMgr:               valMgr,
 		coinbaseTxExec:       NewCoinbaseTxExecutor(state, consensus, valMgr),
-		slashTxExec:          NewSlashTxExecutor(consensus, valMgr),
+		slashTxExec:          slashTxExec,
 		sendTxExec:           NewSendTxExecutor(),
-		reserveFundTxExec:    NewReserveFundTxExecutor(state),
+		reserveFundTxExec:    NewReserveFundTxExecutor(state, slashTxExec),
 		releaseFundTxExec:    NewReleaseFundTxExecutor(state),
-		servicePaymentTxExec: NewServicePaymentTxExecutor(state),
+		servicePaymentTxExec: NewServicePaymentTxExecutor(state, slashTxExec),
 		splitRuleTxExec:      NewSplitRuleTxExecutor(state),
 		//smartContractTxExec:  NewSmartContractTxExecutor(state),
-		depositStakeTxExec:  NewDepositStakeExecutor(),
-		withdrawStakeTxExec: NewWithdrawStakeExecutor(state),
-		skipSanityCheck:     false,
+		depositStakeTxExec:   NewDepositStakeExecutor(),
+		withdrawStakeTxExec:  NewWithdrawStakeExecutor(state),
+		slashEvidenceTxExec:  NewSlashEvidenceTxExecutor(slashTxExec),
+		batchSlashTxExec:     NewBatchSlashTxExecutor(slashTxExec),
+		multiFundSlashTxExec: NewMultiFundSlashTxExecutor(slashTxExec),
+		skipSanityCheck:      false,
 	}
 
 	return executor
 }
 
+// SlashTxExecutor returns the executor for SlashTxs, e.g. to configure the slashing rules
+func (exec *Executor) SlashTxExecutor() *SlashTxExecutor {
+	return exec.slashTxExec
+}
+
+// SetValidatorSetProvider sets the source of the validator set the proposers of the coinbase and slash
+// transactions are checked against, e.g. for alternative consensus backends
+func (exec *Executor) SetValidatorSetProvider(valSetProvider ValidatorSetProvider) {
+	exec.coinbaseTxExec.SetValidatorSetProvider(valSetProvider)
+	exec.slashTxExec.SetValidatorSetProvider(valSetProvider)
+}
+
 // SetSkipSanityCheck sets the flag for sanity check.
 // Skip checks while replaying commmitted blocks.
 func (exec *Executor) SetSkipSanityCheck(skip bool) {
 	exec.skipSanityCheck = skip
 }
 
+// ValidateWiring verifies that the executor and all the tx executors it dispatches to have their
+// dependencies properly wired up. It is meant to be called at startup to fail fast on misconfiguration.
+func (exec *Executor) ValidateWiring() error {
+	if exec.state == nil {
+		return errors.New("Executor: ledger state is not set")
+	}
+	if err := exec.coinbaseTxExec.ValidateWiring(); err != nil {
+		return err
+	}
+	if err := exec.slashTxExec.ValidateWiring(); err != nil {
+		return err
+	}
+	return nil
+}
+
 // ExecuteTx executes the given transaction
 func (exec *Executor) ExecuteTx(tx types.Tx) (common.Hash, result.Result) {
 	return exec.processTx(tx, core.DeliveredView)
@@ -97,6 +147,17 @@ func (exec *Executor) GetTxInfo(tx types.Tx) (*core.TxInfo, result.Result) {
 	return txInfo, result.OK
 }
 
+// GetTxFee returns the fee charged for executing the given transaction. For the transactions whose fee
+// depends on the execution, e.g. the gas used, the maximum fee is returned
+func (exec *Executor) GetTxFee(tx types.Tx) (types.Coins, result.Result) {
+	txExecutor := exec.getTxExecutor(tx)
+	if txExecutor == nil {
+		return types.Coins{}, result.Error("Unknown tx type")
+	}
+
+	return txExecutor.getFee(tx), result.OK
+}
+
 // processTx contains the main logic to process the transaction. If the tx is invalid, a TMSP error will be returned.
 func (exec *Executor) processTx(tx types.Tx, viewSel core.ViewSelector) (common.Hash, result.Result) {
 	chainID := exec.state.GetChainID()
@@ -110,12 +171,29 @@ func (exec *Executor) processTx(tx types.Tx, viewSel core.ViewSelector) (common.
 		view = exec.state.Screened()
 	}
 
+	// The slash proof verification budget is per block, so it does not apply to the screening
+	return exec.executeTx(chainID, view, tx, viewSel != core.ScreenedView)
+}
+
+// executeTx checks and processes the transaction against the given view
+func (exec *Executor) executeTx(chainID string, view *st.StoreView, tx types.Tx, applyVerificationBudget bool) (common.Hash, result.Result) {
+	verificationCost := exec.slashTxExec.proofVerificationCost(view, tx)
+	if applyVerificationBudget && !exec.skipSanityCheck {
+		budgetResult := exec.slashTxExec.checkProofVerificationBudget(view, verificationCost)
+		if budgetResult.IsError() {
+			return common.Hash{}, budgetResult
+		}
+	}
+
 	sanityCheckResult := exec.sanityCheck(chainID, view, tx)
 	if sanityCheckResult.IsError() {
 		return common.Hash{}, sanityCheckResult
 	}
 
 	txHash, processResult := exec.process(chainID, view, tx)
+	if processResult.IsOK() && verificationCost > 0 {
+		view.AddSlashProofVerifications(verificationCost)
+	}
 	return txHash, processResult
 }
 
@@ -127,7 +205,7 @@ func (exec *Executor) sanityCheck(chainID string, view *st.StoreView, tx types.T
 	var sanityCheckResult result.Result
 	txExecutor := exec.getTxExecutor(tx)
 	if txExecutor != nil {
-		sanityCheckResult = txExecutor.sanityCheck(chainID, view, tx)
+		sanityCheckResult = sanityCheckReadOnly(txExecutor, chainID, view, tx)
 	} else {
 		sanityCheckResult = result.Error("Unknown tx type")
 	}
@@ -135,6 +213,22 @@ func (exec *Executor) sanityCheck(chainID string, view *st.StoreView, tx types.T
 	return sanityCheckResult
 }
 
+// sanityCheckReadOnly runs the sanity check of the tx against a read-only view, an attempt to mutate
+// the state is caught and reported as an error
+func sanityCheckReadOnly(txExecutor TxExecutor, chainID string, view *st.StoreView, tx types.Tx) (sanityCheckResult result.Result) {
+	defer func() {
+		if err := recover(); err != nil {
+			if err != st.ErrWriteToReadOnlyView {
+				panic(err)
+			}
+			logger.Errorf("Sanity check attempted to mutate the state, tx: %v", tx)
+			sanityCheckResult = result.Error("Sanity check attempted to mutate the state")
+		}
+	}()
+
+	return txExecutor.sanityCheck(chainID, view.ReadOnlyView(), tx)
+}
+
 func (exec *Executor) process(chainID string, view *st.StoreView, tx types.Tx) (common.Hash, result.Result) {
 	var processResult result.Result
 	var txHash common.Hash
@@ -171,6 +265,12 @@ func (exec *Executor) getTxExecutor(tx types.Tx) TxExecutor {
 		txExecutor = exec.depositStakeTxExec
 	case *types.WithdrawStakeTx:
 		txExecutor = exec.withdrawStakeTxExec
+	case *types.SlashEvidenceTx:
+		txExecutor = exec.slashEvidenceTxExec
+	case *types.BatchSlashTx:
+		txExecutor = exec.batchSlashTxExec
+	case *types.MultiFundSlashTx:
+		txExecutor = exec.multiFundSlashTxExec
 	default:
 		txExecutor = nil
 	}
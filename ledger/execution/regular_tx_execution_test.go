@@ -6,7 +6,9 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/common/result"
+	st "github.com/thetatoken/theta/ledger/state"
 	"github.com/thetatoken/theta/ledger/types"
 )
 
@@ -544,6 +546,57 @@ func TestReserveFundTx(t *testing.T) {
 	assert.Equal(uint64(1), retrievedUserAcc.ReservedFunds[0].ReserveSequence)
 }
 
+func TestGetReservedFunds(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	txFee := getMinimumTxFee()
+	alice := types.MakeAcc("User Alice")
+	alice.Balance = types.NewCoins(0, 10000*txFee)
+	et.acc2State(alice)
+	bob := types.MakeAcc("User Bob")
+	bob.Balance = types.NewCoins(0, 3000*txFee)
+	et.acc2State(bob)
+	et.fastforwardTo(1e2)
+
+	for reserveSeq := 1; reserveSeq <= 2; reserveSeq++ {
+		tx := createReserveFundTx(et.chainID, &alice, 1000*txFee, 1001*txFee, reserveSeq, []string{"rid001"})
+		res := et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+		assert.True(res.IsOK(), res.Message)
+		_, res = et.executor.getTxExecutor(tx).process(et.chainID, et.state().Delivered(), tx)
+		assert.True(res.IsOK(), res.Message)
+	}
+	et.state().Commit()
+	view := et.state().Delivered()
+
+	// An account with multiple reserved funds
+	reservedFunds, res := GetReservedFunds(view, alice.Address)
+	assert.True(res.IsOK(), res.Message)
+	if assert.Equal(2, len(reservedFunds)) {
+		assert.Equal(uint64(1), reservedFunds[0].ReserveSequence)
+		assert.Equal(uint64(2), reservedFunds[1].ReserveSequence)
+		assert.True(types.NewCoins(0, 1000*txFee).IsEqual(reservedFunds[0].InitialFund))
+	}
+
+	// The reserved funds returned are a copy
+	reservedFunds[0].UsedFund = types.NewCoins(0, 500*txFee)
+	reservedFunds[0].ResourceIDs[0] = "rid002"
+	reservedFund := view.GetAccount(alice.Address).ReservedFunds[0]
+	assert.True(reservedFund.UsedFund.IsZero())
+	assert.Equal("rid001", reservedFund.ResourceIDs[0])
+
+	// An account without reserved funds
+	reservedFunds, res = GetReservedFunds(view, bob.Address)
+	assert.True(res.IsOK(), res.Message)
+	assert.NotNil(reservedFunds)
+	assert.Equal(0, len(reservedFunds))
+
+	// A nonexistent account
+	reservedFunds, res = GetReservedFunds(view, types.MakeAcc("User Carol").Address)
+	assert.True(res.IsError())
+	assert.Nil(reservedFunds)
+}
+
 func TestReleaseFundTx(t *testing.T) {
 	assert := assert.New(t)
 	et := NewExecTest()
@@ -1073,3 +1126,22 @@ func TestSplitRuleTxUpdate(t *testing.T) {
 	log.Infof("currHeight = %v", currHeight)
 	log.Infof("endHeight2 = %v", endHeight2)
 }
+
+type mutatingTxExecutor struct {
+	SendTxExecutor
+}
+
+func (exec *mutatingTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	view.SetAccount(common.Address{}, types.NewAccount(common.Address{}))
+	return result.OK
+}
+
+func TestSanityCheckReadOnly(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+	view := et.state().Delivered()
+
+	res := sanityCheckReadOnly(&mutatingTxExecutor{}, et.chainID, view, &types.SendTx{})
+	assert.True(res.IsError(), res.Message)
+	assert.Nil(view.GetAccount(common.Address{}))
+}
@@ -0,0 +1,120 @@
+package execution
+
+import (
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// SimulationResult is the predicted outcome of executing a transaction
+type SimulationResult struct {
+	TxHash        common.Hash
+	Result        result.Result
+	BalanceDeltas []BalanceDelta // only the accounts whose balance would change
+}
+
+// BalanceDelta is the predicted change of the balance of an account
+type BalanceDelta struct {
+	Address common.Address `json:"address"`
+	Delta   types.Coins    `json:"delta"`
+}
+
+// SimulateTx predicts the outcome of executing the transaction against the given view, e.g. for the RPC
+// callers to tell whether a SlashTx would succeed and what it would cost. The transaction is checked and
+// processed exactly as it would be in a block, but against a copy of the view which is discarded
+// afterwards, so the given view is left untouched.
+func (exec *Executor) SimulateTx(view *st.StoreView, tx types.Tx) (*SimulationResult, error) {
+	simView, err := view.Copy()
+	if err != nil {
+		return nil, err
+	}
+
+	chainID := exec.state.GetChainID()
+	addrs := exec.simulatedAddresses(tx)
+	balancesBefore := getBalances(simView, addrs)
+
+	txHash, res := exec.executeTx(chainID, simView, tx, false)
+	simResult := &SimulationResult{
+		TxHash:        txHash,
+		Result:        res,
+		BalanceDeltas: []BalanceDelta{},
+	}
+	if res.IsError() {
+		simResult.TxHash = types.TxID(chainID, tx)
+		return simResult, nil
+	}
+
+	balancesAfter := getBalances(simView, addrs)
+	for idx, addr := range addrs {
+		delta := balancesAfter[idx].Minus(balancesBefore[idx])
+		if !delta.IsZero() {
+			simResult.BalanceDeltas = append(simResult.BalanceDeltas, BalanceDelta{Address: addr, Delta: delta})
+		}
+	}
+	return simResult, nil
+}
+
+// simulatedAddresses returns the accounts whose balance the transaction may change
+func (exec *Executor) simulatedAddresses(transaction types.Tx) []common.Address {
+	addrs := []common.Address{}
+	switch tx := transaction.(type) {
+	case *types.CoinbaseTx:
+		for _, output := range tx.Outputs {
+			addrs = append(addrs, output.Address)
+		}
+	case *types.SlashTx:
+		addrs = append(addrs, tx.Proposer.Address, tx.SlashedAddress, exec.slashTxExec.params.Treasury)
+	case *types.BatchSlashTx:
+		addrs = append(addrs, tx.Proposer.Address, exec.slashTxExec.params.Treasury)
+		for _, entry := range tx.Entries {
+			addrs = append(addrs, entry.SlashedAddress)
+		}
+	case *types.MultiFundSlashTx:
+		addrs = append(addrs, tx.Proposer.Address, tx.SlashedAddress, exec.slashTxExec.params.Treasury)
+	case *types.SendTx:
+		for _, input := range tx.Inputs {
+			addrs = append(addrs, input.Address)
+		}
+		for _, output := range tx.Outputs {
+			addrs = append(addrs, output.Address)
+		}
+	case *types.ReserveFundTx:
+		addrs = append(addrs, tx.Source.Address)
+	case *types.ReleaseFundTx:
+		addrs = append(addrs, tx.Source.Address)
+	case *types.ServicePaymentTx:
+		addrs = append(addrs, tx.Source.Address, tx.Target.Address)
+	case *types.SplitRuleTx:
+		addrs = append(addrs, tx.Initiator.Address)
+	case *types.SmartContractTx:
+		addrs = append(addrs, tx.From.Address, tx.To.Address)
+	case *types.DepositStakeTx:
+		addrs = append(addrs, tx.Source.Address)
+	case *types.WithdrawStakeTx:
+		addrs = append(addrs, tx.Source.Address)
+	case *types.SlashEvidenceTx:
+		addrs = append(addrs, tx.Submitter.Address)
+	}
+
+	uniqueAddrs := []common.Address{}
+	seen := make(map[common.Address]bool)
+	for _, addr := range addrs {
+		if !seen[addr] {
+			seen[addr] = true
+			uniqueAddrs = append(uniqueAddrs, addr)
+		}
+	}
+	return uniqueAddrs
+}
+
+func getBalances(view *st.StoreView, addrs []common.Address) []types.Coins {
+	balances := make([]types.Coins, len(addrs))
+	for idx, addr := range addrs {
+		balances[idx] = types.NewCoins(0, 0)
+		if account := view.GetAccount(addr); account != nil {
+			balances[idx] = account.Balance.NoNil()
+		}
+	}
+	return balances
+}
@@ -0,0 +1,357 @@
+package execution
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// SlashRemainderPolicy determines what becomes of the remainder of the slashed amount after quantization
+type SlashRemainderPolicy byte
+
+const (
+	SlashRemainderReturned SlashRemainderPolicy = iota // the remainder is returned to the slashed account
+	SlashRemainderBurnt                                // the remainder is burnt
+)
+
+// SlashSplit splits the slashed amount among the burn, the treasury and the proposer in proportion to the
+// weights, which need not sum up to any particular total
+type SlashSplit struct {
+	BurnWeight     uint64
+	TreasuryWeight uint64
+	ProposerWeight uint64
+}
+
+// Split splits the amount per denomination. Each share is rounded down, and the remainder lost to rounding is
+// assigned to the first share with a non-zero weight, in the order of burn, treasury and proposer, so the
+// three shares always sum up to the amount. If all the weights are zero, the proposer gets the whole amount
+func (s SlashSplit) Split(amount types.Coins) (burnt, treasury, proposer types.Coins) {
+	amount = amount.NoNil()
+	weights := []uint64{s.BurnWeight, s.TreasuryWeight, s.ProposerWeight}
+	thetaShares := splitForDenom(amount.ThetaWei, weights)
+	tfuelShares := splitForDenom(amount.TFuelWei, weights)
+
+	burnt = types.Coins{ThetaWei: thetaShares[0], TFuelWei: tfuelShares[0]}
+	treasury = types.Coins{ThetaWei: thetaShares[1], TFuelWei: tfuelShares[1]}
+	proposer = types.Coins{ThetaWei: thetaShares[2], TFuelWei: tfuelShares[2]}
+	return burnt, treasury, proposer
+}
+
+// isBurnAddress tells whether the address is the zero address. The zero address is a sink rather than an
+// account: the coins sent to it are burnt, i.e. removed from the supply, instead of creating an account
+// at the zero address which holds them
+func isBurnAddress(addr common.Address) bool {
+	return addr == common.Address{}
+}
+
+// calcSlashedAmount computes the amount seized from the reserved fund. The seizure is denomination-aware:
+// the collateral and the remaining fund of a denomination are seized only if the reserved fund was
+// overspent in that denomination, otherwise they are returned to the owner of the reserved fund
+func calcSlashedAmount(reservedFund *types.ReservedFund, fundIntendedToSpend types.Coins) (slashedAmount, returnedAmount types.Coins) {
+	thetaOverspent, tfuelOverspent := types.IsOverspent(reservedFund.InitialFund, fundIntendedToSpend)
+	return calcSlashedAmountForOverspending(reservedFund, thetaOverspent, tfuelOverspent)
+}
+
+// calcSlashedAmountForOverspending computes the amount seized from the reserved fund given the
+// denominations it was overspent in
+func calcSlashedAmountForOverspending(reservedFund *types.ReservedFund, thetaOverspent, tfuelOverspent bool) (slashedAmount, returnedAmount types.Coins) {
+	initialFund := reservedFund.InitialFund.NoNil()
+	usedFund := reservedFund.UsedFund.NoNil()
+	collateral := reservedFund.Collateral.NoNil()
+
+	slashedTheta, returnedTheta := calcSlashedAmountForDenom(initialFund.ThetaWei, usedFund.ThetaWei, collateral.ThetaWei, thetaOverspent)
+	slashedTFuel, returnedTFuel := calcSlashedAmountForDenom(initialFund.TFuelWei, usedFund.TFuelWei, collateral.TFuelWei, tfuelOverspent)
+
+	slashedAmount = types.Coins{ThetaWei: slashedTheta, TFuelWei: slashedTFuel}
+	returnedAmount = types.Coins{ThetaWei: returnedTheta, TFuelWei: returnedTFuel}
+	return slashedAmount, returnedAmount
+}
+
+// calcPartialSlashedAmount computes the amount seized from the reserved fund when only the overspent
+// amount is slashed. In each denomination, the slashed amount is the overspent amount capped by the
+// collateral, and the rest of the collateral and the remaining fund are returned
+func calcPartialSlashedAmount(reservedFund *types.ReservedFund, overspentAmount types.Coins) (slashedAmount, returnedAmount types.Coins) {
+	initialFund := reservedFund.InitialFund.NoNil()
+	usedFund := reservedFund.UsedFund.NoNil()
+	collateral := reservedFund.Collateral.NoNil()
+	overspentAmount = overspentAmount.NoNil()
+
+	slashedTheta, returnedTheta := calcPartialSlashedAmountForDenom(initialFund.ThetaWei, usedFund.ThetaWei, collateral.ThetaWei, overspentAmount.ThetaWei)
+	slashedTFuel, returnedTFuel := calcPartialSlashedAmountForDenom(initialFund.TFuelWei, usedFund.TFuelWei, collateral.TFuelWei, overspentAmount.TFuelWei)
+
+	slashedAmount = types.Coins{ThetaWei: slashedTheta, TFuelWei: slashedTFuel}
+	returnedAmount = types.Coins{ThetaWei: returnedTheta, TFuelWei: returnedTFuel}
+	return slashedAmount, returnedAmount
+}
+
+// calcRevocationSlashedAmount computes the amount seized from the reserved fund for a channel revocation.
+// Settling a revoked channel state is not tied to any denomination, hence everything is seized
+func calcRevocationSlashedAmount(reservedFund *types.ReservedFund) (slashedAmount, returnedAmount types.Coins) {
+	initialFund := reservedFund.InitialFund.NoNil()
+	usedFund := reservedFund.UsedFund.NoNil()
+	collateral := reservedFund.Collateral.NoNil()
+
+	slashedAmount = types.Coins{
+		ThetaWei: calcSlashableForDenom(initialFund.ThetaWei, usedFund.ThetaWei, collateral.ThetaWei),
+		TFuelWei: calcSlashableForDenom(initialFund.TFuelWei, usedFund.TFuelWei, collateral.TFuelWei),
+	}
+	returnedAmount = types.NewCoins(0, 0)
+	return slashedAmount, returnedAmount
+}
+
+// SlashDistribution is how a slash seizes a reserved fund, and how the slashed amount is split
+type SlashDistribution struct {
+	SlashedAmount  types.Coins // seized from the reserved fund, net of the remainder of the quantization
+	ReturnedAmount types.Coins // returned to the slashed account
+	BurntAmount    types.Coins // share of the slashed amount burnt
+	TreasuryAmount types.Coins // share of the slashed amount credited to the treasury
+	ProposerAmount types.Coins // share of the slashed amount rewarded to the proposer
+	BurntRemainder types.Coins // remainder of the quantization, if burnt
+}
+
+// calcSlashDistribution calculates how the reserved fund is slashed with the proof, which is assumed to be
+// verified. For an overspending proof, the overspending is evaluated against the reserved fund the proof is
+// verified against, which may be the one in a state snapshot. It also tells whether the reserved fund is
+// overspent at all, i.e. whether there is anything to slash. Both the SlashTx execution and EstimateReward
+// rely on it, so that the estimates match the execution
+func (exec *SlashTxExecutor) calcSlashDistribution(slashedAddress common.Address, reason types.SlashReason,
+	reservedFund, proofReservedFund *types.ReservedFund, slashProofBytes common.Bytes) (SlashDistribution, bool, result.Result) {
+	var slashedAmount, returnedAmount types.Coins
+	if reason == types.SlashReasonChannelRevocation {
+		slashedAmount, returnedAmount = calcRevocationSlashedAmount(reservedFund)
+	} else {
+		overspendingProof, err := decodeOverspendingProof(slashProofBytes)
+		if err != nil {
+			return SlashDistribution{}, false, invalidSlashProofEncoding(err)
+		}
+		thetaOverspent, tfuelOverspent := proofReservedFund.OverspentDenoms(overspendingProof.ServicePayments)
+		if !thetaOverspent && !tfuelOverspent {
+			return SlashDistribution{}, false, result.OK
+		}
+		if exec.params.PartialSlash {
+			overspentAmount := proofReservedFund.OverspentAmount(overspendingProof.ServicePayments)
+			slashedAmount, returnedAmount = calcPartialSlashedAmount(reservedFund, overspentAmount)
+		} else {
+			slashedAmount, returnedAmount = calcSlashedAmountForOverspending(reservedFund, thetaOverspent, tfuelOverspent)
+		}
+	}
+
+	if res := checkSlashedCommitment(reservedFund, slashedAmount, returnedAmount); res.IsError() {
+		exec.logger.Errorf("Reserved fund %v of %v is corrupted: %v", reservedFund.ReserveSequence, slashedAddress.Hex(), res.Message)
+		return SlashDistribution{}, false, res
+	}
+
+	burntRemainder := types.NewCoins(0, 0)
+	if !exec.params.Quantum.IsZero() {
+		var remainder types.Coins
+		slashedAmount, remainder = quantizeSlashedAmount(slashedAmount, exec.params.Quantum)
+		if exec.params.RemainderPolicy == SlashRemainderReturned {
+			returnedAmount = returnedAmount.Plus(remainder)
+		} else {
+			burntRemainder = remainder
+		}
+	}
+
+	burntAmount, treasuryAmount, proposerAmount := exec.params.Split.Split(slashedAmount)
+	if exec.params.MaxNativeProposerReward != nil {
+		var excess types.Coins
+		proposerAmount, excess = capNativeProposerReward(proposerAmount, exec.params.MaxNativeProposerReward)
+		treasuryAmount = treasuryAmount.Plus(excess)
+	}
+	if isBurnAddress(exec.params.Treasury) {
+		burntAmount = burntAmount.Plus(treasuryAmount)
+		treasuryAmount = types.NewCoins(0, 0)
+	}
+
+	return SlashDistribution{
+		SlashedAmount:  slashedAmount,
+		ReturnedAmount: returnedAmount,
+		BurntAmount:    burntAmount,
+		TreasuryAmount: treasuryAmount,
+		ProposerAmount: proposerAmount,
+		BurntRemainder: burntRemainder,
+	}, true, result.OK
+}
+
+// checkSlashedCommitment verifies the amount seized from the reserved fund, i.e. the amount slashed and the
+// amount returned, does not exceed what the fund committed, so that corrupted fund accounting, e.g. a
+// negative UsedFund, cannot credit coins that were never reserved
+func checkSlashedCommitment(reservedFund *types.ReservedFund, slashedAmount, returnedAmount types.Coins) result.Result {
+	commitment := reservedFund.InitialFund.NoNil().Plus(reservedFund.Collateral.NoNil())
+	seizedAmount := slashedAmount.Plus(returnedAmount)
+	if !commitment.IsGTE(seizedAmount) {
+		return result.Error("Slashed amount %v exceeds the commitment %v of reserved fund %v",
+			seizedAmount, commitment, reservedFund.ReserveSequence)
+	}
+	return result.OK
+}
+
+// capNativeProposerReward splits the proposer reward into the TFuel paid to the proposer, at most maxReward,
+// and the excess, which includes the whole Theta share
+func capNativeProposerReward(reward types.Coins, maxReward *big.Int) (capped, excess types.Coins) {
+	reward = reward.NoNil()
+	cappedTFuel := reward.TFuelWei
+	if cappedTFuel.Cmp(maxReward) > 0 {
+		cappedTFuel = maxReward
+	}
+	capped = types.Coins{ThetaWei: big.NewInt(0), TFuelWei: new(big.Int).Set(cappedTFuel)}
+	excess = reward.Minus(capped)
+	return capped, excess
+}
+
+// ValidatorReward is the share of a slash reward credited to a validator
+type ValidatorReward struct {
+	Address common.Address
+	Amount  types.Coins
+}
+
+// shareSlashReward shares the reward among the validators effective for the block executed against the view
+// in proportion to their stakes. The validators are listed in the order of their addresses. If the validators
+// have no stake at all, the reward is not shared, i.e. no validator reward is returned
+func (exec *SlashTxExecutor) shareSlashReward(view *st.StoreView, reward types.Coins) ([]ValidatorReward, result.Result) {
+	validators, res := getValidators(exec.valSetProvider, view)
+	if res.IsError() {
+		return nil, res
+	}
+	validators = append([]core.Validator{}, validators...)
+	sort.Slice(validators, func(i, j int) bool {
+		return bytes.Compare(validators[i].Address[:], validators[j].Address[:]) < 0
+	})
+
+	stakes := make([]*big.Int, len(validators))
+	totalStake := big.NewInt(0)
+	for idx, validator := range validators {
+		stakes[idx] = big.NewInt(0)
+		if validator.Stake != nil && validator.Stake.Sign() > 0 {
+			stakes[idx] = validator.Stake
+		}
+		totalStake.Add(totalStake, stakes[idx])
+	}
+	if totalStake.Sign() == 0 {
+		return nil, result.OK
+	}
+
+	reward = reward.NoNil()
+	thetaShares := splitByStakeForDenom(reward.ThetaWei, stakes, totalStake)
+	tfuelShares := splitByStakeForDenom(reward.TFuelWei, stakes, totalStake)
+	validatorRewards := make([]ValidatorReward, len(validators))
+	for idx, validator := range validators {
+		validatorRewards[idx] = ValidatorReward{
+			Address: validator.Address,
+			Amount:  types.Coins{ThetaWei: thetaShares[idx], TFuelWei: tfuelShares[idx]},
+		}
+	}
+	return validatorRewards, result.OK
+}
+
+// splitByStakeForDenom splits the amount in proportion to the stakes with the largest remainder method: each
+// share is rounded down, and the units lost to rounding go one each to the shares with the largest fractional
+// parts, the earlier share first in case of a tie, so the shares always sum up to the amount
+func splitByStakeForDenom(amount *big.Int, stakes []*big.Int, totalStake *big.Int) []*big.Int {
+	shares := make([]*big.Int, len(stakes))
+	fractions := make([]*big.Int, len(stakes))
+	leftover := new(big.Int).Set(amount)
+	for idx, stake := range stakes {
+		shares[idx], fractions[idx] = new(big.Int).DivMod(new(big.Int).Mul(amount, stake), totalStake, new(big.Int))
+		leftover.Sub(leftover, shares[idx])
+	}
+
+	order := make([]int, len(stakes))
+	for idx := range order {
+		order[idx] = idx
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return fractions[order[i]].Cmp(fractions[order[j]]) > 0
+	})
+	for _, idx := range order {
+		if leftover.Sign() <= 0 {
+			break
+		}
+		shares[idx].Add(shares[idx], big.NewInt(1))
+		leftover.Sub(leftover, big.NewInt(1))
+	}
+	return shares
+}
+
+// quantizeSlashedAmount rounds the slashed amount down to a multiple of the quantum per denomination
+func quantizeSlashedAmount(slashedAmount, quantum types.Coins) (quantized, remainder types.Coins) {
+	slashedAmount = slashedAmount.NoNil()
+	quantizedTheta, remainderTheta := quantizeForDenom(slashedAmount.ThetaWei, quantum.ThetaWei)
+	quantizedTFuel, remainderTFuel := quantizeForDenom(slashedAmount.TFuelWei, quantum.TFuelWei)
+
+	quantized = types.Coins{ThetaWei: quantizedTheta, TFuelWei: quantizedTFuel}
+	remainder = types.Coins{ThetaWei: remainderTheta, TFuelWei: remainderTFuel}
+	return quantized, remainder
+}
+
+func quantizeForDenom(amount, quantum *big.Int) (quantized, remainder *big.Int) {
+	if quantum.Sign() <= 0 {
+		return amount, big.NewInt(0)
+	}
+	remainder = new(big.Int).Mod(amount, quantum)
+	quantized = new(big.Int).Sub(amount, remainder)
+	return quantized, remainder
+}
+
+func splitForDenom(amount *big.Int, weights []uint64) []*big.Int {
+	shares := make([]*big.Int, len(weights))
+	totalWeight := new(big.Int)
+	for idx, weight := range weights {
+		shares[idx] = big.NewInt(0)
+		totalWeight.Add(totalWeight, new(big.Int).SetUint64(weight))
+	}
+	if totalWeight.Sign() == 0 {
+		shares[len(shares)-1] = new(big.Int).Set(amount)
+		return shares
+	}
+
+	remainder := new(big.Int).Set(amount)
+	for idx, weight := range weights {
+		share := new(big.Int).Mul(amount, new(big.Int).SetUint64(weight))
+		shares[idx] = share.Div(share, totalWeight)
+		remainder.Sub(remainder, shares[idx])
+	}
+	for idx, weight := range weights {
+		if weight > 0 {
+			shares[idx].Add(shares[idx], remainder)
+			break
+		}
+	}
+	return shares
+}
+
+func calcSlashedAmountForDenom(initialFund, usedFund, collateral *big.Int, overspent bool) (slashed, returned *big.Int) {
+	total := calcSlashableForDenom(initialFund, usedFund, collateral)
+
+	if overspent {
+		return total, big.NewInt(0)
+	}
+	return big.NewInt(0), total
+}
+
+func calcPartialSlashedAmountForDenom(initialFund, usedFund, collateral, overspentAmount *big.Int) (slashed, returned *big.Int) {
+	total := calcSlashableForDenom(initialFund, usedFund, collateral)
+
+	slashed = new(big.Int).Set(overspentAmount)
+	if slashed.Cmp(collateral) > 0 {
+		slashed.Set(collateral)
+	}
+	if slashed.Sign() < 0 {
+		slashed.SetInt64(0)
+	}
+	return slashed, new(big.Int).Sub(total, slashed)
+}
+
+func calcSlashableForDenom(initialFund, usedFund, collateral *big.Int) *big.Int {
+	remainingFund := new(big.Int).Sub(initialFund, usedFund)
+	if remainingFund.Sign() < 0 {
+		remainingFund = big.NewInt(0) // Should NOT happen, just to be on the safe side
+	}
+	return new(big.Int).Add(collateral, remainingFund)
+}
@@ -0,0 +1,929 @@
+package execution
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+func TestCalcSlashedAmountPerDenomination(t *testing.T) {
+	assert := assert.New(t)
+
+	reservedFund := types.ReservedFund{
+		Collateral:  types.NewCoins(200, 200),
+		InitialFund: types.NewCoins(100, 100),
+		UsedFund:    types.NewCoins(0, 40),
+	}
+
+	// Overspent in TFuel only, the Theta collateral and remaining fund are returned
+	slashedAmount, returnedAmount := calcSlashedAmount(&reservedFund, types.NewCoins(50, 150))
+	assert.True(types.NewCoins(0, 260).IsEqual(slashedAmount), slashedAmount.String())
+	assert.True(types.NewCoins(300, 0).IsEqual(returnedAmount), returnedAmount.String())
+
+	// Overspent in Theta only
+	slashedAmount, returnedAmount = calcSlashedAmount(&reservedFund, types.NewCoins(101, 100))
+	assert.True(types.NewCoins(300, 0).IsEqual(slashedAmount), slashedAmount.String())
+	assert.True(types.NewCoins(0, 260).IsEqual(returnedAmount), returnedAmount.String())
+
+	// Overspent in both denominations
+	slashedAmount, returnedAmount = calcSlashedAmount(&reservedFund, types.NewCoins(101, 101))
+	assert.True(types.NewCoins(300, 260).IsEqual(slashedAmount), slashedAmount.String())
+	assert.True(types.NewCoins(0, 0).IsEqual(returnedAmount), returnedAmount.String())
+}
+
+func TestSlashTxPerDenominationCollateral(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+
+	// Alice's reserved fund also holds Theta collateral, but the overspending is in TFuel only
+	thetaCollateral := int64(5000)
+	aliceAcc := et.state().Delivered().GetAccount(alice.Address)
+	aliceAcc.ReservedFunds[0].Collateral.ThetaWei = big.NewInt(thetaCollateral)
+	et.state().Delivered().SetAccount(alice.Address, aliceAcc)
+	et.state().Commit()
+
+	aliceAcc = et.state().Delivered().GetAccount(alice.Address)
+	aliceInitBalance := aliceAcc.Balance
+	reservedFund := aliceAcc.ReservedFunds[0]
+	proposerInitBalance := et.state().Delivered().GetAccount(proposer.Address).Balance
+
+	slashTx := createSlashTx(et.chainID, &proposer, 1, slashIntent.Address, slashIntent.ReserveSequence, slashIntent.Proof)
+	res := et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(slashTx).process(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	expectedSlashedTFuel := new(big.Int).Add(reservedFund.Collateral.TFuelWei, reservedFund.InitialFund.TFuelWei)
+	proposerBalance := et.state().Delivered().GetAccount(proposer.Address).Balance
+	assert.True(proposerInitBalance.Plus(types.Coins{ThetaWei: big.NewInt(0), TFuelWei: expectedSlashedTFuel}).IsEqual(proposerBalance))
+
+	aliceAcc = et.state().Delivered().GetAccount(alice.Address)
+	assert.Equal(0, len(aliceAcc.ReservedFunds))
+	assert.True(aliceInitBalance.Plus(types.NewCoins(thetaCollateral, 0)).IsEqual(aliceAcc.Balance))
+}
+
+func TestSlashTxRewardVesting(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	slashExec := et.executor.SlashTxExecutor()
+	slashExec.SetRewardVestingDuration(100)
+
+	proposerInitBalance := view.GetAccount(proposer.Address).Balance
+	reservedFund := view.GetAccount(alice.Address).ReservedFunds[0]
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	txHash, res := slashExec.process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// The reward is put into vesting instead of being credited to the proposer
+	assert.True(proposerInitBalance.IsEqual(view.GetAccount(proposer.Address).Balance))
+	vesting := view.GetSlashRewardVesting(txHash)
+	assert.NotNil(vesting)
+	assert.Equal(proposer.Address, vesting.Beneficiary)
+	assert.True(reservedFund.Collateral.Plus(reservedFund.InitialFund).IsEqual(vesting.Amount))
+	assert.True(vesting.Released.IsZero())
+	assert.Equal(view.Height(), vesting.StartBlockHeight)
+	assert.Equal(view.Height()+100, vesting.EndBlockHeight)
+	assert.Equal(0, len(view.GetAccount(alice.Address).ReservedFunds))
+}
+
+func TestSlashTxBatchRewardPayout(t *testing.T) {
+	assert := assert.New(t)
+
+	// The proposer files two slashes in the same block, against two reserved funds of Alice
+	slashTwice := func(batchRewardPayout bool) (*execTest, types.PrivAccount, types.Coins) {
+		et, resourceID, alice, bob, proposer, slashIntent := setupForSlash(assert)
+		view := et.state().Delivered()
+		et.executor.SlashTxExecutor().SetBatchRewardPayout(batchRewardPayout)
+
+		acc := view.GetAccount(alice.Address)
+		secondFund := acc.ReservedFunds[0]
+		secondFund.ReserveSequence = 2
+		secondFund.UsedFund = types.NewCoins(0, 0)
+		secondFund.TransferRecords = []types.TransferRecord{}
+		acc.ReservedFunds = append(acc.ReservedFunds, secondFund)
+		view.SetAccount(alice.Address, acc)
+		payment := createServicePaymentTx(et.chainID, &alice, &bob, 8000*getMinimumTxFee(), 1, 1, 1, 2, resourceID)
+		secondProof, err := types.ToBytes(&types.OverspendingProof{
+			ReserveSequence: 2,
+			ServicePayments: []types.ServicePaymentTx{*payment},
+		})
+		assert.Nil(err)
+
+		rewards := types.NewCoins(0, 0)
+		for idx, slashTx := range []*types.SlashTx{
+			createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof),
+			createSlashTx(et.chainID, &proposer, 2, alice.Address, 2, secondProof),
+		} {
+			res := et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, view, slashTx)
+			assert.True(res.IsOK(), res.Message)
+			_, res = et.executor.getTxExecutor(slashTx).process(et.chainID, view, slashTx)
+			assert.True(res.IsOK(), res.Message)
+			rewards = rewards.Plus(view.GetEvents()[idx].(*types.SlashEvent).ProposerAmount)
+		}
+		assert.False(rewards.IsZero())
+		return et, proposer, rewards
+	}
+
+	et, proposer, individualRewards := slashTwice(false)
+	individualBalance := et.state().Delivered().GetAccount(proposer.Address).Balance
+	assert.Equal(0, len(et.state().Delivered().GetPendingSlashRewards()))
+
+	// With the batch payout, the rewards are accumulated during the block, and credited at once at its end
+	et, proposer, accumulatedRewards := slashTwice(true)
+	view := et.state().Delivered()
+	assert.True(individualRewards.IsEqual(accumulatedRewards))
+	assert.True(individualBalance.Minus(accumulatedRewards).IsEqual(view.GetAccount(proposer.Address).Balance))
+	assert.True(accumulatedRewards.IsEqual(view.GetPendingSlashReward(proposer.Address)))
+
+	payouts := PayoutPendingSlashRewards(view)
+	assert.Equal(1, len(payouts))
+	assert.True(accumulatedRewards.IsEqual(payouts[proposer.Address]))
+	assert.True(individualBalance.IsEqual(view.GetAccount(proposer.Address).Balance))
+	assert.Equal(0, len(view.GetPendingSlashRewards()))
+	assert.True(view.GetPendingSlashReward(proposer.Address).IsZero())
+
+	// Nothing is paid twice
+	assert.Equal(0, len(PayoutPendingSlashRewards(view)))
+	assert.True(individualBalance.IsEqual(view.GetAccount(proposer.Address).Balance))
+
+	params := DefaultSlashParams()
+	params.BatchRewardPayout = true
+	params.RewardVestingDuration = 10
+	assert.NotNil(params.Validate())
+}
+
+func TestSlashTxShareRewardWithValidators(t *testing.T) {
+	assert := assert.New(t)
+
+	// Three validators of differing stakes, the proposer having the smallest one
+	dave := types.MakeAcc("User Dave")
+	slash := func(batchRewardPayout bool) (*execTest, types.PrivAccount, types.PrivAccount, types.Coins, result.Result) {
+		et, _, alice, bob, proposer, slashIntent := setupForSlash(assert)
+		view := et.state().Delivered()
+		valSet := core.NewValidatorSet()
+		valSet.AddValidator(core.NewValidator(proposer.Address.String(), big.NewInt(1000003)))
+		valSet.AddValidator(core.NewValidator(bob.Address.String(), big.NewInt(2000011)))
+		valSet.AddValidator(core.NewValidator(dave.Address.String(), big.NewInt(4000037)))
+		slashExec := et.executor.SlashTxExecutor()
+		slashExec.SetValidatorSetProvider(&testValidatorSetProvider{valSet: valSet})
+		slashExec.SetShareRewardWithValidators(true)
+		slashExec.SetBatchRewardPayout(batchRewardPayout)
+
+		distribution, res := slashExec.EstimateReward(et.chainID, view, alice.Address, slashIntent.Proof)
+		assert.True(res.IsOK(), res.Message)
+
+		slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+		res = slashExec.sanityCheck(et.chainID, view, slashTx)
+		assert.True(res.IsOK(), res.Message)
+		_, res = slashExec.process(et.chainID, view, slashTx)
+		assert.True(res.IsOK(), res.Message)
+		return et, bob, proposer, distribution.ProposerAmount, res
+	}
+
+	et, bob, proposer, reward, res := slash(false)
+	view := et.state().Delivered()
+	assert.False(reward.IsZero())
+
+	// The shares sum up exactly to the reward, each within a unit of the proportional share
+	validatorRewards := res.Info["validator_rewards"].([]ValidatorReward)
+	assert.Equal(3, len(validatorRewards))
+	stakes := map[common.Address]int64{proposer.Address: 1000003, bob.Address: 2000011, dave.Address: 4000037}
+	totalShared := types.NewCoins(0, 0)
+	for _, validatorReward := range validatorRewards {
+		totalShared = totalShared.Plus(validatorReward.Amount)
+		proportional := new(big.Int).Mul(reward.TFuelWei, big.NewInt(stakes[validatorReward.Address]))
+		proportional.Div(proportional, big.NewInt(1000003+2000011+4000037))
+		diff := new(big.Int).Sub(validatorReward.Amount.TFuelWei, proportional)
+		assert.True(diff.Sign() >= 0 && diff.Cmp(big.NewInt(1)) <= 0, diff.String())
+	}
+	assert.True(reward.IsEqual(totalShared), "%v != %v", reward, totalShared)
+
+	rewardOf := func(addr common.Address) types.Coins {
+		for _, validatorReward := range validatorRewards {
+			if validatorReward.Address == addr {
+				return validatorReward.Amount
+			}
+		}
+		return types.NewCoins(0, 0)
+	}
+	event := view.GetEvents()[0].(*types.SlashEvent)
+	assert.True(rewardOf(proposer.Address).IsEqual(event.ProposerAmount))
+	assert.True(rewardOf(dave.Address).IsEqual(view.GetAccount(dave.Address).Balance))
+	bobBalance := view.GetAccount(bob.Address).Balance
+
+	// With the batch payout, the shares are paid out at the end of the block
+	et, bob, _, _, res = slash(true)
+	view = et.state().Delivered()
+	assert.True(bobBalance.Minus(rewardOf(bob.Address)).IsEqual(view.GetAccount(bob.Address).Balance))
+	assert.True(rewardOf(bob.Address).IsEqual(view.GetPendingSlashReward(bob.Address)))
+	assert.True(rewardOf(dave.Address).IsEqual(view.GetPendingSlashReward(dave.Address)))
+	PayoutPendingSlashRewards(view)
+	assert.True(bobBalance.IsEqual(view.GetAccount(bob.Address).Balance))
+
+	params := DefaultSlashParams()
+	params.ShareRewardWithValidators = true
+	params.RewardVestingDuration = 10
+	assert.NotNil(params.Validate())
+}
+
+func TestSplitByStakeForDenom(t *testing.T) {
+	assert := assert.New(t)
+
+	split := func(amount int64, stakes ...int64) []int64 {
+		bigStakes := make([]*big.Int, len(stakes))
+		totalStake := big.NewInt(0)
+		for idx, stake := range stakes {
+			bigStakes[idx] = big.NewInt(stake)
+			totalStake.Add(totalStake, bigStakes[idx])
+		}
+		shares := []int64{}
+		for _, share := range splitByStakeForDenom(big.NewInt(amount), bigStakes, totalStake) {
+			shares = append(shares, share.Int64())
+		}
+		return shares
+	}
+
+	// 14.29, 28.57 and 57.14, the unit lost to rounding goes to the largest fractional part
+	assert.Equal([]int64{14, 29, 57}, split(100, 1, 2, 4))
+	// The ties go to the earlier shares
+	assert.Equal([]int64{1, 1, 0}, split(2, 1, 1, 1))
+	assert.Equal([]int64{0, 5, 0}, split(5, 0, 3, 0))
+	assert.Equal([]int64{0, 0}, split(0, 1, 1))
+}
+
+func TestSlashTxBond(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	slashExec := et.executor.SlashTxExecutor()
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	proposerInitBalance := view.GetAccount(proposer.Address).Balance
+
+	// The proposer cannot afford the bond
+	slashExec.SetSlashBond(proposerInitBalance.Plus(types.NewCoins(0, 1)), 100)
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeInsufficientFund, res.Code, res.Message)
+
+	bond := types.NewCoins(0, 100)
+	slashExec.SetSlashBond(bond, 100)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	reservedFund := view.GetAccount(alice.Address).ReservedFunds[0]
+	txHash, res := slashExec.process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// The bond is locked
+	expectedSlashedAmount := reservedFund.Collateral.Plus(reservedFund.InitialFund)
+	proposerBalance := view.GetAccount(proposer.Address).Balance
+	assert.True(proposerInitBalance.Plus(expectedSlashedAmount).Minus(bond).IsEqual(proposerBalance))
+	slashBond := view.GetSlashBond(txHash)
+	assert.NotNil(slashBond)
+	assert.Equal(proposer.Address, slashBond.Proposer)
+	assert.True(bond.IsEqual(slashBond.Amount))
+	assert.Equal(view.Height()+100, slashBond.ReturnBlockHeight)
+
+	// The bond of a bad slash is forfeited
+	forfeited, res := ForfeitSlashBond(view, txHash)
+	assert.True(res.IsOK(), res.Message)
+	assert.True(bond.IsEqual(forfeited))
+	assert.Nil(view.GetSlashBond(txHash))
+	assert.True(proposerBalance.IsEqual(view.GetAccount(proposer.Address).Balance))
+
+	_, res = ForfeitSlashBond(view, txHash)
+	assert.True(res.IsError(), res.Message)
+}
+
+func TestSlashTxFee(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	slashExec := et.executor.SlashTxExecutor()
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	proposerInitBalance := view.GetAccount(proposer.Address).Balance
+
+	// No fee by default
+	fee, res := et.executor.GetTxFee(slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.True(fee.IsZero())
+
+	// The proposer cannot afford the fee, the slashed amount cannot pay for it
+	slashExec.SetSlashFee(proposerInitBalance.Plus(types.NewCoins(0, 1)))
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeInsufficientFund, res.Code, res.Message)
+
+	// Neither can it afford the bond and the fee combined
+	slashExec.SetSlashBond(types.NewCoins(0, 1), 100)
+	slashExec.SetSlashFee(proposerInitBalance)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeInsufficientFund, res.Code, res.Message)
+	slashExec.SetSlashBond(types.NewCoins(0, 0), 0)
+
+	slashFee := types.NewCoins(0, 100)
+	slashExec.SetSlashFee(slashFee)
+	fee, res = et.executor.GetTxFee(slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.True(slashFee.IsEqual(fee))
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	reservedFund := view.GetAccount(alice.Address).ReservedFunds[0]
+	_, res = slashExec.process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// The fee is deducted from the proposer balance, and burnt
+	expectedSlashedAmount := reservedFund.Collateral.Plus(reservedFund.InitialFund)
+	proposerBalance := view.GetAccount(proposer.Address).Balance
+	assert.True(proposerInitBalance.Plus(expectedSlashedAmount).Minus(slashFee).IsEqual(proposerBalance))
+	assert.True(slashFee.IsEqual(view.GetSlashBurntSupply()))
+
+	// The fee of a BatchSlashTx covers each of its entries
+	batchSlashTx := createBatchSlashTx(et.chainID, &proposer, 2,
+		types.SlashEntry{SlashedAddress: alice.Address, ReserveSequence: 2},
+		types.SlashEntry{SlashedAddress: alice.Address, ReserveSequence: 3})
+	fee, res = et.executor.GetTxFee(batchSlashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.True(slashFee.Plus(slashFee).IsEqual(fee))
+}
+
+func TestSlashTxQuantization(t *testing.T) {
+	assert := assert.New(t)
+
+	txFee := getMinimumTxFee()
+	quantum := types.NewCoins(0, 7*txFee+3)
+
+	for _, policy := range []SlashRemainderPolicy{SlashRemainderReturned, SlashRemainderBurnt} {
+		et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+		view := et.state().Delivered()
+
+		slashExec := et.executor.SlashTxExecutor()
+		slashExec.SetSlashQuantization(quantum, policy)
+
+		reservedFund := view.GetAccount(alice.Address).ReservedFunds[0]
+		slashedAmount, returnedAmount := calcSlashedAmountForOverspending(&reservedFund, false, true)
+		remainder := new(big.Int).Mod(slashedAmount.TFuelWei, quantum.TFuelWei)
+		assert.True(remainder.Sign() > 0)
+		quantizedAmount := types.Coins{
+			ThetaWei: slashedAmount.ThetaWei,
+			TFuelWei: new(big.Int).Sub(slashedAmount.TFuelWei, remainder),
+		}
+
+		aliceBalance := view.GetAccount(alice.Address).Balance
+		proposerBalance := view.GetAccount(proposer.Address).Balance
+		slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+		res := slashExec.sanityCheck(et.chainID, view, slashTx)
+		assert.True(res.IsOK(), res.Message)
+		_, res = slashExec.process(et.chainID, view, slashTx)
+		assert.True(res.IsOK(), res.Message)
+
+		assert.True(proposerBalance.Plus(quantizedAmount).IsEqual(view.GetAccount(proposer.Address).Balance))
+		expectedAliceBalance := aliceBalance.Plus(returnedAmount)
+		if policy == SlashRemainderReturned {
+			expectedAliceBalance = expectedAliceBalance.Plus(types.Coins{ThetaWei: big.NewInt(0), TFuelWei: remainder})
+		}
+		assert.True(expectedAliceBalance.IsEqual(view.GetAccount(alice.Address).Balance))
+	}
+}
+
+func TestSlashTxQuantizationNilDenomination(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	// A quantum set without its Theta denomination does not quantize the Theta amount
+	txFee := getMinimumTxFee()
+	params := DefaultSlashParams()
+	params.Quantum = types.Coins{TFuelWei: big.NewInt(7*txFee + 3)}
+	slashExec := et.executor.SlashTxExecutor()
+	slashExec.SetParams(params)
+	assert.NotNil(slashExec.Params().Quantum.ThetaWei)
+
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = slashExec.process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(0, len(view.GetAccount(alice.Address).ReservedFunds))
+}
+
+func TestSlashTxSplit(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	slashExec := et.executor.SlashTxExecutor()
+	split := SlashSplit{BurnWeight: 10, TreasuryWeight: 85, ProposerWeight: 5}
+	treasury := types.MakeAcc("treasury").Address
+	slashExec.SetSlashSplit(split, treasury)
+	assert.Nil(view.GetAccount(treasury))
+
+	reservedFund := view.GetAccount(alice.Address).ReservedFunds[0]
+	slashedAmount, _ := calcSlashedAmountForOverspending(&reservedFund, false, true)
+	burntAmount, treasuryAmount, proposerFee := split.Split(slashedAmount)
+	assert.True(treasuryAmount.TFuelWei.Cmp(proposerFee.TFuelWei) > 0)
+
+	proposerBalance := view.GetAccount(proposer.Address).Balance
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = slashExec.process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.True(burntAmount.IsEqual(res.Info["burnt_amount"].(types.Coins)))
+	assert.True(treasuryAmount.IsEqual(res.Info["treasury_amount"].(types.Coins)))
+
+	// The treasury receives the bulk, the proposer only the finder's fee
+	assert.True(treasuryAmount.IsEqual(view.GetAccount(treasury).Balance))
+	assert.True(proposerBalance.Plus(proposerFee).IsEqual(view.GetAccount(proposer.Address).Balance))
+
+	// The reserved fund is removed exactly once
+	assert.Equal(0, len(view.GetAccount(alice.Address).ReservedFunds))
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError())
+
+	// The treasury may be the proposer itself
+	et, _, alice, _, proposer, slashIntent = setupForSlash(assert)
+	view = et.state().Delivered()
+	slashExec = et.executor.SlashTxExecutor()
+	slashExec.SetSlashSplit(split, proposer.Address)
+
+	proposerBalance = view.GetAccount(proposer.Address).Balance
+	slashTx = createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	_, res = slashExec.process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.True(proposerBalance.Plus(treasuryAmount).Plus(proposerFee).IsEqual(view.GetAccount(proposer.Address).Balance))
+}
+
+func TestSlashTxZeroAddressTreasury(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	slashExec := et.executor.SlashTxExecutor()
+	split := SlashSplit{BurnWeight: 10, TreasuryWeight: 85, ProposerWeight: 5}
+	slashExec.SetSlashSplit(split, common.Address{})
+
+	reservedFund := view.GetAccount(alice.Address).ReservedFunds[0]
+	slashedAmount, _ := calcSlashedAmountForOverspending(&reservedFund, false, true)
+	burntAmount, treasuryAmount, proposerFee := split.Split(slashedAmount)
+	assert.False(treasuryAmount.IsZero())
+
+	// The treasury share sent to the zero address is burnt, no account is created for it
+	proposerBalance := view.GetAccount(proposer.Address).Balance
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	executeSlashWithInvariants(assert, et, view, slashTx, burntAmount.Plus(treasuryAmount))
+	assert.Nil(view.GetAccount(common.Address{}))
+	assert.True(burntAmount.Plus(treasuryAmount).IsEqual(view.GetSlashBurntSupply()))
+	assert.True(proposerBalance.Plus(proposerFee).IsEqual(view.GetAccount(proposer.Address).Balance))
+
+	events := view.GetEvents()
+	assert.Equal(1, len(events))
+	event := events[0].(*types.SlashEvent)
+	assert.True(event.TreasuryAmount.IsZero())
+	assert.True(burntAmount.Plus(treasuryAmount).IsEqual(event.BurntAmount))
+}
+
+func TestSlashTxMissingTreasury(t *testing.T) {
+	assert := assert.New(t)
+	split := SlashSplit{BurnWeight: 10, TreasuryWeight: 85, ProposerWeight: 5}
+	treasury := types.MakeAcc("treasury")
+
+	// With the policy enabled, the treasury share is burnt if the treasury has no account
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+	slashExec := et.executor.SlashTxExecutor()
+	slashExec.SetSlashSplit(split, treasury.Address)
+	slashExec.SetBurnIfTreasuryMissing(true)
+
+	reservedFund := view.GetAccount(alice.Address).ReservedFunds[0]
+	slashedAmount, _ := calcSlashedAmountForOverspending(&reservedFund, false, true)
+	burntAmount, treasuryAmount, _ := split.Split(slashedAmount)
+	assert.False(treasuryAmount.IsZero())
+
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	executeSlashWithInvariants(assert, et, view, slashTx, burntAmount.Plus(treasuryAmount))
+	assert.Nil(view.GetAccount(treasury.Address))
+	assert.True(burntAmount.Plus(treasuryAmount).IsEqual(view.GetSlashBurntSupply()))
+	event := view.GetEvents()[0].(*types.SlashEvent)
+	assert.True(event.TreasuryAmount.IsZero())
+	assert.True(burntAmount.Plus(treasuryAmount).IsEqual(event.BurntAmount))
+
+	// An existing treasury account is credited as usual
+	et, _, alice, _, proposer, slashIntent = setupForSlash(assert)
+	et.acc2State(treasury)
+	view = et.state().Delivered()
+	slashExec = et.executor.SlashTxExecutor()
+	slashExec.SetSlashSplit(split, treasury.Address)
+	slashExec.SetBurnIfTreasuryMissing(true)
+	treasuryBalance := view.GetAccount(treasury.Address).Balance
+
+	slashTx = createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	executeSlashWithInvariants(assert, et, view, slashTx, burntAmount.Plus(treasuryAmount)) // leaves the slashed and proposer accounts
+	assert.True(treasuryBalance.Plus(treasuryAmount).IsEqual(view.GetAccount(treasury.Address).Balance))
+	assert.True(burntAmount.IsEqual(view.GetSlashBurntSupply()))
+}
+
+func TestSlashTxNativeProposerRewardCap(t *testing.T) {
+	assert := assert.New(t)
+
+	// The Theta share is routed to the treasury, so is the TFuel in excess of the cap
+	reward := types.NewCoins(300, 500)
+	capped, excess := capNativeProposerReward(reward, big.NewInt(200))
+	assert.True(types.NewCoins(0, 200).IsEqual(capped))
+	assert.True(types.NewCoins(300, 300).IsEqual(excess))
+	capped, excess = capNativeProposerReward(reward, big.NewInt(1000))
+	assert.True(types.NewCoins(0, 500).IsEqual(capped))
+	assert.True(types.NewCoins(300, 0).IsEqual(excess))
+
+	treasury := types.MakeAcc("treasury").Address
+	for _, underCap := range []bool{true, false} {
+		et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+		view := et.state().Delivered()
+		slashExec := et.executor.SlashTxExecutor()
+		slashExec.SetSlashSplit(SlashSplit{}, treasury)
+
+		reservedFund := view.GetAccount(alice.Address).ReservedFunds[0]
+		slashedAmount, _ := calcSlashedAmountForOverspending(&reservedFund, false, true)
+		assert.True(slashedAmount.TFuelWei.Sign() > 0)
+		maxReward := new(big.Int).Add(slashedAmount.TFuelWei, big.NewInt(1))
+		if !underCap {
+			maxReward = new(big.Int).Div(slashedAmount.TFuelWei, big.NewInt(3))
+		}
+		slashExec.SetMaxNativeProposerReward(maxReward)
+		proposerAmount, treasuryAmount := capNativeProposerReward(slashedAmount, maxReward)
+
+		proposerBalance := view.GetAccount(proposer.Address).Balance
+		slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+		res := slashExec.sanityCheck(et.chainID, view, slashTx)
+		assert.True(res.IsOK(), res.Message)
+		_, res = slashExec.process(et.chainID, view, slashTx)
+		assert.True(res.IsOK(), res.Message)
+
+		assert.True(proposerBalance.Plus(proposerAmount).IsEqual(view.GetAccount(proposer.Address).Balance))
+		assert.True(treasuryAmount.IsEqual(res.Info["treasury_amount"].(types.Coins)))
+		if underCap {
+			assert.True(slashedAmount.IsEqual(proposerAmount))
+			assert.Nil(view.GetAccount(treasury))
+		} else {
+			assert.Equal(0, maxReward.Cmp(proposerAmount.TFuelWei))
+			assert.True(treasuryAmount.IsEqual(view.GetAccount(treasury).Balance))
+		}
+	}
+}
+
+func TestSlashTxCommitmentBound(t *testing.T) {
+	assert := assert.New(t)
+
+	slashWithUsedFund := func(usedFund types.Coins) (result.Result, types.ReservedFund) {
+		et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+		view := et.state().Delivered()
+		slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+		res := et.executor.SlashTxExecutor().sanityCheck(et.chainID, view, slashTx)
+		assert.True(res.IsOK(), res.Message)
+
+		acc := view.GetAccount(alice.Address)
+		acc.ReservedFunds[0].UsedFund = usedFund
+		view.SetAccount(alice.Address, acc)
+		_, res = et.executor.SlashTxExecutor().process(et.chainID, view, slashTx)
+		return res, acc.ReservedFunds[0]
+	}
+
+	// Nothing used: the whole commitment is seized
+	res, reservedFund := slashWithUsedFund(types.NewCoins(0, 0))
+	assert.True(res.IsOK(), res.Message)
+	commitment := reservedFund.InitialFund.Plus(reservedFund.Collateral)
+	seized := res.Info["slashed_amount"].(types.Coins).Plus(res.Info["returned_amount"].(types.Coins))
+	assert.True(commitment.IsEqual(seized))
+
+	// More used than reserved: at most the collateral is seized
+	res, reservedFund = slashWithUsedFund(reservedFund.InitialFund.Plus(types.NewCoins(1, 1)))
+	assert.True(res.IsOK(), res.Message)
+	seized = res.Info["slashed_amount"].(types.Coins).Plus(res.Info["returned_amount"].(types.Coins))
+	assert.True(reservedFund.Collateral.IsEqual(seized))
+
+	// A negative used fund, which cannot even be stored, would credit coins that were never reserved
+	reservedFund.UsedFund = types.NewCoins(0, -1)
+	slashedAmount, returnedAmount := calcSlashedAmountForOverspending(&reservedFund, true, true)
+	res = checkSlashedCommitment(&reservedFund, slashedAmount, returnedAmount)
+	assert.True(res.IsError())
+	assert.Contains(res.Message, "exceeds the commitment")
+}
+
+func TestSlashTxBurntSupply(t *testing.T) {
+	assert := assert.New(t)
+
+	// Rewarding the proposer with the slashed amount does not change the supply
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+	slashExec := et.executor.SlashTxExecutor()
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	_, res := slashExec.process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.True(view.GetSlashBurntSupply().IsZero())
+
+	// Burning the slashed amount reduces the supply
+	et, _, alice, _, proposer, slashIntent = setupForSlash(assert)
+	view = et.state().Delivered()
+	slashExec = et.executor.SlashTxExecutor()
+	split := SlashSplit{BurnWeight: 10, ProposerWeight: 90}
+	slashExec.SetSlashSplit(split, common.Address{})
+	reservedFund := view.GetAccount(alice.Address).ReservedFunds[0]
+	slashedAmount, _ := calcSlashedAmountForOverspending(&reservedFund, false, true)
+	burntAmount, _, _ := split.Split(slashedAmount)
+	assert.False(burntAmount.IsZero())
+
+	slashTx = createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	executeSlashWithInvariants(assert, et, view, slashTx, burntAmount)
+	assert.True(burntAmount.IsEqual(view.GetSlashBurntSupply()))
+
+	// So does a forfeited bond, on top of the burnt amount
+	bond := types.NewCoins(0, 100)
+	view.SetSlashBond(slashTx.ID(et.chainID), &types.SlashBond{Proposer: proposer.Address, Amount: bond})
+	_, res = ForfeitSlashBond(view, slashTx.ID(et.chainID))
+	assert.True(res.IsOK(), res.Message)
+	assert.True(burntAmount.Plus(bond).IsEqual(view.GetSlashBurntSupply()))
+
+	// The burnt supply is committed along with the state
+	et.state().Commit()
+	assert.True(burntAmount.Plus(bond).IsEqual(et.state().Delivered().GetSlashBurntSupply()))
+}
+
+func TestSlashTxLargeAmounts(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+	slashExec := et.executor.SlashTxExecutor()
+	treasury := types.MakeAcc("Treasury").Address
+	slashExec.SetSlashSplit(SlashSplit{BurnWeight: 10, TreasuryWeight: 60, ProposerWeight: 30}, treasury)
+
+	// The coins are arbitrary-precision, so the amounts around and beyond the maximum 256-bit integer
+	// are slashed and credited exactly, nothing wraps around
+	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	aliceAccount := view.GetAccount(alice.Address)
+	aliceAccount.ReservedFunds[0].Collateral = types.Coins{ThetaWei: big.NewInt(0), TFuelWei: new(big.Int).Set(maxUint256)}
+	view.SetAccount(alice.Address, aliceAccount)
+	proposerAccount := view.GetAccount(proposer.Address)
+	proposerAccount.Balance.TFuelWei = new(big.Int).Set(maxUint256)
+	view.SetAccount(proposer.Address, proposerAccount)
+
+	reservedFund := aliceAccount.ReservedFunds[0]
+	expectedSlashedAmount := reservedFund.Collateral.Plus(reservedFund.InitialFund.Minus(reservedFund.UsedFund))
+	assert.True(expectedSlashedAmount.TFuelWei.Cmp(maxUint256) > 0)
+
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = slashExec.process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	events := view.GetEvents()
+	assert.Equal(1, len(events))
+	event := events[0].(*types.SlashEvent)
+	assert.True(expectedSlashedAmount.IsEqual(event.SlashedAmount))
+	assert.True(event.SlashedAmount.IsEqual(event.BurntAmount.Plus(event.TreasuryAmount).Plus(event.ProposerAmount)))
+	for _, amount := range []types.Coins{event.BurntAmount, event.TreasuryAmount, event.ProposerAmount} {
+		assert.True(amount.IsNonnegative())
+	}
+
+	expectedProposerBalance := proposerAccount.Balance.Plus(event.ProposerAmount)
+	assert.True(expectedProposerBalance.TFuelWei.Cmp(maxUint256) > 0)
+
+	// The balances are stored exactly too
+	et.state().Commit()
+	view = et.state().Delivered()
+	assert.True(expectedProposerBalance.IsEqual(view.GetAccount(proposer.Address).Balance))
+	assert.True(event.TreasuryAmount.IsEqual(view.GetAccount(treasury).Balance))
+	assert.Equal(0, len(view.GetAccount(alice.Address).ReservedFunds))
+}
+
+func TestQuantizeSlashedAmount(t *testing.T) {
+	assert := assert.New(t)
+
+	quantized, remainder := quantizeSlashedAmount(types.NewCoins(25, 1000), types.NewCoins(10, 0))
+	assert.True(types.NewCoins(20, 1000).IsEqual(quantized))
+	assert.True(types.NewCoins(5, 0).IsEqual(remainder))
+
+	// Amounts already quantized are left as is
+	quantized, remainder = quantizeSlashedAmount(types.NewCoins(30, 1000), types.NewCoins(10, 100))
+	assert.True(types.NewCoins(30, 1000).IsEqual(quantized))
+	assert.True(remainder.IsZero())
+}
+
+func TestSlashSplit(t *testing.T) {
+	assert := assert.New(t)
+
+	// 1000 split 1:1:1, the remainder of 1 goes to the burn
+	burnt, treasury, proposer := SlashSplit{1, 1, 1}.Split(types.NewCoins(1000, 10))
+	assert.True(types.NewCoins(334, 4).IsEqual(burnt))
+	assert.True(types.NewCoins(333, 3).IsEqual(treasury))
+	assert.True(types.NewCoins(333, 3).IsEqual(proposer))
+
+	// Without a burn share, the remainder goes to the treasury
+	burnt, treasury, proposer = SlashSplit{0, 2, 1}.Split(types.NewCoins(100, 0))
+	assert.True(burnt.IsZero())
+	assert.True(types.NewCoins(67, 0).IsEqual(treasury))
+	assert.True(types.NewCoins(33, 0).IsEqual(proposer))
+
+	// Without any weight, the proposer gets the whole amount
+	burnt, treasury, proposer = SlashSplit{}.Split(types.NewCoins(100, 7))
+	assert.True(burnt.IsZero())
+	assert.True(treasury.IsZero())
+	assert.True(types.NewCoins(100, 7).IsEqual(proposer))
+}
+
+func TestSlashSplitConservesAmount(t *testing.T) {
+	assert := assert.New(t)
+	rng := rand.New(rand.NewSource(254))
+
+	randomWeight := func() uint64 {
+		switch rng.Intn(4) {
+		case 0:
+			return 0
+		case 1:
+			return math.MaxUint64 - uint64(rng.Intn(10))
+		default:
+			return uint64(rng.Intn(1000))
+		}
+	}
+	randomAmount := func() *big.Int {
+		return new(big.Int).Rand(rng, new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil))
+	}
+
+	for i := 0; i < 1000; i++ {
+		split := SlashSplit{randomWeight(), randomWeight(), randomWeight()}
+		amount := types.Coins{ThetaWei: randomAmount(), TFuelWei: randomAmount()}
+		burnt, treasury, proposer := split.Split(amount)
+
+		assert.True(burnt.IsNonnegative() && treasury.IsNonnegative() && proposer.IsNonnegative(), "%v %v", split, amount)
+		assert.True(amount.IsEqual(burnt.Plus(treasury).Plus(proposer)), "%v %v", split, amount)
+
+		// The shares are deterministic
+		burnt2, treasury2, proposer2 := split.Split(amount)
+		assert.True(burnt.IsEqual(burnt2) && treasury.IsEqual(treasury2) && proposer.IsEqual(proposer2))
+	}
+}
+
+func TestSlashTxZeroCollateral(t *testing.T) {
+	assert := assert.New(t)
+
+	setupZeroCollateral := func() (*execTest, *st.StoreView, *types.SlashTx, types.PrivAccount, types.PrivAccount) {
+		et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+		view := et.state().Delivered()
+
+		// A legacy reserved fund without collateral
+		acc := view.GetAccount(alice.Address)
+		acc.ReservedFunds[0].Collateral = types.NewCoins(0, 0)
+		view.SetAccount(alice.Address, acc)
+
+		slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+		return et, view, slashTx, alice, proposer
+	}
+
+	// By default, only the remaining fund is slashed
+	et, view, slashTx, alice, proposer := setupZeroCollateral()
+	slashExec := et.executor.SlashTxExecutor()
+	reservedFund := view.GetAccount(alice.Address).ReservedFunds[0]
+	remainingFund := reservedFund.InitialFund.Minus(reservedFund.UsedFund)
+	proposerBalance := view.GetAccount(proposer.Address).Balance
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = slashExec.process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.True(proposerBalance.Plus(remainingFund).IsEqual(view.GetAccount(proposer.Address).Balance))
+	assert.Equal(0, len(view.GetAccount(alice.Address).ReservedFunds))
+
+	// Rejected under the policy
+	et, view, slashTx, _, _ = setupZeroCollateral()
+	slashExec = et.executor.SlashTxExecutor()
+	slashExec.SetRejectZeroCollateral(true)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeSlashZeroCollateral, res.Code, res.Message)
+}
+
+func TestSlashTxPartiallyUsedReservedFund(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, bob, _, _, _, _ := setupForServicePayment(assert)
+	proposer := et.accProposer
+	et.acc2State(proposer)
+
+	txFee := getMinimumTxFee()
+	reserveFundTx := createReserveFundTx(et.chainID, &alice, 1000*txFee, 1001*txFee, 2, []string{"rid002"})
+	res := et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(reserveFundTx).process(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+	et.state().Commit()
+
+	// Bob legitimately settles a payment against the reserved fund
+	settledPayment := createServicePaymentTx(et.chainID, &alice, &bob, 600*txFee, 1, 1, 1, 2, "rid002")
+	res = et.executor.getTxExecutor(settledPayment).sanityCheck(et.chainID, et.state().Delivered(), settledPayment)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(settledPayment).process(et.chainID, et.state().Delivered(), settledPayment)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
+	et.state().Commit()
+
+	view := et.state().Delivered()
+	createProof := func(payments ...*types.ServicePaymentTx) common.Bytes {
+		overspendingProof := &types.OverspendingProof{ReserveSequence: 2}
+		for _, payment := range payments {
+			overspendingProof.ServicePayments = append(overspendingProof.ServicePayments, *payment)
+		}
+		proof, err := types.ToBytes(overspendingProof)
+		assert.Nil(err)
+		return proof
+	}
+	slashExec := et.executor.SlashTxExecutor()
+
+	// The claimed payment alone is within the reserved fund, but not together with the settled one
+	claimedPayment := createServicePaymentTx(et.chainID, &alice, &bob, 500*txFee, 1, 2, 2, 2, "rid002")
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 2, createProof(claimedPayment))
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// Including the settled payment in the proof does not count it twice
+	claimedPayment = createServicePaymentTx(et.chainID, &alice, &bob, 300*txFee, 1, 2, 2, 2, "rid002")
+	slashTx = createSlashTx(et.chainID, &proposer, 1, alice.Address, 2, createProof(settledPayment, claimedPayment))
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeSlashNotOverspent, res.Code, res.Message)
+
+	slashTx = createSlashTx(et.chainID, &proposer, 1, alice.Address, 2, createProof(settledPayment))
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeSlashNotOverspent, res.Code, res.Message)
+
+	// Settled payments in the proof still count towards the overspending
+	claimedPayment = createServicePaymentTx(et.chainID, &alice, &bob, 500*txFee, 1, 2, 2, 2, "rid002")
+	slashTx = createSlashTx(et.chainID, &proposer, 1, alice.Address, 2, createProof(settledPayment, claimedPayment))
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+}
+
+func TestSlashTxPartialSlash(t *testing.T) {
+	assert := assert.New(t)
+	txFee := getMinimumTxFee()
+
+	// The reserved fund of alice holds 1000*txFee with a collateral of 1001*txFee, it is overspent by less
+	// than the collateral, then by more
+	for _, paymentAmount := range []int64{1500, 8000} {
+		et, resourceID, alice, bob, _, _, _, _ := setupForServicePayment(assert)
+		proposer := et.accProposer
+		et.acc2State(proposer)
+		et.state().Commit()
+
+		servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &bob, paymentAmount*txFee, 1, 1, 1, 1, resourceID)
+		res := et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+		assert.True(res.IsOK(), res.Message)
+		_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+		assert.True(res.IsOK(), res.Message)
+		slashIntent := et.state().Delivered().GetSlashIntents()[0]
+		et.state().Commit()
+
+		view := et.state().Delivered()
+		slashExec := et.executor.SlashTxExecutor()
+		fullSlash, res := slashExec.EstimateReward(et.chainID, view, alice.Address, slashIntent.Proof)
+		assert.True(res.IsOK(), res.Message)
+		slashExec.SetPartialSlash(true)
+		partialSlash, res := slashExec.EstimateReward(et.chainID, view, alice.Address, slashIntent.Proof)
+		assert.True(res.IsOK(), res.Message)
+
+		// The full slash seizes the collateral and the remaining fund, the partial slash only the overspent
+		// amount up to the collateral, and returns the rest
+		commitment := types.NewCoins(0, 2001*txFee)
+		assert.True(commitment.IsEqual(fullSlash.SlashedAmount), fullSlash.SlashedAmount.String())
+		assert.True(fullSlash.ReturnedAmount.IsZero())
+		overspentAmount := paymentAmount - 1000
+		if overspentAmount > 1001 {
+			overspentAmount = 1001
+		}
+		expectedSlashedAmount := types.NewCoins(0, overspentAmount*txFee)
+		assert.True(expectedSlashedAmount.IsEqual(partialSlash.SlashedAmount), partialSlash.SlashedAmount.String())
+		assert.True(commitment.Minus(expectedSlashedAmount).IsEqual(partialSlash.ReturnedAmount))
+
+		// The SlashTx executes as estimated
+		aliceBalance := view.GetAccount(alice.Address).Balance
+		proposerBalance := view.GetAccount(proposer.Address).Balance
+		slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+		res = slashExec.sanityCheck(et.chainID, view, slashTx)
+		assert.True(res.IsOK(), res.Message)
+		_, res = slashExec.process(et.chainID, view, slashTx)
+		assert.True(res.IsOK(), res.Message)
+
+		aliceAccount := view.GetAccount(alice.Address)
+		assert.Equal(0, len(aliceAccount.ReservedFunds))
+		assert.True(aliceBalance.Plus(partialSlash.ReturnedAmount).IsEqual(aliceAccount.Balance))
+		assert.True(proposerBalance.Plus(partialSlash.ProposerAmount).IsEqual(view.GetAccount(proposer.Address).Balance))
+	}
+}
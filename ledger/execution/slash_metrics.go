@@ -0,0 +1,170 @@
+package execution
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/thetatoken/theta/common/metrics"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// ------------------------------- Slash Metrics -----------------------------------
+
+// SlashRejectionReason classifies the rejections of the SlashTxs for the metrics
+type SlashRejectionReason string
+
+const (
+	SlashRejectionBadSignature         SlashRejectionReason = "bad_signature"          // the proposer signature does not verify
+	SlashRejectionFundNotFound         SlashRejectionReason = "fund_not_found"         // the slashed account or reserved fund does not exist
+	SlashRejectionInvalidProof         SlashRejectionReason = "invalid_proof"          // the slash proof is malformed or does not prove the offense
+	SlashRejectionNonValidatorProposer SlashRejectionReason = "non_validator_proposer" // the proposer is not a validator
+	SlashRejectionOther                SlashRejectionReason = "other"
+)
+
+// SlashRejectionReasons lists the reasons the rejections are broken down by
+var SlashRejectionReasons = []SlashRejectionReason{
+	SlashRejectionBadSignature,
+	SlashRejectionFundNotFound,
+	SlashRejectionInvalidProof,
+	SlashRejectionNonValidatorProposer,
+	SlashRejectionOther,
+}
+
+const slashRejectionInfoKey = "slash_rejection"
+
+// withSlashRejection tags the error result with the rejection reason
+func withSlashRejection(res result.Result, reason SlashRejectionReason) result.Result {
+	if res.IsOK() {
+		return res
+	}
+	info := make(result.Info, len(res.Info)+1)
+	for k, v := range res.Info {
+		info[k] = v
+	}
+	info[slashRejectionInfoKey] = reason
+	res.Info = info
+	return res
+}
+
+// SlashRejectionReasonOf classifies the rejection of a SlashTx from the result of its sanity check
+func SlashRejectionReasonOf(res result.Result) SlashRejectionReason {
+	if reason, ok := res.Info[slashRejectionInfoKey].(SlashRejectionReason); ok {
+		return reason
+	}
+	switch res.Code {
+	case result.CodeSlashWrongReserveSequence, result.CodeSlashNotOverspent, result.CodeSlashReasonMismatch,
+		result.CodeSlashReserveTxHashMismatch, result.CodeSlashUnsupportedSignatureScheme,
+		result.CodeSlashProofTimestampOutOfSkew:
+		return SlashRejectionInvalidProof
+	}
+	return SlashRejectionOther
+}
+
+// SlashMetrics receives the slashing metrics of the SlashTxExecutor. The attempts are the sanity checks of
+// the SlashTxs, so a SlashTx is counted once when it is screened for the mempool and once when it is
+// delivered. The retryable failures are not counted as rejections. The host implements it to export the
+// metrics, or wires the SlashCounters to its metrics registry
+type SlashMetrics interface {
+	SlashAttempted()
+	SlashRejected(reason SlashRejectionReason)
+	SlashSucceeded(slashedAmount types.Coins)
+}
+
+var _ SlashMetrics = (*SlashCounters)(nil)
+
+// SlashCounters counts the slash attempts, successes and rejections, and sums up the slashed amounts
+type SlashCounters struct {
+	mu            sync.Mutex
+	attempts      int64
+	successes     int64
+	slashedAmount types.Coins
+	rejections    map[SlashRejectionReason]int64
+}
+
+// SlashCountersSnapshot is a copy of the SlashCounters at some point in time
+type SlashCountersSnapshot struct {
+	Attempts      int64
+	Successes     int64
+	SlashedAmount types.Coins
+	Rejections    map[SlashRejectionReason]int64
+}
+
+// NewSlashCounters creates a new instance of SlashCounters
+func NewSlashCounters() *SlashCounters {
+	return &SlashCounters{
+		slashedAmount: types.NewCoins(0, 0),
+		rejections:    make(map[SlashRejectionReason]int64),
+	}
+}
+
+// SlashAttempted implements the SlashMetrics interface
+func (c *SlashCounters) SlashAttempted() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.attempts++
+}
+
+// SlashRejected implements the SlashMetrics interface
+func (c *SlashCounters) SlashRejected(reason SlashRejectionReason) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rejections[reason]++
+}
+
+// SlashSucceeded implements the SlashMetrics interface
+func (c *SlashCounters) SlashSucceeded(slashedAmount types.Coins) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.successes++
+	c.slashedAmount = c.slashedAmount.Plus(slashedAmount.NoNil())
+}
+
+// Snapshot returns a copy of the counters
+func (c *SlashCounters) Snapshot() SlashCountersSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rejections := make(map[SlashRejectionReason]int64, len(c.rejections))
+	for reason, count := range c.rejections {
+		rejections[reason] = count
+	}
+	return SlashCountersSnapshot{
+		Attempts:      c.attempts,
+		Successes:     c.successes,
+		SlashedAmount: c.slashedAmount.Plus(types.NewCoins(0, 0)),
+		Rejections:    rejections,
+	}
+}
+
+// Register registers the counters as gauges of the registry under the given prefix, e.g. "ledger/slash/",
+// for the host to export them along with its other metrics. The slashed amounts are in wei, as float64
+// since they may exceed the int64 range
+func (c *SlashCounters) Register(prefix string, r metrics.Registry) error {
+	gauges := map[string]interface{}{
+		"attempts":  metrics.NewFunctionalGauge(func() int64 { return c.Snapshot().Attempts }),
+		"successes": metrics.NewFunctionalGauge(func() int64 { return c.Snapshot().Successes }),
+		"slashed/thetawei": metrics.NewFunctionalGaugeFloat64(func() float64 {
+			return weiToFloat64(c.Snapshot().SlashedAmount.ThetaWei)
+		}),
+		"slashed/tfuelwei": metrics.NewFunctionalGaugeFloat64(func() float64 {
+			return weiToFloat64(c.Snapshot().SlashedAmount.TFuelWei)
+		}),
+	}
+	for _, reason := range SlashRejectionReasons {
+		reason := reason
+		gauges["rejections/"+string(reason)] = metrics.NewFunctionalGauge(func() int64 {
+			return c.Snapshot().Rejections[reason]
+		})
+	}
+	for name, gauge := range gauges {
+		if err := r.Register(prefix+name, gauge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func weiToFloat64(amount *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(amount).Float64()
+	return f
+}
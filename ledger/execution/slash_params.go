@@ -0,0 +1,325 @@
+package execution
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// SlashParams are the per-chain slashing rules the SlashTxExecutor enforces. All the nodes of a chain must
+// run with the same parameters, so they are meant to be distributed along with the genesis. The zero value
+// of a parameter disables the corresponding rule, see the SlashTxExecutor setters for the details.
+type SlashParams struct {
+	MaxReservedFundAge            uint64                   `json:"max_reserved_fund_age"`    // in blocks
+	GracePeriod                   uint64                   `json:"grace_period"`             // in blocks
+	DisputeWindow                 uint64                   `json:"dispute_window"`           // in blocks
+	MaxProofTimestampSkew         uint64                   `json:"max_proof_timestamp_skew"` // in seconds
+	RewardVestingDuration         uint64                   `json:"reward_vesting_duration"`  // in blocks
+	Bond                          types.Coins              `json:"bond"`
+	BondLockPeriod                uint64                   `json:"bond_lock_period"` // in blocks
+	Quantum                       types.Coins              `json:"quantum"`
+	RemainderPolicy               SlashRemainderPolicy     `json:"remainder_policy"`
+	Split                         SlashSplit               `json:"split"`
+	Treasury                      common.Address           `json:"treasury"`
+	BurnIfTreasuryMissing         bool                     `json:"burn_if_treasury_missing"`
+	MinCollateral                 types.Coins              `json:"min_collateral"`
+	MinProposerStake              *big.Int                 `json:"min_proposer_stake"`
+	RejectZeroCollateral          bool                     `json:"reject_zero_collateral"`
+	RejectConflictedProposer      bool                     `json:"reject_conflicted_proposer"`
+	MarginalOverspendMargin       types.Coins              `json:"marginal_overspend_margin"`
+	AttestUnderspend              bool                     `json:"attest_underspend"`
+	RequireReserveTxHash          bool                     `json:"require_reserve_tx_hash"`
+	FlagStatus                    types.AccountSlashStatus `json:"flag_status"`
+	FlagDuration                  uint64                   `json:"flag_duration"` // in blocks
+	UnslashableAddresses          []common.Address         `json:"unslashable_addresses"`
+	SlashEvidenceExpiryDuration   uint64                   `json:"slash_evidence_expiry_duration"` // in blocks
+	MaxBatchSlashTxEntries        int                      `json:"max_batch_slash_tx_entries"`
+	Fee                           types.Coins              `json:"fee"`
+	MaxProofVerificationsPerBlock uint64                   `json:"max_proof_verifications_per_block"`
+	MaxNativeProposerReward       *big.Int                 `json:"max_native_proposer_reward"` // in TFuelWei
+	BatchRewardPayout             bool                     `json:"batch_reward_payout"`
+	ShareRewardWithValidators     bool                     `json:"share_reward_with_validators"`
+	PartialSlash                  bool                     `json:"partial_slash"`
+}
+
+// DefaultSlashParams returns the parameters the SlashTxExecutor runs with unless configured otherwise
+func DefaultSlashParams() SlashParams {
+	return SlashParams{
+		Bond:                        types.NewCoins(0, 0),
+		Quantum:                     types.NewCoins(0, 0),
+		MinCollateral:               types.NewCoins(0, 0),
+		MarginalOverspendMargin:     types.NewCoins(0, 0),
+		Fee:                         types.NewCoins(0, 0),
+		SlashEvidenceExpiryDuration: types.SlashEvidenceExpiryDuration,
+		MaxBatchSlashTxEntries:      types.MaximumBatchSlashTxEntries,
+	}
+}
+
+// LoadSlashParams loads the parameters from their JSON encoding, e.g. from the chain parameters distributed
+// along with the genesis. The parameters absent from the encoding keep their default values.
+func LoadSlashParams(data []byte) (SlashParams, error) {
+	params := DefaultSlashParams()
+	if err := json.Unmarshal(data, &params); err != nil {
+		return SlashParams{}, errors.Wrap(err, "Failed to parse the slash parameters")
+	}
+	params.normalize()
+	if err := params.Validate(); err != nil {
+		return SlashParams{}, err
+	}
+	return params, nil
+}
+
+// normalize replaces the nil denominations of the amounts with zeros, which disable the corresponding rules
+func (params *SlashParams) normalize() {
+	params.Bond = params.Bond.NoNil()
+	params.Quantum = params.Quantum.NoNil()
+	params.MinCollateral = params.MinCollateral.NoNil()
+	params.MarginalOverspendMargin = params.MarginalOverspendMargin.NoNil()
+	params.Fee = params.Fee.NoNil()
+}
+
+// Validate verifies the parameters are consistent
+func (params *SlashParams) Validate() error {
+	if !params.Bond.IsNonnegative() || !params.Quantum.IsNonnegative() ||
+		!params.MinCollateral.IsNonnegative() || !params.MarginalOverspendMargin.IsNonnegative() ||
+		!params.Fee.IsNonnegative() {
+		return errors.New("Slash parameters cannot have negative amounts")
+	}
+	if params.MinProposerStake != nil && params.MinProposerStake.Sign() < 0 {
+		return errors.New("The minimum proposer stake cannot be negative")
+	}
+	if params.MaxNativeProposerReward != nil && params.MaxNativeProposerReward.Sign() < 0 {
+		return errors.New("The maximum native proposer reward cannot be negative")
+	}
+	if params.MaxReservedFundAge > 0 && params.GracePeriod >= params.MaxReservedFundAge {
+		return errors.Errorf("The slash grace period %v overlaps the max reserved fund age %v",
+			params.GracePeriod, params.MaxReservedFundAge)
+	}
+	if params.BatchRewardPayout && params.RewardVestingDuration > 0 {
+		return errors.New("The slash rewards cannot both vest and be paid out in batch")
+	}
+	if params.ShareRewardWithValidators && params.RewardVestingDuration > 0 {
+		return errors.New("The slash rewards cannot both vest and be shared among the validators")
+	}
+	if params.MaxBatchSlashTxEntries <= 0 {
+		return errors.Errorf("The maximum number of BatchSlashTx entries %v is not positive", params.MaxBatchSlashTxEntries)
+	}
+	return nil
+}
+
+// Params returns the slash parameters the executor enforces
+func (exec *SlashTxExecutor) Params() SlashParams {
+	return exec.params
+}
+
+// SetParams replaces the slash parameters the executor enforces. The nil denominations of the amounts are
+// taken as zeros
+func (exec *SlashTxExecutor) SetParams(params SlashParams) {
+	params.normalize()
+	exec.params = params
+	exec.SetUnslashableAddresses(params.UnslashableAddresses)
+}
+
+// SetMaxReservedFundAge sets the maximum age (in terms of number of blocks) of a slashable reserved fund.
+// Reserved funds that were created earlier than that cannot be slashed. Zero disables the limit.
+func (exec *SlashTxExecutor) SetMaxReservedFundAge(maxAge uint64) {
+	exec.params.MaxReservedFundAge = maxAge
+}
+
+// SetSlashGracePeriod sets the number of blocks after its creation during which a reserved fund is not yet
+// slashable. The grace period and the maximum age (see SetMaxReservedFundAge) delimit the window in which a
+// reserved fund can be slashed. Where they overlap, the maximum age takes precedence, i.e. a reserved fund
+// too old to be slashed is reported as such even if it appears to be in a grace period.
+func (exec *SlashTxExecutor) SetSlashGracePeriod(gracePeriod uint64) {
+	exec.params.GracePeriod = gracePeriod
+}
+
+// SetSlashDisputeWindow sets the number of blocks after the release height of a reserved fund during which
+// slash proofs against it are accepted. After that, the evidence is considered stale. Zero disables the expiry.
+func (exec *SlashTxExecutor) SetSlashDisputeWindow(window uint64) {
+	exec.params.DisputeWindow = window
+}
+
+// SetMaxProofTimestampSkew sets the maximum skew (in seconds) allowed between the timestamp of an overspending
+// proof and the time of the block being executed. A non-zero skew requires the proofs to be timestamped.
+func (exec *SlashTxExecutor) SetMaxProofTimestampSkew(maxSkew uint64) {
+	exec.params.MaxProofTimestampSkew = maxSkew
+}
+
+// SetRewardVestingDuration sets the number of blocks over which the slash reward vests to the proposer,
+// to discourage hit-and-run slashing. Zero credits the reward to the proposer at once.
+func (exec *SlashTxExecutor) SetRewardVestingDuration(duration uint64) {
+	exec.params.RewardVestingDuration = duration
+}
+
+// SetBatchRewardPayout accumulates the slash rewards per proposer instead of crediting them with each SlashTx,
+// and pays them out in a single credit at the end of the block (see PayoutPendingSlashRewards). The rewards
+// are thus not spendable by the proposer within the block the slashes are included in. It is exclusive of
+// SetRewardVestingDuration.
+func (exec *SlashTxExecutor) SetBatchRewardPayout(enabled bool) {
+	exec.params.BatchRewardPayout = enabled
+}
+
+// SetShareRewardWithValidators shares the slash reward among the validators in proportion to their stakes,
+// instead of rewarding the proposer alone, which only gets its share. The validators are those effective at
+// the height the SlashTx is processed. It is exclusive of SetRewardVestingDuration.
+func (exec *SlashTxExecutor) SetShareRewardWithValidators(enabled bool) {
+	exec.params.ShareRewardWithValidators = enabled
+}
+
+// SetPartialSlash slashes only the amount an overspending proof shows the reserved fund is overspent by, up
+// to the collateral, instead of the whole collateral and remaining fund. The rest of the reserved fund is
+// returned to the slashed account. The channel revocations are still slashed in full.
+func (exec *SlashTxExecutor) SetPartialSlash(enabled bool) {
+	exec.params.PartialSlash = enabled
+}
+
+// SetSlashBond requires the proposer of a slash to lock the given bond, to deter frivolous slashes. The bond
+// is returned to the proposer after lockPeriod blocks, unless it is forfeited by ForfeitSlashBond in the
+// meantime. A zero bond disables the requirement.
+func (exec *SlashTxExecutor) SetSlashBond(bond types.Coins, lockPeriod uint64) {
+	exec.params.Bond = bond.NoNil()
+	exec.params.BondLockPeriod = lockPeriod
+}
+
+// SetSlashFee charges the proposer of a slash the given fee for executing the SlashTx. The fee is burnt, and
+// it is charged before the proposer is rewarded, so the proposer must be able to afford it upfront. A zero
+// fee disables the charge.
+func (exec *SlashTxExecutor) SetSlashFee(fee types.Coins) {
+	exec.params.Fee = fee.NoNil()
+}
+
+// SetMaxProofVerificationsPerBlock caps the number of signatures verified for the slash proofs of a block,
+// so that a flood of large proofs cannot stall the block production. The slashes exceeding the budget are
+// deferred to the later blocks. Zero disables the cap.
+func (exec *SlashTxExecutor) SetMaxProofVerificationsPerBlock(maxVerifications uint64) {
+	exec.params.MaxProofVerificationsPerBlock = maxVerifications
+}
+
+// SetSlashQuantization quantizes the slashed amounts to multiples of the given unit (per denomination), so
+// repeated small slashes do not leave unspendable dust in the proposer accounts. The remainder is handled
+// according to the given policy. A zero unit for a denomination disables its quantization.
+func (exec *SlashTxExecutor) SetSlashQuantization(quantum types.Coins, policy SlashRemainderPolicy) {
+	exec.params.Quantum = quantum.NoNil()
+	exec.params.RemainderPolicy = policy
+}
+
+// SetSlashSplit splits the slashed amounts among the burn, the treasury account and the proposer instead of
+// awarding the whole amounts to the proposer, so the proposer gains little by colluding with the overspender.
+// E.g. SlashSplit{TreasuryWeight: 95, ProposerWeight: 5} awards the proposer a 5% finder's fee. The treasury
+// is the zero address by default, which burns the treasury share instead (see isBurnAddress)
+func (exec *SlashTxExecutor) SetSlashSplit(split SlashSplit, treasury common.Address) {
+	exec.params.Split = split
+	exec.params.Treasury = treasury
+}
+
+// SetBurnIfTreasuryMissing sets what becomes of the treasury share of a slash if the treasury has no account
+// yet. By default, the account is created and credited. If enabled, the share is burnt instead, so that no
+// account springs up at a misconfigured treasury address.
+func (exec *SlashTxExecutor) SetBurnIfTreasuryMissing(enabled bool) {
+	exec.params.BurnIfTreasuryMissing = enabled
+}
+
+// SetMaxNativeProposerReward pays the proposer reward of a slash in the native coin, i.e. TFuel, only, and
+// caps it at the given amount (in TFuelWei). The Theta share of the reward and the TFuel in excess of the cap
+// are credited to the treasury instead (see SetSlashSplit). Nil disables the cap.
+func (exec *SlashTxExecutor) SetMaxNativeProposerReward(maxReward *big.Int) {
+	exec.params.MaxNativeProposerReward = maxReward
+}
+
+// SetMinimumCollateral sets the minimum collateral (per denomination) of the reserved funds, enforced when
+// the funds are reserved, so that slashing them seizes more than the remaining fund
+func (exec *SlashTxExecutor) SetMinimumCollateral(minCollateral types.Coins) {
+	exec.params.MinCollateral = minCollateral.NoNil()
+}
+
+// SetMinimumProposerStake sets the minimum active stake, i.e. the stake deposited to and not withdrawn from
+// the proposer, a proposer needs to file a slash. Nil or zero disables the requirement.
+func (exec *SlashTxExecutor) SetMinimumProposerStake(minStake *big.Int) {
+	exec.params.MinProposerStake = minStake
+}
+
+// SetRejectZeroCollateral sets whether to reject the SlashTxs against reserved funds without collateral, e.g.
+// legacy funds reserved before the collateral was required. Otherwise only the remaining fund is seized.
+func (exec *SlashTxExecutor) SetRejectZeroCollateral(reject bool) {
+	exec.params.RejectZeroCollateral = reject
+}
+
+// SetRejectConflictedProposer sets whether to reject the SlashTxs whose proposer is a payment target in the
+// slash proof. Such a proposer has a conflict of interest, since the account it slashes owes it payments.
+// The rule is opt-in, unlike the rejection of a proposer slashing its own reserved fund, since the payment
+// targets are the first to learn of the overspending and the natural proposers of the SlashTxs.
+func (exec *SlashTxExecutor) SetRejectConflictedProposer(reject bool) {
+	exec.params.RejectConflictedProposer = reject
+}
+
+// SetUnslashableAddresses sets the denylist of the protected system accounts, e.g. the treasury, burn
+// and genesis accounts, which cannot be slashed. It replaces the previously set denylist.
+func (exec *SlashTxExecutor) SetUnslashableAddresses(addresses []common.Address) {
+	exec.params.UnslashableAddresses = addresses
+	exec.unslashableAddresses = make(map[common.Address]bool)
+	for _, address := range addresses {
+		exec.unslashableAddresses[address] = true
+	}
+}
+
+// SetMarginalOverspendMargin sets the margin under which an overspending is flagged as marginal. Proofs
+// whose claimed payments exceed the reserved fund by less than the margin in an overspent denomination are
+// logged as warnings, as potentially erroneous slashes. A zero margin disables the detection.
+func (exec *SlashTxExecutor) SetMarginalOverspendMargin(margin types.Coins) {
+	exec.params.MarginalOverspendMargin = margin
+}
+
+// SetAttestUnderspend sets whether a SlashTx whose proof shows the reserved fund is not overspent records a
+// "validated, no overspend" attestation on the reserved fund instead of being rejected. Nothing is slashed in
+// that case. The attestation is only recorded once the reserved fund expired, i.e. no more payments can
+// settle against it, and it is invalidated by a payment settled afterwards. It does not shorten the freeze
+// period before the reserved fund can be released, since a proof may leave out some signed payments, e.g.
+// those of a pending slash.
+func (exec *SlashTxExecutor) SetAttestUnderspend(enabled bool) {
+	exec.params.AttestUnderspend = enabled
+}
+
+// SetRequireReserveTxHash sets whether the overspending proofs must be bound to the ReserveFundTx that
+// created the reserved fund (see types.ReserveBoundOverspendingProof), so that a proof cannot be applied
+// to another reserved fund with the same reserve sequence. Bound proofs are verified regardless. Note that
+// the reserved funds created before their ReserveFundTx hashes were recorded cannot be slashed with this on.
+func (exec *SlashTxExecutor) SetRequireReserveTxHash(required bool) {
+	exec.params.RequireReserveTxHash = required
+}
+
+// SetSlashFlagPolicy sets the status the slashed accounts are flagged with, and for how many blocks the
+// flag stays. A flagged account cannot reserve new funds. A zero duration keeps the flag until it is
+// cleared with StoreView.DeleteAccountSlashFlag. AccountSlashStatusNone disables the flagging.
+func (exec *SlashTxExecutor) SetSlashFlagPolicy(status types.AccountSlashStatus, duration uint64) {
+	exec.params.FlagStatus = status
+	exec.params.FlagDuration = duration
+}
+
+// checkCollateral verifies the collateral of a reserved fund meets the minimum collateral
+func (exec *SlashTxExecutor) checkCollateral(collateral types.Coins) error {
+	if !collateral.IsGTE(exec.params.MinCollateral) {
+		return errors.Errorf("Collateral %v is below the minimum collateral %v", collateral, exec.params.MinCollateral)
+	}
+	return nil
+}
+
+// checkSlashableWindow verifies that a reserved fund of the given duration (in terms of number of blocks)
+// stays slashable from the end of the grace period until it can be released. Otherwise an overspender
+// could escape the punishment by overspending the fund before or after its slashable window.
+func (exec *SlashTxExecutor) checkSlashableWindow(duration uint64) error {
+	if exec.params.GracePeriod >= duration {
+		return errors.Errorf("Reserved fund duration %v does not exceed the slash grace period %v",
+			duration, exec.params.GracePeriod)
+	}
+	releasableAge := duration + types.ReservedFundFreezePeriodDuration
+	if exec.params.MaxReservedFundAge > 0 && exec.params.MaxReservedFundAge < releasableAge {
+		return errors.Errorf("Reserved fund would become too old to be slashed %v blocks before it can be released",
+			releasableAge-exec.params.MaxReservedFundAge)
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package execution
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+func TestSlashTxExecutorValidateWiring(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	assert.Nil(et.executor.ValidateWiring())
+	assert.Nil(et.executor.slashTxExec.ValidateWiring())
+
+	consensus := et.executor.consensus
+	valMgr := et.executor.valMgr
+
+	assert.NotNil(NewSlashTxExecutor(nil, valMgr).ValidateWiring())
+	assert.NotNil(NewSlashTxExecutor(consensus, nil).ValidateWiring())
+	assert.NotNil(NewExecutor(et.executor.state, consensus, nil).ValidateWiring())
+	assert.NotNil(NewExecutor(nil, consensus, valMgr).ValidateWiring())
+}
+
+func TestSlashTxMaxReservedFundAge(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	et.fastforwardBy(10)
+
+	view := et.state().Delivered()
+	reservedFund := view.GetAccount(alice.Address).ReservedFunds[0]
+	assert.True(reservedFund.StartBlockHeight > 0)
+	age := view.Height() - reservedFund.StartBlockHeight
+
+	slashExec := et.executor.SlashTxExecutor()
+	slashTx := createSlashTx(et.chainID, &proposer, 1, slashIntent.Address, slashIntent.ReserveSequence, slashIntent.Proof)
+
+	// The reserved fund is older than the cutoff
+	slashExec.SetMaxReservedFundAge(age - 1)
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError(), res.Message)
+	assert.Equal(result.CodeSlashReservedFundTooOld, res.Code)
+
+	// The reserved fund is within the cutoff
+	slashExec.SetMaxReservedFundAge(age)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// No age limit
+	slashExec.SetMaxReservedFundAge(0)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// The age of a reserved fund reserved before the start block height was recorded is unknown
+	account := view.GetAccount(alice.Address)
+	account.ReservedFunds[0].StartBlockHeight = 0
+	view.SetAccount(alice.Address, account)
+	slashExec.SetMaxReservedFundAge(1)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+}
+
+func TestSlashTxGracePeriod(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	et.fastforwardBy(10)
+
+	view := et.state().Delivered()
+	reservedFund := view.GetAccount(alice.Address).ReservedFunds[0]
+	age := view.Height() - reservedFund.StartBlockHeight
+
+	slashExec := et.executor.SlashTxExecutor()
+	slashTx := createSlashTx(et.chainID, &proposer, 1, slashIntent.Address, slashIntent.ReserveSequence, slashIntent.Proof)
+
+	// The reserved fund is still in the grace period
+	slashExec.SetSlashGracePeriod(age + 1)
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError(), res.Message)
+	assert.Equal(result.CodeSlashReservedFundTooNew, res.Code)
+
+	slashExec.SetSlashGracePeriod(age)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// Where the grace period and the max age overlap, the max age takes precedence
+	slashExec.SetSlashGracePeriod(age + 1)
+	slashExec.SetMaxReservedFundAge(age - 1)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError(), res.Message)
+	assert.Equal(result.CodeSlashReservedFundTooOld, res.Code)
+
+	// Overlapping windows are a misconfiguration
+	assert.NotNil(slashExec.ValidateWiring())
+	slashExec.SetMaxReservedFundAge(age + 2)
+	assert.Nil(slashExec.ValidateWiring())
+}
+
+func TestLoadSlashParams(t *testing.T) {
+	assert := assert.New(t)
+
+	// The parameters absent from the encoding keep their default values
+	params, err := LoadSlashParams([]byte(`{"grace_period": 10, "bond": {"thetawei": "0", "tfuelwei": "500"}, "min_proposer_stake": 1000}`))
+	assert.Nil(err)
+	assert.Equal(uint64(10), params.GracePeriod)
+	assert.True(types.NewCoins(0, 500).IsEqual(params.Bond))
+	assert.Equal(int64(1000), params.MinProposerStake.Int64())
+	assert.True(params.Quantum.IsZero())
+	assert.Equal(types.SlashEvidenceExpiryDuration, params.SlashEvidenceExpiryDuration)
+	assert.Equal(types.MaximumBatchSlashTxEntries, params.MaxBatchSlashTxEntries)
+
+	params, err = LoadSlashParams([]byte(`{}`))
+	assert.Nil(err)
+	assert.Equal(DefaultSlashParams(), params)
+
+	// Malformed or inconsistent parameters are rejected
+	_, err = LoadSlashParams([]byte(`{"grace_period": "ten"}`))
+	assert.NotNil(err)
+	_, err = LoadSlashParams([]byte(`{"grace_period": 10, "max_reserved_fund_age": 10}`))
+	assert.NotNil(err)
+	_, err = LoadSlashParams([]byte(`{"min_collateral": {"thetawei": "-1", "tfuelwei": "0"}}`))
+	assert.NotNil(err)
+	_, err = LoadSlashParams([]byte(`{"max_batch_slash_tx_entries": 0}`))
+	assert.NotNil(err)
+}
+
+func TestSlashTxExecutorWithParams(t *testing.T) {
+	assert := assert.New(t)
+	txFee := getMinimumTxFee()
+
+	params := DefaultSlashParams()
+	params.Bond = types.Coins{ThetaWei: big.NewInt(0), TFuelWei: new(big.Int).Mul(big.NewInt(txFee), big.NewInt(1e12))}
+	params.UnslashableAddresses = []common.Address{common.HexToAddress("0x1234")}
+	slashExec := NewSlashTxExecutorWithParams(nil, nil, params)
+	assert.Equal(params, slashExec.Params())
+	assert.True(slashExec.unslashableAddresses[common.HexToAddress("0x1234")])
+
+	// The same SlashTx is accepted or rejected depending on the parameters
+	et, _, alice, bob, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	res := et.executor.SlashTxExecutor().sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	et.executor.SlashTxExecutor().SetParams(params)
+	res = et.executor.SlashTxExecutor().sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeInsufficientFund, res.Code, res.Message)
+
+	params = DefaultSlashParams()
+	params.UnslashableAddresses = []common.Address{alice.Address}
+	et.executor.SlashTxExecutor().SetParams(params)
+	res = et.executor.SlashTxExecutor().sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeSlashProtectedAddress, res.Code, res.Message)
+
+	// The parameters also govern the BatchSlashTxs and the SlashEvidenceTxs
+	params = DefaultSlashParams()
+	params.MaxBatchSlashTxEntries = 1
+	params.SlashEvidenceExpiryDuration = 7
+	et.executor.SlashTxExecutor().SetParams(params)
+	entry := types.SlashEntry{SlashedAddress: alice.Address, ReserveSequence: 1, SlashProof: slashIntent.Proof}
+	batchSlashExec := et.executor.getTxExecutor(&types.BatchSlashTx{})
+	res = batchSlashExec.sanityCheck(et.chainID, view, createBatchSlashTx(et.chainID, &proposer, 1, entry))
+	assert.True(res.IsOK(), res.Message)
+	res = batchSlashExec.sanityCheck(et.chainID, view, createBatchSlashTx(et.chainID, &proposer, 1, entry, entry))
+	assert.True(res.IsError(), res.Message)
+
+	payment := createServicePaymentTx(et.chainID, &alice, &bob, 100*txFee, 1, 1, 1, 1, "rid001")
+	res = execSlashEvidenceTx(et, createSlashEvidenceTx(et.chainID, &bob, 2, alice.Address, 1, payment))
+	assert.True(res.IsOK(), res.Message)
+	evidence := et.state().Delivered().GetSlashEvidence(alice.Address, 1)
+	assert.NotNil(evidence)
+	assert.Equal(et.state().Delivered().Height()+7-1, evidence.EndBlockHeight)
+}
+
+func TestReserveFundTxSlashableWindow(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, _, _, _, _, _ := setupForServicePayment(assert)
+	view := et.state().Delivered()
+	slashExec := et.executor.SlashTxExecutor()
+
+	txFee := getMinimumTxFee()
+	reserveFundTx := createReserveFundTx(et.chainID, &alice, 1000*txFee, 1001*txFee, 2, []string{resourceID})
+	duration := reserveFundTx.Duration
+
+	// The reserved fund would never be slashable
+	slashExec.SetSlashGracePeriod(duration)
+	res := et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, view, reserveFundTx)
+	assert.True(res.IsError(), res.Message)
+	assert.Equal(result.CodeReserveFundCheckFailed, res.Code)
+	slashExec.SetSlashGracePeriod(duration - 1)
+
+	// The reserved fund would become too old to be slashed before it can be released
+	slashExec.SetMaxReservedFundAge(duration + types.ReservedFundFreezePeriodDuration - 1)
+	res = et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, view, reserveFundTx)
+	assert.True(res.IsError(), res.Message)
+	assert.Equal(result.CodeReserveFundCheckFailed, res.Code)
+
+	slashExec.SetMaxReservedFundAge(duration + types.ReservedFundFreezePeriodDuration)
+	res = et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, view, reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+}
+
+func TestSlashTxDisputeWindow(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+
+	slashExec := et.executor.SlashTxExecutor()
+	slashExec.SetSlashDisputeWindow(10)
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+
+	reservedFund := et.state().Delivered().GetAccount(alice.Address).ReservedFunds[0]
+	expiryBlockHeight := reservedFund.MinimumReleaseBlockHeight() + 10
+
+	// Submitted past the end block height of the reserved fund, but just before the expiry
+	assert.True(expiryBlockHeight-1 > reservedFund.EndBlockHeight)
+	et.fastforwardTo(expiryBlockHeight - 1)
+	view := et.state().Delivered()
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// Submitted at the expiry
+	et.fastforwardTo(expiryBlockHeight)
+	view = et.state().Delivered()
+	assert.Equal(expiryBlockHeight, view.Height())
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// Submitted after the expiry
+	et.fastforwardTo(expiryBlockHeight + 1)
+	view = et.state().Delivered()
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeSlashProofExpired, res.Code, res.Message)
+
+	// Submitted well after the expiry
+	et.fastforwardTo(expiryBlockHeight + 1000)
+	view = et.state().Delivered()
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeSlashProofExpired, res.Code, res.Message)
+
+	// Without the dispute window, the proofs do not expire
+	slashExec.SetSlashDisputeWindow(0)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+}
+
+func TestReserveFundTxMinimumCollateral(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, _, _, _, _ := setupForServicePayment(assert)
+	view := et.state().Delivered()
+
+	txFee := getMinimumTxFee()
+	reserveFundTx := createReserveFundTx(et.chainID, &alice, 1000*txFee, 1001*txFee, 2, []string{"rid002"})
+	reserveFundExec := et.executor.getTxExecutor(reserveFundTx)
+
+	et.executor.SlashTxExecutor().SetMinimumCollateral(types.NewCoins(0, 2000*txFee))
+	res := reserveFundExec.sanityCheck(et.chainID, view, reserveFundTx)
+	assert.Equal(result.CodeReserveFundCheckFailed, res.Code, res.Message)
+
+	et.executor.SlashTxExecutor().SetMinimumCollateral(types.NewCoins(0, 1001*txFee))
+	res = reserveFundExec.sanityCheck(et.chainID, view, reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+}
+
+func TestSlashTxExecutorNilDependencies(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+
+	// The missing dependency is named, rather than the check panicking
+	res := NewSlashTxExecutor(nil, et.executor.valMgr).sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError())
+	assert.False(res.IsRetryable())
+	assert.Contains(res.Message, "consensus engine is not set")
+	res = NewSlashTxExecutor(et.executor.consensus, nil).sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError())
+	assert.Contains(res.Message, "validator manager is not set")
+
+	// With a standalone validator set provider, the checks relying on the consensus engine fail cleanly
+	withProposer := core.NewValidatorSet()
+	withProposer.AddValidator(core.NewValidator(proposer.Address.String(), big.NewInt(100)))
+	slashExec := NewSlashTxExecutor(nil, nil)
+	slashExec.SetValidatorSetProvider(&testValidatorSetProvider{valSet: withProposer})
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	slashExec.SetMaxProofTimestampSkew(10)
+	proof, err := decodeOverspendingProof(slashIntent.Proof)
+	assert.Nil(err)
+	proof.SetTimestamp(big.NewInt(100))
+	timestampedProof, err := types.ToBytes(proof)
+	assert.Nil(err)
+	slashTx = createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, timestampedProof)
+	assert.NotPanics(func() {
+		res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	})
+	assert.True(res.IsError())
+	assert.Contains(res.Message, "block time is unknown")
+}
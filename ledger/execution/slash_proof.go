@@ -0,0 +1,456 @@
+package execution
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// checkSlashReason verifies the type of the slash proof matches the reason of the SlashTx, so that a proof
+// is only verified as the kind of evidence the SlashTx claims it is
+func checkSlashReason(reason types.SlashReason, slashProofBytes []byte) result.Result {
+	var proofReason types.SlashReason
+	switch reason {
+	case types.SlashReasonOverspending, types.SlashReasonChannelRevocation:
+		proofReason = types.SlashReasonOverspending
+		if isChannelRevocationProof(slashProofBytes) {
+			proofReason = types.SlashReasonChannelRevocation
+		}
+	default:
+		return result.Error("Slash reason %v is not supported", reason).
+			WithErrorCode(result.CodeSlashReasonMismatch)
+	}
+
+	if reason != proofReason {
+		return result.Error("Slash reason %v does not match the %v slash proof", reason, proofReason).
+			WithErrorCode(result.CodeSlashReasonMismatch)
+	}
+	return result.OK
+}
+
+// getProofAccount returns the slashed account to verify the slash proof against, i.e. the account in the
+// state snapshot the proof is bound to, or the current account if the proof is not bound
+func getProofAccount(view *st.StoreView, tx *types.SlashTx) (*types.Account, result.Result) {
+	snapshot := tx.GetSnapshot()
+	if snapshot == nil {
+		account := view.GetAccount(tx.SlashedAddress)
+		if account == nil {
+			return nil, result.Error("Account %v does not exist!", tx.SlashedAddress).WithErrorCode(result.CodeSlashAccountNotFound)
+		}
+		return account, result.OK
+	}
+
+	if snapshot.Height > view.Height() {
+		return nil, result.Error("Slash proof is bound to the snapshot at block height %v, beyond the current height %v",
+			snapshot.Height, view.Height()).WithErrorCode(result.CodeSlashInvalidSnapshot)
+	}
+	snapshotView := st.NewStoreView(snapshot.Height, snapshot.StateRoot, view.GetDB())
+	if snapshotView == nil {
+		return nil, result.Error("Slash proof is bound to the state snapshot %v, which is not available",
+			snapshot.StateRoot.Hex()).WithErrorCode(result.CodeSlashInvalidSnapshot)
+	}
+	account := snapshotView.GetAccount(tx.SlashedAddress)
+	if account == nil {
+		return nil, result.Error("Account %v does not exist in the state snapshot %v",
+			tx.SlashedAddress, snapshot.StateRoot.Hex()).WithErrorCode(result.CodeSlashInvalidSnapshot)
+	}
+	return account, result.OK
+}
+
+func (exec *SlashTxExecutor) verifySlashProof(ctx context.Context, chainID string, view *st.StoreView, slashedAccount *types.Account, reserveSequence uint64, overspendingProofBytes []byte) result.Result {
+	proofLogger := exec.logger.WithFields(log.Fields{
+		"slashedAddress":  slashedAccount.Address.Hex(),
+		"reserveSequence": reserveSequence,
+	})
+	overspendingProof, err := decodeOverspendingProof(overspendingProofBytes)
+	if err != nil {
+		proofLogger.Warnf("Failed to parse overspending proof: %v", err)
+		return invalidSlashProofEncoding(err)
+	}
+
+	if exec.params.MaxProofTimestampSkew > 0 {
+		res := exec.checkProofTimestamp(view, overspendingProof)
+		if res.IsError() {
+			return res
+		}
+	}
+
+	// The proof must cover the same reserved fund the SlashTx claims, otherwise the proposer
+	// could slash one reserved fund with the evidence of another
+	if overspendingProof.ReserveSequence != reserveSequence {
+		proofLogger.Warnf("Overspending proof reserve sequence %v does not match the SlashTx reserve sequence %v",
+			overspendingProof.ReserveSequence, reserveSequence)
+		return result.Error("Invalid slash proof, proof reserve sequence %v does not match the SlashTx reserve sequence %v",
+			overspendingProof.ReserveSequence, reserveSequence).WithErrorCode(result.CodeSlashInvalidProof)
+	}
+
+	reservedFund, _, res := findReservedFund(slashedAccount, reserveSequence)
+	if res.IsError() {
+		proofLogger.Warnf("Failed to locate the reserved fund for the overspending proof: %v", res.Message)
+		return res
+	}
+
+	slashedAddress := slashedAccount.Address
+	overspent, err := types.VerifyOverspendingProofWithContext(ctx, chainID, slashedAccount, *overspendingProof)
+	if cause := errors.Cause(err); cause == context.Canceled || cause == context.DeadlineExceeded {
+		return result.Error("Verification of the slash proof aborted: %v", err).
+			WithErrorCode(result.CodeSlashVerificationAborted)
+	}
+	if err != nil {
+		proofLogger.Warnf("Invalid overspending proof: %v", err)
+		res := result.Error("Invalid slash proof, %v", err).WithErrorCode(result.CodeSlashInvalidProof)
+		switch errors.Cause(err).(type) {
+		case *types.WrongReserveSequenceError:
+			res = res.WithErrorCode(result.CodeSlashWrongReserveSequence)
+		case *types.UnsupportedSignatureSchemeError:
+			res = res.WithErrorCode(result.CodeSlashUnsupportedSignatureScheme)
+		}
+		return res
+	}
+
+	fundIntendedToSpend := reservedFund.FundIntendedToSpend(overspendingProof.ServicePayments)
+	if exec.logOverspendingMargin {
+		exec.logger.WithFields(log.Fields{
+			"slashedAddress":      slashedAddress.Hex(),
+			"reserveSequence":     reserveSequence,
+			"fundIntendedToSpend": fundIntendedToSpend.String(),
+			"initialFund":         reservedFund.InitialFund.String(),
+		}).Debug("Verifying the overspending of the reserved fund")
+	}
+
+	if !overspent {
+		return result.Error("Invalid slash proof, the reserved fund %v is not overspent", reserveSequence).
+			WithErrorCode(result.CodeSlashNotOverspent)
+	}
+
+	if !exec.params.MarginalOverspendMargin.IsZero() &&
+		isMarginalOverspending(reservedFund.InitialFund, fundIntendedToSpend, exec.params.MarginalOverspendMargin) {
+		exec.logger.WithFields(log.Fields{
+			"slashedAddress":    slashedAddress.Hex(),
+			"reserveSequence":   reserveSequence,
+			"marginalOverspend": fundIntendedToSpend.NoNil().Minus(reservedFund.InitialFund.NoNil()).String(),
+			"margin":            exec.params.MarginalOverspendMargin.String(),
+		}).Warn("The reserved fund is overspent by less than the margin, the slash could be erroneous")
+	}
+	return result.OK
+}
+
+// isMarginalOverspending tells whether the fund intended to spend exceeds the limit by less than the margin
+// in any of the overspent denominations
+func isMarginalOverspending(limit, fundIntendedToSpend, margin types.Coins) bool {
+	limit = limit.NoNil()
+	intended := fundIntendedToSpend.NoNil()
+	margin = margin.NoNil()
+	return isMarginalForDenom(limit.ThetaWei, intended.ThetaWei, margin.ThetaWei) ||
+		isMarginalForDenom(limit.TFuelWei, intended.TFuelWei, margin.TFuelWei)
+}
+
+func isMarginalForDenom(limit, fundIntendedToSpend, margin *big.Int) bool {
+	excess := new(big.Int).Sub(fundIntendedToSpend, limit)
+	return excess.Sign() > 0 && excess.Cmp(margin) < 0
+}
+
+// checkReserveTxHash verifies that an overspending proof bound to a reserved fund instance is bound to the
+// ReserveFundTx that created the reserved fund. Unbound proofs are accepted only if the binding is not required
+func (exec *SlashTxExecutor) checkReserveTxHash(view *st.StoreView, slashedAddress common.Address, reserveSequence uint64, overspendingProofBytes []byte) result.Result {
+	_, reserveTxHash, err := decodeBoundOverspendingProof(overspendingProofBytes)
+	if err != nil {
+		return invalidSlashProofEncoding(err)
+	}
+
+	if reserveTxHash == nil {
+		if exec.params.RequireReserveTxHash {
+			return result.Error("Invalid slash proof, the proof is not bound to the ReserveFundTx of reserved fund %v",
+				reserveSequence).WithErrorCode(result.CodeSlashReserveTxHashMismatch)
+		}
+		return result.OK
+	}
+
+	recordedTxHash, exists := view.GetReserveFundTxHash(slashedAddress, reserveSequence)
+	if !exists || recordedTxHash != *reserveTxHash {
+		return result.Error("Invalid slash proof, the proof is bound to ReserveFundTx %v, which did not create reserved fund %v",
+			reserveTxHash.Hex(), reserveSequence).WithErrorCode(result.CodeSlashReserveTxHashMismatch)
+	}
+	return result.OK
+}
+
+// bindOverspendingProof binds the overspending proof to the ReserveFundTx that created the reserved fund, if
+// its hash has been recorded. Otherwise the proof is returned as is
+func bindOverspendingProof(view *st.StoreView, addr common.Address, reserveSequence uint64, overspendingProofBytes common.Bytes) common.Bytes {
+	reserveTxHash, exists := view.GetReserveFundTxHash(addr, reserveSequence)
+	if !exists {
+		return overspendingProofBytes
+	}
+	overspendingProof, err := decodeOverspendingProof(overspendingProofBytes)
+	if err != nil {
+		return overspendingProofBytes
+	}
+	boundProofBytes, err := types.ToBytes(&types.ReserveBoundOverspendingProof{
+		ReserveTxHash: reserveTxHash,
+		Proof:         *overspendingProof,
+	})
+	if err != nil {
+		return overspendingProofBytes
+	}
+	return boundProofBytes
+}
+
+// checkProofTimestamp verifies the timestamp of the proof is within the allowed skew of the time of the block
+// being executed. The local tip is not used since it differs from node to node
+func (exec *SlashTxExecutor) checkProofTimestamp(view *st.StoreView, overspendingProof *types.OverspendingProof) result.Result {
+	timestamp := overspendingProof.GetTimestamp()
+	if timestamp == nil {
+		return result.Error("Invalid slash proof, the proof is not timestamped").
+			WithErrorCode(result.CodeSlashProofTimestampOutOfSkew)
+	}
+
+	blockTimestamp := view.BlockTimestamp()
+	if blockTimestamp == nil {
+		return result.Error("The block time is unknown, cannot check the slash proof timestamp")
+	}
+
+	skew := new(big.Int).Sub(timestamp, blockTimestamp)
+	if skew.Abs(skew).Cmp(new(big.Int).SetUint64(exec.params.MaxProofTimestampSkew)) > 0 {
+		return result.Error("Invalid slash proof, the proof timestamp %v is too far from the block time %v",
+			timestamp, blockTimestamp).WithErrorCode(result.CodeSlashProofTimestampOutOfSkew)
+	}
+	return result.OK
+}
+
+// verifySlashedServicePayment verifies the service payment was signed by the slashed account against the
+// given reserved fund
+func verifySlashedServicePayment(chainID string, slashedAddress common.Address, reserveSequence uint64, servicePaymentTx *types.ServicePaymentTx) result.Result {
+	err := types.VerifySlashedServicePayment(chainID, slashedAddress, reserveSequence, servicePaymentTx)
+	if err == nil {
+		return result.OK
+	}
+	res := result.Error("%v", err).WithErrorCode(result.CodeSlashInvalidProof)
+	switch err.(type) {
+	case *types.WrongReserveSequenceError:
+		res = res.WithErrorCode(result.CodeSlashWrongReserveSequence)
+	case *types.UnsupportedSignatureSchemeError:
+		res = res.WithErrorCode(result.CodeSlashUnsupportedSignatureScheme)
+	}
+	return res
+}
+
+// verifyChannelRevocationProof verifies that the slashed account signed two states of the same payment
+// channel, and the newer state (with a larger payment sequence) contradicts the claimed one
+func (exec *SlashTxExecutor) verifyChannelRevocationProof(chainID string, slashedAccount *types.Account, reserveSequence uint64, revocationProofBytes []byte) bool {
+	revocationProof, err := decodeChannelRevocationProof(revocationProofBytes)
+	if err != nil {
+		exec.logger.Errorf("Failed to parse channel revocation proof: %v", err)
+		return false
+	}
+
+	_, _, res := findReservedFund(slashedAccount, reserveSequence)
+	if res.IsError() {
+		exec.logger.Warnf("Failed to locate the reserved fund for the channel revocation proof: %v", res.Message)
+		return false
+	}
+
+	claimedState := &revocationProof.ClaimedState
+	newerState := &revocationProof.NewerState
+	for _, state := range []*types.ServicePaymentTx{claimedState, newerState} {
+		if state.Source.Address != slashedAccount.Address {
+			return false // the state does not come from the slashed account
+		}
+
+		if state.ReserveSequence != reserveSequence {
+			return false // the state does not belong to claimed reserved fund
+		}
+
+		sourceSignedBytes := state.SourceSignBytes(chainID)
+		if !state.Source.Signature.Verify(sourceSignedBytes, slashedAccount.Address) {
+			return false // the state not signed by the slashed account
+		}
+	}
+
+	if claimedState.Target.Address != newerState.Target.Address || claimedState.ResourceID != newerState.ResourceID {
+		return false // the two states do not belong to the same channel
+	}
+
+	if newerState.PaymentSequence <= claimedState.PaymentSequence {
+		return false // the newer state does not revoke the claimed one
+	}
+
+	if newerState.Source.Coins.NoNil().IsEqual(claimedState.Source.Coins.NoNil()) {
+		return false // the newer state does not contradict the claimed one
+	}
+
+	return true
+}
+
+// getSlashProof returns the slash proof carried by the SlashTx. A SlashTx without a proof consumes the slash
+// evidence accumulated on-chain against the reserved fund by SlashEvidenceTxs
+func getSlashProof(view *st.StoreView, tx *types.SlashTx) (common.Bytes, result.Result) {
+	if len(tx.SlashProof) > 0 {
+		return tx.SlashProof, result.OK
+	}
+
+	evidence := getUnexpiredSlashEvidence(view, tx.SlashedAddress, tx.ReserveSequence)
+	if evidence == nil {
+		return nil, result.Error("No slash evidence accumulated for reserved fund %v of %v",
+			tx.ReserveSequence, tx.SlashedAddress).WithErrorCode(result.CodeSlashNoEvidence)
+	}
+	slashProofBytes, err := types.ToBytes(evidence.OverspendingProof())
+	if err != nil {
+		return nil, result.Error("Failed to encode the accumulated slash evidence: %v", err)
+	}
+	return slashProofBytes, result.OK
+}
+
+// proofVerificationCost returns the number of signatures verified for the slash proofs of the given SlashTx,
+// BatchSlashTx or MultiFundSlashTx, which is charged against the verification budget of the block
+func (exec *SlashTxExecutor) proofVerificationCost(view *st.StoreView, transaction types.Tx) uint64 {
+	switch tx := transaction.(type) {
+	case *types.SlashTx:
+		slashProofBytes, res := getSlashProof(view, tx)
+		if res.IsError() {
+			return 0 // rejected by the sanity check anyway
+		}
+		return slashProofVerificationCost(slashProofBytes)
+	case *types.BatchSlashTx:
+		cost := uint64(0)
+		for idx := range tx.Entries {
+			cost += exec.proofVerificationCost(view, tx.SlashTx(idx))
+		}
+		return cost
+	case *types.MultiFundSlashTx:
+		cost := uint64(0)
+		for idx := range tx.Funds {
+			cost += exec.proofVerificationCost(view, tx.SlashTx(idx))
+		}
+		return cost
+	default:
+		return 0
+	}
+}
+
+func slashProofVerificationCost(slashProofBytes common.Bytes) uint64 {
+	if isChannelRevocationProof(slashProofBytes) {
+		return 2 // the claimed and the newer states
+	}
+	if overspendingProof, err := decodeOverspendingProof(slashProofBytes); err == nil {
+		return uint64(len(overspendingProof.ServicePayments))
+	}
+	return 0
+}
+
+// checkProofVerificationBudget verifies the slash proofs costing the given number of signature verifications
+// fit in the remaining verification budget of the block
+func (exec *SlashTxExecutor) checkProofVerificationBudget(view *st.StoreView, cost uint64) result.Result {
+	maxVerifications := exec.params.MaxProofVerificationsPerBlock
+	if maxVerifications == 0 || cost == 0 {
+		return result.OK
+	}
+	spent := view.SlashProofVerifications()
+	if spent+cost > maxVerifications {
+		return result.Error("Slash proof verification budget exceeded: %v of %v verifications spent in the block, "+
+			"the slash requires %v", spent, maxVerifications, cost).
+			WithErrorCode(result.CodeSlashVerificationBudgetExceeded)
+	}
+	return result.OK
+}
+
+// isSlashProofPaymentTarget tells whether the address receives any of the service payments in the slash proof
+func isSlashProofPaymentTarget(slashProofBytes common.Bytes, addr common.Address) bool {
+	var servicePayments []types.ServicePaymentTx
+	if revocationProof, err := decodeChannelRevocationProof(slashProofBytes); err == nil {
+		servicePayments = []types.ServicePaymentTx{revocationProof.ClaimedState, revocationProof.NewerState}
+	} else if overspendingProof, err := decodeOverspendingProof(slashProofBytes); err == nil {
+		servicePayments = overspendingProof.ServicePayments
+	}
+
+	for _, servicePaymentTx := range servicePayments {
+		if servicePaymentTx.Target.Address == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// findReservedFund locates the reserved fund with the given reserve sequence, see Account.FindReservedFund
+func findReservedFund(account *types.Account, reserveSequence uint64) (*types.ReservedFund, int, result.Result) {
+	reservedFund, reservedFundIdx, ok := account.FindReservedFund(reserveSequence)
+	if ok {
+		return reservedFund, reservedFundIdx, result.OK
+	}
+	if account.CountReservedFunds(reserveSequence) > 1 {
+		return nil, -1, result.Error("Multiple reserved funds found for %v", reserveSequence).
+			WithErrorCode(result.CodeSlashDuplicateReserveSequence)
+	}
+	return nil, -1, result.Error("Reserved fund not found for %v", reserveSequence).
+		WithErrorCode(result.CodeSlashReservedFundNotFound)
+}
+
+// DecodeSlashProof decodes the OverspendingProof carried by the given SlashTx without executing it,
+// e.g. for operators to inspect a pending SlashTx
+func DecodeSlashProof(tx *types.SlashTx) (*types.OverspendingProof, error) {
+	if tx == nil {
+		return nil, errors.New("SlashTx is nil")
+	}
+	return decodeOverspendingProof(tx.SlashProof)
+}
+
+// invalidSlashProofEncoding reports a slash proof that cannot be decoded. A malformed proof only fails the
+// SlashTx carrying it
+func invalidSlashProofEncoding(err error) result.Result {
+	return result.Error("Invalid slash proof encoding: %v", err).WithErrorCode(result.CodeSlashInvalidProof)
+}
+
+func decodeOverspendingProof(overspendingProofBytes []byte) (*types.OverspendingProof, error) {
+	overspendingProof, _, err := decodeBoundOverspendingProof(overspendingProofBytes)
+	return overspendingProof, err
+}
+
+// decodeBoundOverspendingProof decodes an overspending proof, which may be bound to the ReserveFundTx that
+// created the reserved fund. The returned ReserveFundTx hash is nil if the proof is not bound
+func decodeBoundOverspendingProof(overspendingProofBytes []byte) (*types.OverspendingProof, *common.Hash, error) {
+	if len(overspendingProofBytes) == 0 {
+		return nil, nil, errors.New("Empty overspending proof")
+	}
+	overspendingProof := &types.OverspendingProof{}
+	if types.IsOverspendingProofJSON(overspendingProofBytes) {
+		if err := types.DecodeOverspendingProofJSON(overspendingProofBytes, overspendingProof); err != nil {
+			return nil, nil, err
+		}
+		return overspendingProof, nil, nil
+	}
+
+	err := types.FromBytes(overspendingProofBytes, overspendingProof)
+	if err == nil {
+		return overspendingProof, nil, nil
+	}
+	boundProof := &types.ReserveBoundOverspendingProof{}
+	if types.FromBytes(overspendingProofBytes, boundProof) != nil {
+		return nil, nil, err
+	}
+	return &boundProof.Proof, &boundProof.ReserveTxHash, nil
+}
+
+func decodeChannelRevocationProof(revocationProofBytes []byte) (*types.ChannelRevocationProof, error) {
+	if len(revocationProofBytes) == 0 {
+		return nil, errors.New("Empty channel revocation proof")
+	}
+	revocationProof := &types.ChannelRevocationProof{}
+	err := types.FromBytes(revocationProofBytes, revocationProof)
+	if err != nil {
+		return nil, err
+	}
+	return revocationProof, nil
+}
+
+// isChannelRevocationProof tells whether the slash proof is a channel revocation proof rather than
+// an overspending proof. The two proofs have different RLP layouts and cannot be mistaken for each other
+func isChannelRevocationProof(slashProofBytes []byte) bool {
+	_, err := decodeChannelRevocationProof(slashProofBytes)
+	return err == nil
+}
@@ -0,0 +1,842 @@
+package execution
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+func TestDecodeSlashProof(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+
+	slashTx := createSlashTx(et.chainID, &proposer, 1, slashIntent.Address, slashIntent.ReserveSequence, slashIntent.Proof)
+	proof, err := DecodeSlashProof(slashTx)
+	assert.Nil(err)
+	assert.Equal(slashIntent.ReserveSequence, proof.ReserveSequence)
+	assert.Equal(1, len(proof.ServicePayments))
+	assert.Equal(alice.Address, proof.ServicePayments[0].Source.Address)
+
+	slashTx = createSlashTx(et.chainID, &proposer, 1, slashIntent.Address, slashIntent.ReserveSequence, []byte("malformed proof"))
+	proof, err = DecodeSlashProof(slashTx)
+	assert.NotNil(err)
+	assert.Nil(proof)
+
+	slashTx = createSlashTx(et.chainID, &proposer, 1, slashIntent.Address, slashIntent.ReserveSequence, nil)
+	proof, err = DecodeSlashProof(slashTx)
+	assert.NotNil(err)
+	assert.Nil(proof)
+
+	proof, err = DecodeSlashProof(nil)
+	assert.NotNil(err)
+	assert.Nil(proof)
+}
+
+func createChannelRevocationProof(assert *assert.Assertions, claimedState, newerState *types.ServicePaymentTx) common.Bytes {
+	proofBytes, err := types.ToBytes(&types.ChannelRevocationProof{
+		ClaimedState: *claimedState,
+		NewerState:   *newerState,
+	})
+	assert.Nil(err)
+	return proofBytes
+}
+
+func TestSlashTxChannelRevocation(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, proposer, _ := setupForSlash(assert)
+
+	txFee := getMinimumTxFee()
+	claimedState := createServicePaymentTx(et.chainID, &alice, &bob, 100*txFee, 1, 1, 2, 1, resourceID)
+	newerState := createServicePaymentTx(et.chainID, &alice, &bob, 200*txFee, 1, 1, 3, 1, resourceID)
+	proof := createChannelRevocationProof(assert, claimedState, newerState)
+	assert.True(isChannelRevocationProof(proof))
+
+	aliceAcc := et.state().Delivered().GetAccount(alice.Address)
+	reservedFund := aliceAcc.ReservedFunds[0]
+	proposerInitBalance := et.state().Delivered().GetAccount(proposer.Address).Balance
+
+	slashTx := createSlashTxWithReason(et.chainID, &proposer, 1, alice.Address, 1, types.SlashReasonChannelRevocation, proof)
+	res := et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(slashTx).process(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(types.SlashReasonChannelRevocation, res.Info["slash_reason"])
+
+	expectedSlashedAmount := reservedFund.Collateral.Plus(reservedFund.InitialFund).Minus(reservedFund.UsedFund)
+	proposerBalance := et.state().Delivered().GetAccount(proposer.Address).Balance
+	assert.True(proposerInitBalance.Plus(expectedSlashedAmount).IsEqual(proposerBalance))
+	assert.Equal(0, len(et.state().Delivered().GetAccount(alice.Address).ReservedFunds))
+}
+
+func TestSlashTxStaleChannelRevocation(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, proposer, slashIntent := setupForSlash(assert)
+
+	txFee := getMinimumTxFee()
+	claimedState := createServicePaymentTx(et.chainID, &alice, &bob, 100*txFee, 1, 1, 2, 1, resourceID)
+	view := et.state().Delivered()
+
+	// The "newer" state has a smaller payment sequence
+	olderState := createServicePaymentTx(et.chainID, &alice, &bob, 200*txFee, 1, 1, 1, 1, resourceID)
+	slashTx := createSlashTxWithReason(et.chainID, &proposer, 1, alice.Address, 1, types.SlashReasonChannelRevocation,
+		createChannelRevocationProof(assert, claimedState, olderState))
+	res := et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError(), res.Message)
+
+	// The newer state does not contradict the claimed one
+	sameState := createServicePaymentTx(et.chainID, &alice, &bob, 100*txFee, 1, 1, 3, 1, resourceID)
+	slashTx = createSlashTxWithReason(et.chainID, &proposer, 1, alice.Address, 1, types.SlashReasonChannelRevocation,
+		createChannelRevocationProof(assert, claimedState, sameState))
+	res = et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError(), res.Message)
+
+	// The newer state belongs to another channel
+	otherChannelState := createServicePaymentTx(et.chainID, &alice, &bob, 200*txFee, 1, 1, 3, 1, "rid002")
+	slashTx = createSlashTxWithReason(et.chainID, &proposer, 1, alice.Address, 1, types.SlashReasonChannelRevocation,
+		createChannelRevocationProof(assert, claimedState, otherChannelState))
+	res = et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError(), res.Message)
+
+	// The newer state is not signed by the slashed account
+	forgedState := createServicePaymentTx(et.chainID, &alice, &bob, 200*txFee, 1, 1, 3, 1, resourceID)
+	forgedState.Source.Signature = bob.Sign(forgedState.SourceSignBytes(et.chainID))
+	slashTx = createSlashTxWithReason(et.chainID, &proposer, 1, alice.Address, 1, types.SlashReasonChannelRevocation,
+		createChannelRevocationProof(assert, claimedState, forgedState))
+	res = et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError(), res.Message)
+
+	// Overspending proofs are not mistaken for channel revocation proofs
+	assert.False(isChannelRevocationProof(slashIntent.Proof))
+}
+
+func TestSlashTxReason(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, proposer, slashIntent := setupForSlash(assert)
+	et.executor.SlashTxExecutor().SetSlashFlagPolicy(types.AccountSlashStatusSlashed, 0)
+
+	txFee := getMinimumTxFee()
+	claimedState := createServicePaymentTx(et.chainID, &alice, &bob, 100*txFee, 1, 1, 2, 1, resourceID)
+	newerState := createServicePaymentTx(et.chainID, &alice, &bob, 200*txFee, 1, 1, 3, 1, resourceID)
+	revocationProof := createChannelRevocationProof(assert, claimedState, newerState)
+	view := et.state().Delivered()
+
+	// The reason must match the type of the slash proof
+	for _, tc := range []struct {
+		reason types.SlashReason
+		proof  common.Bytes
+	}{
+		{types.SlashReasonChannelRevocation, slashIntent.Proof},
+		{types.SlashReasonOverspending, revocationProof},
+		{types.SlashReasonDoubleSigning, slashIntent.Proof},
+		{types.SlashReasonDowntime, revocationProof},
+		{types.SlashReason(100), slashIntent.Proof},
+	} {
+		slashTx := createSlashTxWithReason(et.chainID, &proposer, 1, alice.Address, 1, tc.reason, tc.proof)
+		res := et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, view, slashTx)
+		assert.True(res.IsError(), tc.reason.String())
+		assert.Equal(result.CodeSlashReasonMismatch, res.Code, tc.reason.String())
+	}
+
+	// The reason is recorded on the slashed account
+	slashTx := createSlashTxWithReason(et.chainID, &proposer, 1, alice.Address, 1, types.SlashReasonOverspending, slashIntent.Proof)
+	res := et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(slashTx).process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(types.SlashReasonOverspending, res.Info["slash_reason"])
+
+	flag := view.GetAccountSlashFlag(alice.Address)
+	assert.NotNil(flag)
+	assert.Equal(types.SlashReasonOverspending, flag.Reason)
+}
+
+func TestSlashTxWouldAcceptProof(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, _, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+	slashExec := et.executor.SlashTxExecutor()
+	txFee := getMinimumTxFee()
+
+	accepted, reason := slashExec.WouldAcceptProof(et.chainID, view, alice.Address, slashIntent.Proof)
+	assert.True(accepted, reason)
+
+	createProof := func(reserveSequence uint64, payments ...*types.ServicePaymentTx) common.Bytes {
+		proof := &types.OverspendingProof{ReserveSequence: reserveSequence}
+		for _, payment := range payments {
+			proof.ServicePayments = append(proof.ServicePayments, *payment)
+		}
+		proofBytes, err := types.ToBytes(proof)
+		assert.Nil(err)
+		return proofBytes
+	}
+	forgedPayment := createServicePaymentTx(et.chainID, &alice, &bob, 100*txFee, 1, 1, 1, 1, resourceID)
+	forgedPayment.Source.Signature = bob.Sign(forgedPayment.SourceSignBytes(et.chainID))
+
+	for _, tc := range []struct {
+		description    string
+		slashedAddress common.Address
+		proof          common.Bytes
+	}{
+		{"unparsable proof", alice.Address, common.Bytes("not a proof")},
+		{"missing account", common.HexToAddress("0x1234"), slashIntent.Proof},
+		{"missing reserved fund", alice.Address, createProof(2,
+			createServicePaymentTx(et.chainID, &alice, &bob, 100*txFee, 1, 1, 1, 2, resourceID))},
+		{"proof against another account", bob.Address, slashIntent.Proof},
+		{"forged payment", alice.Address, createProof(1, forgedPayment)},
+		{"not overspent", alice.Address, createProof(1,
+			createServicePaymentTx(et.chainID, &alice, &bob, 100*txFee, 1, 1, 1, 1, resourceID))},
+	} {
+		accepted, reason := slashExec.WouldAcceptProof(et.chainID, view, tc.slashedAddress, tc.proof)
+		assert.False(accepted, tc.description)
+		assert.NotEmpty(reason, tc.description)
+	}
+
+	// Unbound proofs are rejected once the binding to the ReserveFundTx is required
+	slashExec.SetRequireReserveTxHash(true)
+	accepted, reason = slashExec.WouldAcceptProof(et.chainID, view, alice.Address, slashIntent.Proof)
+	assert.False(accepted)
+	assert.NotEmpty(reason)
+
+	// Checking a proof does not consume the reserved fund
+	assert.Equal(1, len(view.GetAccount(alice.Address).ReservedFunds))
+}
+
+func TestSlashTxGarbageProof(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+	slashExec := et.executor.SlashTxExecutor()
+
+	rng := rand.New(rand.NewSource(257))
+	garbageProofs := []common.Bytes{
+		common.Bytes("not a proof"),
+		slashIntent.Proof[:len(slashIntent.Proof)/2],
+	}
+	for i := 0; i < 16; i++ {
+		garbage := make(common.Bytes, 1+rng.Intn(256))
+		rng.Read(garbage)
+		garbageProofs = append(garbageProofs, garbage)
+	}
+
+	for idx, garbage := range garbageProofs {
+		slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, garbage)
+		assert.NotPanics(func() {
+			res := slashExec.sanityCheck(et.chainID, view, slashTx)
+			assert.True(res.IsError(), "garbage proof #%v", idx)
+			assert.Contains(res.Message, "Invalid slash proof encoding", "garbage proof #%v", idx)
+		})
+	}
+
+	// The node keeps processing valid SlashTxs
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+}
+
+func TestSlashTxJSONEncodedProof(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+
+	proof, err := decodeOverspendingProof(slashIntent.Proof)
+	assert.Nil(err)
+	jsonProof, err := types.EncodeOverspendingProofJSON(proof)
+	assert.Nil(err)
+
+	decodedProof, err := decodeOverspendingProof(jsonProof)
+	assert.Nil(err)
+	assert.Equal(proof.ReserveSequence, decodedProof.ReserveSequence)
+	assert.Equal(len(proof.ServicePayments), len(decodedProof.ServicePayments))
+
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, slashIntent.ReserveSequence, jsonProof)
+	res := et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(slashTx).process(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(0, len(et.state().Delivered().GetAccount(alice.Address).ReservedFunds))
+}
+
+func execSlashEvidenceTx(et *execTest, tx *types.SlashEvidenceTx) result.Result {
+	res := et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	if res.IsError() {
+		return res
+	}
+	_, res = et.executor.getTxExecutor(tx).process(et.chainID, et.state().Delivered(), tx)
+	et.state().Commit()
+	return res
+}
+
+func TestSlashEvidenceAccumulation(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, carol, _, _, _ := setupForServicePayment(assert)
+	proposer := et.accProposer
+	et.acc2State(proposer)
+	et.state().Commit()
+
+	// Alice has reserved 1000*txFee, and overspends it with two payments that are submitted separately
+	txFee := getMinimumTxFee()
+	payment1 := createServicePaymentTx(et.chainID, &alice, &bob, 600*txFee, 1, 1, 1, 1, resourceID)
+	payment2 := createServicePaymentTx(et.chainID, &alice, &bob, 600*txFee, 1, 1, 2, 1, resourceID)
+
+	res := execSlashEvidenceTx(et, createSlashEvidenceTx(et.chainID, &carol, 1, alice.Address, 1, payment1))
+	assert.True(res.IsOK(), res.Message)
+	evidence := et.state().Delivered().GetSlashEvidence(alice.Address, 1)
+	assert.NotNil(evidence)
+	assert.Equal(1, len(evidence.ServicePayments))
+	assert.Equal(et.state().Delivered().Height()+types.SlashEvidenceExpiryDuration-1, evidence.EndBlockHeight)
+
+	// The accumulated evidence does not prove overspending yet
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, nil)
+	res = et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsError(), res.Message)
+
+	// A service payment cannot be submitted twice
+	res = execSlashEvidenceTx(et, createSlashEvidenceTx(et.chainID, &carol, 2, alice.Address, 1, payment1))
+	assert.True(res.IsError(), res.Message)
+
+	// Service payments not signed by the slashed account are rejected
+	forgedPayment := createServicePaymentTx(et.chainID, &bob, &carol, 600*txFee, 1, 1, 3, 1, resourceID)
+	res = execSlashEvidenceTx(et, createSlashEvidenceTx(et.chainID, &carol, 2, alice.Address, 1, forgedPayment))
+	assert.True(res.IsError(), res.Message)
+
+	res = execSlashEvidenceTx(et, createSlashEvidenceTx(et.chainID, &carol, 2, alice.Address, 1, payment2))
+	assert.True(res.IsOK(), res.Message)
+	evidence = et.state().Delivered().GetSlashEvidence(alice.Address, 1)
+	assert.Equal(2, len(evidence.ServicePayments))
+
+	// The SlashTx consumes the accumulated evidence
+	proposerInitBalance := et.state().Delivered().GetAccount(proposer.Address).Balance
+	reservedFund := et.state().Delivered().GetAccount(alice.Address).ReservedFunds[0]
+	res = et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(slashTx).process(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	expectedSlashedAmount := reservedFund.Collateral.Plus(reservedFund.InitialFund)
+	proposerBalance := et.state().Delivered().GetAccount(proposer.Address).Balance
+	assert.True(proposerInitBalance.Plus(expectedSlashedAmount).IsEqual(proposerBalance))
+	assert.Equal(0, len(et.state().Delivered().GetAccount(alice.Address).ReservedFunds))
+	assert.Nil(et.state().Delivered().GetSlashEvidence(alice.Address, 1))
+}
+
+func TestSlashEvidenceExpiry(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, carol, _, _, _ := setupForServicePayment(assert)
+	proposer := et.accProposer
+	et.acc2State(proposer)
+	et.state().Commit()
+
+	txFee := getMinimumTxFee()
+	payment1 := createServicePaymentTx(et.chainID, &alice, &bob, 600*txFee, 1, 1, 1, 1, resourceID)
+	payment2 := createServicePaymentTx(et.chainID, &alice, &bob, 600*txFee, 1, 1, 2, 1, resourceID)
+
+	res := execSlashEvidenceTx(et, createSlashEvidenceTx(et.chainID, &carol, 1, alice.Address, 1, payment1, payment2))
+	assert.True(res.IsOK(), res.Message)
+	evidence := et.state().Delivered().GetSlashEvidence(alice.Address, 1)
+	assert.NotNil(evidence)
+
+	et.fastforwardTo(evidence.EndBlockHeight + 1)
+
+	// The evidence has expired, and cannot be consumed any more
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, nil)
+	res = et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsError(), res.Message)
+	_, res = et.executor.getTxExecutor(slashTx).process(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsError(), res.Message)
+
+	// The expired evidence is swept by the next SlashEvidenceTx, which starts the accumulation over
+	payment3 := createServicePaymentTx(et.chainID, &alice, &carol, 100*txFee, 1, 1, 3, 1, resourceID)
+	res = execSlashEvidenceTx(et, createSlashEvidenceTx(et.chainID, &bob, 1, alice.Address, 1, payment1))
+	assert.True(res.IsOK(), res.Message)
+	evidence = et.state().Delivered().GetSlashEvidence(alice.Address, 1)
+	assert.Equal(1, len(evidence.ServicePayments))
+	assert.True(evidence.EndBlockHeight > et.state().Delivered().Height())
+
+	// Expired evidence against other reserved funds is swept as well
+	et.state().Delivered().SetSlashEvidence(&types.SlashEvidence{
+		Address:         bob.Address,
+		ReserveSequence: 1,
+		EndBlockHeight:  1,
+	})
+	et.state().Commit()
+	res = execSlashEvidenceTx(et, createSlashEvidenceTx(et.chainID, &bob, 2, alice.Address, 1, payment3))
+	assert.True(res.IsOK(), res.Message)
+	assert.Nil(et.state().Delivered().GetSlashEvidence(bob.Address, 1))
+	assert.Equal(2, len(et.state().Delivered().GetSlashEvidence(alice.Address, 1).ServicePayments))
+}
+
+func TestSlashTxConflictedProposer(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	// Alice overspends her reserved fund with a payment to the proposer
+	txFee := getMinimumTxFee()
+	payment := createServicePaymentTx(et.chainID, &alice, &proposer, 8000*txFee, 1, 1, 1, 1, resourceID)
+	proof, err := types.ToBytes(&types.OverspendingProof{
+		ReserveSequence: 1,
+		ServicePayments: []types.ServicePaymentTx{*payment},
+	})
+	assert.Nil(err)
+	conflictedSlashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, proof)
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+
+	// The rule is disabled by default
+	slashExec := et.executor.SlashTxExecutor()
+	res := slashExec.sanityCheck(et.chainID, view, conflictedSlashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	slashExec.SetRejectConflictedProposer(true)
+	res = slashExec.sanityCheck(et.chainID, view, conflictedSlashTx)
+	assert.True(res.IsError(), res.Message)
+	assert.Equal(result.CodeSlashConflictedProposer, res.Code)
+
+	// The proposer is not a payment target of the original proof
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+}
+
+func TestSlashTxPaymentWrongReserveSequence(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, proposer, _ := setupForSlash(assert)
+
+	// The second payment is signed by Alice, but against another reserved fund
+	txFee := getMinimumTxFee()
+	payment1 := createServicePaymentTx(et.chainID, &alice, &bob, 800*txFee, 1, 1, 1, 1, resourceID)
+	payment2 := createServicePaymentTx(et.chainID, &alice, &bob, 800*txFee, 1, 1, 2, 2, resourceID)
+	proof, err := types.ToBytes(&types.OverspendingProof{
+		ReserveSequence: 1,
+		ServicePayments: []types.ServicePaymentTx{*payment1, *payment2},
+	})
+	assert.Nil(err)
+
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, proof)
+	res := et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsError(), res.Message)
+	assert.Equal(result.CodeSlashWrongReserveSequence, res.Code)
+	assert.Contains(res.Message, "wrong reserve sequence")
+}
+
+func TestSlashTxProofTimestampSkew(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	// The time of the block being executed is used, not the one of the local tip
+	blockTime := int64(1000000)
+	view.SetBlockTimestamp(big.NewInt(blockTime))
+	et.executor.consensus.(*TestConsensusEngine).tip = &core.ExtendedBlock{
+		Block: &core.Block{BlockHeader: &core.BlockHeader{Timestamp: big.NewInt(blockTime + 3600)}},
+	}
+
+	createTimestampedSlashTx := func(timestamp int64) *types.SlashTx {
+		proof, err := decodeOverspendingProof(slashIntent.Proof)
+		assert.Nil(err)
+		proof.SetTimestamp(big.NewInt(timestamp))
+		proofBytes, err := types.ToBytes(proof)
+		assert.Nil(err)
+
+		decodedProof, err := decodeOverspendingProof(proofBytes)
+		assert.Nil(err)
+		assert.Equal(timestamp, decodedProof.GetTimestamp().Int64())
+		return createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, proofBytes)
+	}
+
+	// Timestamps are not required by default
+	slashExec := et.executor.SlashTxExecutor()
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	slashExec.SetMaxProofTimestampSkew(60)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError(), res.Message)
+	assert.Equal(result.CodeSlashProofTimestampOutOfSkew, res.Code)
+
+	// In skew
+	for _, timestamp := range []int64{blockTime, blockTime - 60, blockTime + 60} {
+		res = slashExec.sanityCheck(et.chainID, view, createTimestampedSlashTx(timestamp))
+		assert.True(res.IsOK(), res.Message)
+	}
+
+	// Out of skew
+	for _, timestamp := range []int64{blockTime - 61, blockTime + 61, 0} {
+		res = slashExec.sanityCheck(et.chainID, view, createTimestampedSlashTx(timestamp))
+		assert.True(res.IsError(), res.Message)
+		assert.Equal(result.CodeSlashProofTimestampOutOfSkew, res.Code)
+	}
+}
+
+func TestSlashStateProof(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+
+	unslashedStateRoot := et.state().Commit()
+	unslashedProof, err := st.GenerateSlashStateProof(et.state().Delivered(), slashTx)
+	assert.Nil(err)
+
+	res := et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(slashTx).process(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsOK(), res.Message)
+	stateRoot := et.state().Commit()
+
+	proof, err := st.GenerateSlashStateProof(et.state().Delivered(), slashTx)
+	assert.Nil(err)
+
+	slashedAccount, proposerAccount, err := st.VerifySlashStateProof(stateRoot, proof)
+	assert.Nil(err)
+	assert.Equal(et.state().Delivered().GetAccount(alice.Address).Hash(), slashedAccount.Hash())
+	assert.Equal(et.state().Delivered().GetAccount(proposer.Address).Hash(), proposerAccount.Hash())
+	assert.Equal(0, len(slashedAccount.ReservedFunds))
+
+	// The proof does not verify against a different state root
+	_, _, err = st.VerifySlashStateProof(unslashedStateRoot, proof)
+	assert.NotNil(err)
+
+	// The state before the slash still holds the reserved fund
+	_, _, err = st.VerifySlashStateProof(unslashedStateRoot, unslashedProof)
+	assert.NotNil(err)
+}
+
+func TestSlashTxForeignChainProof(t *testing.T) {
+	assert := assert.New(t)
+	txFee := getMinimumTxFee()
+	et, resourceID, alice, bob, proposer, _ := setupForSlash(assert)
+	view := et.state().Delivered()
+	slashExec := et.executor.SlashTxExecutor()
+
+	makeProof := func(chainID string) common.Bytes {
+		payment := createServicePaymentTx(chainID, &alice, &bob, 8000*txFee, 1, 1, 1, 1, resourceID)
+		proof, err := types.ToBytes(&types.OverspendingProof{
+			ReserveSequence: 1,
+			ServicePayments: []types.ServicePaymentTx{*payment},
+		})
+		assert.Nil(err)
+		return proof
+	}
+
+	// The payments signed for another chain cannot be replayed against this chain
+	foreignProof := makeProof("other_chain_id")
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, foreignProof)
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError(), res.Message)
+	assert.Contains(res.Message, "for chain "+et.chainID)
+	res = slashExec.VerifyProof(et.chainID, view, alice.Address, foreignProof)
+	assert.True(res.IsError(), res.Message)
+
+	// The same payments signed for this chain are accepted
+	proof := makeProof(et.chainID)
+	slashTx = createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, proof)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	res = slashExec.VerifyProof(et.chainID, view, alice.Address, proof)
+	assert.True(res.IsOK(), res.Message)
+}
+
+func TestSlashTxBoundSnapshot(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+
+	snapshotStateRoot := et.state().Commit()
+	view := et.state().Delivered()
+	snapshotHeight := view.Height() - 1
+
+	createBoundSlashTx := func(height uint64, stateRoot common.Hash) *types.SlashTx {
+		slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+		slashTx.BindSnapshot(height, stateRoot)
+		slashTx.Proposer.Signature = proposer.Sign(slashTx.SignBytes(et.chainID))
+		return slashTx
+	}
+
+	// The current state differs from the snapshot, and no longer shows the overspending
+	aliceAccount := view.GetAccount(alice.Address)
+	reservedFund := aliceAccount.ReservedFunds[0]
+	aliceAccount.ReservedFunds[0].InitialFund = reservedFund.InitialFund.Plus(types.NewCoins(0, 100000*getMinimumTxFee()))
+	view.SetAccount(alice.Address, aliceAccount)
+
+	slashExec := et.executor.SlashTxExecutor()
+	unboundSlashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	res := slashExec.sanityCheck(et.chainID, view, unboundSlashTx)
+	assert.True(res.IsError(), res.Message)
+
+	// Snapshots that are not available, or beyond the current height, are rejected
+	res = slashExec.sanityCheck(et.chainID, view, createBoundSlashTx(snapshotHeight, common.BytesToHash([]byte("unknown root"))))
+	assert.Equal(result.CodeSlashInvalidSnapshot, res.Code, res.Message)
+	res = slashExec.sanityCheck(et.chainID, view, createBoundSlashTx(view.Height()+1, snapshotStateRoot))
+	assert.Equal(result.CodeSlashInvalidSnapshot, res.Code, res.Message)
+
+	// The proof is verified against the bound snapshot
+	boundSlashTx := createBoundSlashTx(snapshotHeight, snapshotStateRoot)
+	res = slashExec.sanityCheck(et.chainID, view, boundSlashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	proposerInitBalance := view.GetAccount(proposer.Address).Balance
+	_, res = slashExec.process(et.chainID, view, boundSlashTx)
+	assert.True(res.IsOK(), res.Message)
+	currentReservedFund := aliceAccount.ReservedFunds[0]
+	expectedSlashedAmount := currentReservedFund.Collateral.Plus(currentReservedFund.InitialFund)
+	assert.True(proposerInitBalance.Plus(expectedSlashedAmount).IsEqual(view.GetAccount(proposer.Address).Balance))
+	assert.Equal(0, len(view.GetAccount(alice.Address).ReservedFunds))
+}
+
+func TestSlashTxMarginalOverspend(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	hook := &testLogHook{}
+	standardLogger := log.StandardLogger()
+	hooks := make(log.LevelHooks)
+	for l, levelHooks := range standardLogger.Hooks {
+		hooks[l] = levelHooks
+	}
+	log.AddHook(hook)
+	defer func() {
+		standardLogger.Hooks = hooks
+	}()
+
+	countMarginalEntries := func() int {
+		count := 0
+		for _, entry := range hook.entries {
+			if _, ok := entry.Data["marginalOverspend"]; ok {
+				assert.Equal(log.WarnLevel, entry.Level)
+				count++
+			}
+		}
+		hook.entries = nil
+		return count
+	}
+
+	slashExec := et.executor.SlashTxExecutor()
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+
+	// Disabled by default
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(0, countMarginalEntries())
+
+	// The reserved fund of 1000 txFee is overspent by 7000 txFee
+	txFee := getMinimumTxFee()
+	slashExec.SetMarginalOverspendMargin(types.NewCoins(0, 7000*txFee))
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(0, countMarginalEntries())
+
+	slashExec.SetMarginalOverspendMargin(types.NewCoins(0, 7001*txFee))
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(1, countMarginalEntries())
+
+	// The margin only applies to the overspent denominations
+	slashExec.SetMarginalOverspendMargin(types.NewCoins(7001*txFee, 0))
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(0, countMarginalEntries())
+}
+
+func TestSlashTxReserveTxHash(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	reserveTxHash, exists := view.GetReserveFundTxHash(alice.Address, 1)
+	assert.True(exists)
+
+	overspendingProof, err := decodeOverspendingProof(slashIntent.Proof)
+	assert.Nil(err)
+	createBoundProof := func(txHash common.Hash) common.Bytes {
+		proof, err := types.ToBytes(&types.ReserveBoundOverspendingProof{
+			ReserveTxHash: txHash,
+			Proof:         *overspendingProof,
+		})
+		assert.Nil(err)
+		return proof
+	}
+	boundProof := bindOverspendingProof(view, alice.Address, 1, slashIntent.Proof)
+	assert.Equal(createBoundProof(reserveTxHash), boundProof)
+
+	slashExec := et.executor.SlashTxExecutor()
+	unboundSlashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	boundSlashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, boundProof)
+	mismatchedSlashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1,
+		createBoundProof(common.BytesToHash([]byte("another reserve fund tx"))))
+
+	// The binding is checked whenever present
+	res := slashExec.sanityCheck(et.chainID, view, unboundSlashTx)
+	assert.True(res.IsOK(), res.Message)
+	res = slashExec.sanityCheck(et.chainID, view, boundSlashTx)
+	assert.True(res.IsOK(), res.Message)
+	res = slashExec.sanityCheck(et.chainID, view, mismatchedSlashTx)
+	assert.Equal(result.CodeSlashReserveTxHashMismatch, res.Code, res.Message)
+
+	// And is mandatory if required
+	slashExec.SetRequireReserveTxHash(true)
+	res = slashExec.sanityCheck(et.chainID, view, unboundSlashTx)
+	assert.Equal(result.CodeSlashReserveTxHashMismatch, res.Code, res.Message)
+	res = slashExec.sanityCheck(et.chainID, view, mismatchedSlashTx)
+	assert.Equal(result.CodeSlashReserveTxHashMismatch, res.Code, res.Message)
+	res = slashExec.sanityCheck(et.chainID, view, boundSlashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	_, res = slashExec.process(et.chainID, view, boundSlashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(0, len(view.GetAccount(alice.Address).ReservedFunds))
+	_, exists = view.GetReserveFundTxHash(alice.Address, 1)
+	assert.False(exists)
+}
+
+func TestSlashTxPaymentSequenceKey(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, bob, _, _, _, _ := setupForServicePayment(assert)
+	proposer := et.accProposer
+	et.acc2State(proposer)
+
+	txFee := getMinimumTxFee()
+	reserveFundTx := createReserveFundTx(et.chainID, &alice, 1000*txFee, 1001*txFee, 2, []string{"rid002"})
+	_, res := et.executor.getTxExecutor(reserveFundTx).process(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+	et.state().Commit()
+
+	view := et.state().Delivered()
+	createProof := func(payments ...*types.ServicePaymentTx) common.Bytes {
+		overspendingProof := &types.OverspendingProof{ReserveSequence: 2}
+		for _, payment := range payments {
+			overspendingProof.ServicePayments = append(overspendingProof.ServicePayments, *payment)
+		}
+		proof, err := types.ToBytes(overspendingProof)
+		assert.Nil(err)
+		return proof
+	}
+	slashExec := et.executor.SlashTxExecutor()
+
+	// A legitimate multi-payment overspending proof
+	payment1 := createServicePaymentTx(et.chainID, &alice, &bob, 600*txFee, 1, 1, 1, 2, "rid002")
+	payment2 := createServicePaymentTx(et.chainID, &alice, &bob, 500*txFee, 1, 1, 2, 2, "rid002")
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 2, createProof(payment1, payment2))
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// Distinct payment sequences beyond the unicode range used to collide as string(sequence)
+	payment1 = createServicePaymentTx(et.chainID, &alice, &bob, 600*txFee, 1, 1, 0x110000, 2, "rid002")
+	payment2 = createServicePaymentTx(et.chainID, &alice, &bob, 500*txFee, 1, 1, 0x110001, 2, "rid002")
+	assert.Equal(string(rune(payment1.PaymentSequence)), string(rune(payment2.PaymentSequence)))
+	slashTx = createSlashTx(et.chainID, &proposer, 1, alice.Address, 2, createProof(payment1, payment2))
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// A payment settled more than once is still rejected
+	payment2 = createServicePaymentTx(et.chainID, &alice, &bob, 500*txFee, 1, 1, 0x110000, 2, "rid002")
+	slashTx = createSlashTx(et.chainID, &proposer, 1, alice.Address, 2, createProof(payment1, payment2))
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError())
+	assert.Contains(res.Message, "settled more than once")
+}
+
+func TestSlashTxProofVerificationBudget(t *testing.T) {
+	assert := assert.New(t)
+
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	slashExec := et.executor.SlashTxExecutor()
+	slashExec.SetMaxProofVerificationsPerBlock(2)
+
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	cost := slashExec.proofVerificationCost(et.state().Delivered(), slashTx)
+	assert.Equal(uint64(1), cost)
+
+	entry := types.SlashEntry{SlashedAddress: alice.Address, ReserveSequence: 1, SlashProof: slashIntent.Proof}
+	batchSlashTx := createBatchSlashTx(et.chainID, &proposer, 1, entry, entry)
+	assert.Equal(2*cost, slashExec.proofVerificationCost(et.state().Delivered(), batchSlashTx))
+
+	// The earlier slashes of the block spent the budget, the slash is deferred
+	et.state().Delivered().AddSlashProofVerifications(2)
+	_, res := et.executor.ExecuteTx(slashTx)
+	assert.Equal(result.CodeSlashVerificationBudgetExceeded, res.Code, res.Message)
+	assert.True(res.IsRetryable())
+	assert.Equal(1, len(et.state().Delivered().GetAccount(alice.Address).ReservedFunds))
+
+	// The budget is per block, the screening is not limited by it
+	et.state().Screened().AddSlashProofVerifications(2)
+	_, res = et.executor.ScreenTx(slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// The deferred slash fits in the budget of the next block
+	et.fastforwardBy(1)
+	assert.Equal(uint64(0), et.state().Delivered().SlashProofVerifications())
+	_, res = et.executor.ExecuteTx(slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(0, len(et.state().Delivered().GetAccount(alice.Address).ReservedFunds))
+	assert.Equal(cost, et.state().Delivered().SlashProofVerifications())
+
+	// Zero disables the budget
+	slashExec.SetMaxProofVerificationsPerBlock(0)
+	assert.True(slashExec.checkProofVerificationBudget(et.state().Delivered(), 1000).IsOK())
+}
+
+// countdownContext is cancelled once its Err method has been called the given number of times
+type countdownContext struct {
+	context.Context
+	remaining int
+}
+
+func (ctx *countdownContext) Err() error {
+	if ctx.remaining <= 0 {
+		return context.Canceled
+	}
+	ctx.remaining--
+	return nil
+}
+
+func TestSlashTxSanityCheckWithContext(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, proposer, _ := setupForSlash(assert)
+	view := et.state().Delivered()
+	slashExec := et.executor.SlashTxExecutor()
+	counters := NewSlashCounters()
+	slashExec.SetSlashMetrics(counters)
+	txFee := getMinimumTxFee()
+
+	// A proof with many payments
+	payments := []types.ServicePaymentTx{}
+	for paymentSeq := 1; paymentSeq <= 20; paymentSeq++ {
+		payments = append(payments, *createServicePaymentTx(et.chainID, &alice, &bob, 100*txFee, 1, 1, paymentSeq, 1, resourceID))
+	}
+	proofBytes, err := BuildProof(view.GetAccount(alice.Address), 1, payments)
+	assert.Nil(err)
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, proofBytes)
+
+	// Cancelled in the middle of the verification of the payments
+	ctx := &countdownContext{Context: context.Background(), remaining: 5}
+	res := slashExec.SanityCheckWithContext(ctx, et.chainID, view, slashTx)
+	assert.True(res.IsError())
+	assert.Equal(result.CodeSlashVerificationAborted, res.Code)
+	assert.True(res.IsRetryable())
+	assert.Contains(res.Message, "service payment #5")
+
+	// Already past the deadline
+	expiredCtx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	res = slashExec.SanityCheckWithContext(expiredCtx, et.chainID, view, slashTx)
+	assert.Equal(result.CodeSlashVerificationAborted, res.Code)
+
+	// The aborts are not counted as rejections
+	assert.Equal(int64(0), counters.Snapshot().Rejections[SlashRejectionInvalidProof])
+
+	res = slashExec.SanityCheckWithContext(context.Background(), et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	sendTx := &types.SendTx{}
+	res = slashExec.SanityCheckWithContext(context.Background(), et.chainID, view, sendTx)
+	assert.True(res.IsError())
+}
@@ -0,0 +1,79 @@
+package execution
+
+import (
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// RejectedSlashQueueSize is the number of rejected SlashTxs queued for the RejectedSlashSink. The rejections
+// beyond it are dropped, so that a slow sink cannot hold up the transaction processing
+const RejectedSlashQueueSize = 256
+
+// RejectedSlashSink records the SlashTxs rejected by the sanity check along with the reason, e.g. for
+// offline analysis of griefing attempts. Recording is best-effort: the rejections are queued and handed
+// to the sink one at a time by a single worker. Those that do not fit in the queue are dropped and counted,
+// and a failure of the sink does not affect the transaction processing
+type RejectedSlashSink interface {
+	RecordRejectedSlash(tx *types.SlashTx, reason result.Result)
+}
+
+type rejectedSlash struct {
+	tx     *types.SlashTx
+	reason result.Result
+}
+
+// rejectedSlashRecorder hands the queued rejections over to the sink
+type rejectedSlashRecorder struct {
+	sink    RejectedSlashSink
+	queue   chan rejectedSlash
+	dropped uint64
+	logger  log.FieldLogger
+}
+
+func newRejectedSlashRecorder(sink RejectedSlashSink, logger log.FieldLogger) *rejectedSlashRecorder {
+	recorder := &rejectedSlashRecorder{
+		sink:   sink,
+		queue:  make(chan rejectedSlash, RejectedSlashQueueSize),
+		logger: logger,
+	}
+	go recorder.run()
+	return recorder
+}
+
+func (recorder *rejectedSlashRecorder) run() {
+	for rejected := range recorder.queue {
+		recorder.record(rejected)
+	}
+}
+
+func (recorder *rejectedSlashRecorder) record(rejected rejectedSlash) {
+	defer func() {
+		if err := recover(); err != nil {
+			recorder.logger.Warnf("Failed to record the rejected SlashTx: %v", err)
+		}
+	}()
+	recorder.sink.RecordRejectedSlash(rejected.tx, rejected.reason)
+}
+
+// enqueue queues the rejection without blocking, it is dropped if the queue is full
+func (recorder *rejectedSlashRecorder) enqueue(tx *types.SlashTx, reason result.Result) {
+	select {
+	case recorder.queue <- rejectedSlash{tx: tx, reason: reason}:
+	default:
+		dropped := atomic.AddUint64(&recorder.dropped, 1)
+		recorder.logger.Warnf("Rejected SlashTx queue is full, dropped %v rejected SlashTxs so far", dropped)
+	}
+}
+
+// stop lets the worker exit once the queued rejections are recorded
+func (recorder *rejectedSlashRecorder) stop() {
+	close(recorder.queue)
+}
+
+func (recorder *rejectedSlashRecorder) droppedCount() uint64 {
+	return atomic.LoadUint64(&recorder.dropped)
+}
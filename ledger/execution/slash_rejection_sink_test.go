@@ -0,0 +1,124 @@
+package execution
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+type testRejectedSlashSink struct {
+	rejected chan rejectedSlash
+}
+
+func (sink *testRejectedSlashSink) RecordRejectedSlash(tx *types.SlashTx, reason result.Result) {
+	sink.rejected <- rejectedSlash{tx: tx, reason: reason}
+}
+
+func TestSlashTxRejectedSlashSink(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, bob, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	sink := &testRejectedSlashSink{rejected: make(chan rejectedSlash, 1)}
+	slashExec := et.executor.SlashTxExecutor()
+	slashExec.SetRejectedSlashSink(sink)
+
+	expectRejected := func(slashTx *types.SlashTx, code result.ErrorCode) {
+		res := slashExec.sanityCheck(et.chainID, view, slashTx)
+		assert.True(res.IsError(), res.Message)
+		select {
+		case rejected := <-sink.rejected:
+			assert.Equal(slashTx, rejected.tx)
+			assert.Equal(res.Message, rejected.reason.Message)
+			assert.Equal(code, rejected.reason.Code)
+		case <-time.After(5 * time.Second):
+			assert.Fail("Rejected slash not recorded", res.Message)
+		}
+	}
+
+	// Proposer is not a validator
+	expectRejected(createSlashTx(et.chainID, &alice, 2, slashIntent.Address, slashIntent.ReserveSequence, slashIntent.Proof), result.CodeSlashNonValidatorProposer)
+
+	// Invalid proposer signature
+	slashTx := createSlashTx(et.chainID, &proposer, 1, slashIntent.Address, slashIntent.ReserveSequence, slashIntent.Proof)
+	slashTx.Proposer.Signature = bob.Sign(slashTx.SignBytes(et.chainID))
+	expectRejected(slashTx, result.CodeSlashInvalidProposerSignature)
+
+	// Reserved fund not found
+	expectRejected(createSlashTx(et.chainID, &proposer, 1, slashIntent.Address, 100, slashIntent.Proof), result.CodeSlashReservedFundNotFound)
+
+	// Invalid slash proof
+	expectRejected(createSlashTx(et.chainID, &proposer, 1, slashIntent.Address, slashIntent.ReserveSequence, []byte("malformed proof")), result.CodeSlashInvalidProof)
+
+	// Reserved fund too old
+	et.fastforwardBy(10)
+	slashExec.SetMaxReservedFundAge(1)
+	expectRejected(createSlashTx(et.chainID, &proposer, 1, slashIntent.Address, slashIntent.ReserveSequence, slashIntent.Proof), result.CodeSlashReservedFundTooOld)
+	slashExec.SetMaxReservedFundAge(0)
+
+	// Accepted slashes are not recorded
+	res := slashExec.sanityCheck(et.chainID, et.state().Delivered(), createSlashTx(et.chainID, &proposer, 1, slashIntent.Address, slashIntent.ReserveSequence, slashIntent.Proof))
+	assert.True(res.IsOK(), res.Message)
+	select {
+	case rejected := <-sink.rejected:
+		assert.Fail("Unexpected rejected slash recorded", rejected.reason.Message)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+type blockingRejectedSlashSink struct {
+	received chan struct{}
+	release  chan struct{}
+}
+
+func (sink *blockingRejectedSlashSink) RecordRejectedSlash(tx *types.SlashTx, reason result.Result) {
+	sink.received <- struct{}{}
+	<-sink.release
+}
+
+func TestSlashTxRejectedSlashSinkQueue(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, _, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	sink := &blockingRejectedSlashSink{
+		received: make(chan struct{}, RejectedSlashQueueSize+2),
+		release:  make(chan struct{}),
+	}
+	slashExec := et.executor.SlashTxExecutor()
+	slashExec.SetRejectedSlashSink(sink)
+	slashTx := createSlashTx(et.chainID, &alice, 2, slashIntent.Address, slashIntent.ReserveSequence, slashIntent.Proof)
+
+	// The worker is held up by the sink
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError(), res.Message)
+	select {
+	case <-sink.received:
+	case <-time.After(5 * time.Second):
+		assert.Fail("Rejected slash not recorded")
+	}
+
+	// The rejections beyond the queue are dropped instead of blocking the sanity check
+	for i := 0; i < RejectedSlashQueueSize+1; i++ {
+		res = slashExec.sanityCheck(et.chainID, view, slashTx)
+		assert.True(res.IsError(), res.Message)
+	}
+	assert.Equal(uint64(1), slashExec.DroppedRejectedSlashes())
+
+	// The queued rejections are recorded once the sink catches up
+	close(sink.release)
+	for i := 0; i < RejectedSlashQueueSize; i++ {
+		select {
+		case <-sink.received:
+		case <-time.After(5 * time.Second):
+			assert.Fail("Queued rejected slash not recorded")
+			return
+		}
+	}
+
+	slashExec.SetRejectedSlashSink(nil)
+	assert.Equal(uint64(0), slashExec.DroppedRejectedSlashes())
+}
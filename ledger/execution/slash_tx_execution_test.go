@@ -0,0 +1,1631 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/metrics"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+func TestSlashTxReserveSequenceMismatch(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, _, proposer, slashIntent := setupForSlash(assert)
+
+	txFee := getMinimumTxFee()
+	reserveFundTx := createReserveFundTx(et.chainID, &alice, 1000*txFee, 1001*txFee, 2, []string{resourceID})
+	res := et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(reserveFundTx).process(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+	et.state().Commit()
+	assert.Equal(2, len(et.state().Delivered().GetAccount(alice.Address).ReservedFunds))
+
+	// The proof covers reserve sequence 1, the SlashTx claims reserve sequence 2
+	slashTx := createSlashTx(et.chainID, &proposer, 1, slashIntent.Address, 2, slashIntent.Proof)
+	res = et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsError(), res.Message)
+
+	slashTx = createSlashTx(et.chainID, &proposer, 1, slashIntent.Address, slashIntent.ReserveSequence, slashIntent.Proof)
+	res = et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsOK(), res.Message)
+}
+
+func TestSlashTxPaymentSource(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, proposer, slashIntent := setupForSlash(assert)
+	txFee := getMinimumTxFee()
+
+	// The payments of the proof come from the slashed account
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	res := et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// A payment signed by another account cannot be charged against the reserved fund of the slashed account
+	foreignPayment := createServicePaymentTx(et.chainID, &bob, &alice, 8000*txFee, 1, 1, 1, 1, resourceID)
+	proof, err := types.ToBytes(&types.OverspendingProof{
+		ReserveSequence: 1,
+		ServicePayments: []types.ServicePaymentTx{*foreignPayment},
+	})
+	assert.Nil(err)
+	slashTx = createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, proof)
+	res = et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsError())
+	assert.Contains(res.Message, "does not come from the slashed account")
+}
+
+func TestSlashTxDuplicateReserveSequence(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+
+	// Should NOT happen since reserve sequences are strictly increasing, but just in case
+	aliceAcc := et.state().Delivered().GetAccount(alice.Address)
+	aliceAcc.ReservedFunds = append(aliceAcc.ReservedFunds, aliceAcc.ReservedFunds[0])
+	et.state().Delivered().SetAccount(alice.Address, aliceAcc)
+	et.state().Commit()
+
+	slashTx := createSlashTx(et.chainID, &proposer, 1, slashIntent.Address, slashIntent.ReserveSequence, slashIntent.Proof)
+	res := et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsError(), res.Message)
+	assert.Equal(result.CodeSlashDuplicateReserveSequence, res.Code)
+
+	_, res = et.executor.getTxExecutor(slashTx).process(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsError(), res.Message)
+	assert.Equal(result.CodeSlashDuplicateReserveSequence, res.Code)
+	assert.Equal(2, len(et.state().Delivered().GetAccount(alice.Address).ReservedFunds))
+}
+
+func TestSlashTxReplay(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	slashExec := et.executor.SlashTxExecutor()
+	view := et.state().Delivered()
+
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = slashExec.process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	proofHash, slashed := view.GetSlashedProofHash(alice.Address, 1)
+	assert.True(slashed)
+	assert.Equal(crypto.Keccak256Hash(slashIntent.Proof), proofHash)
+
+	// Submitted again in the same block
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeSlashAlreadySlashed, res.Code, res.Message)
+
+	// Submitted again in a later block
+	et.fastforwardBy(1)
+	res = slashExec.sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+	assert.Equal(result.CodeSlashAlreadySlashed, res.Code, res.Message)
+
+	// Other reserved funds of the account are not affected
+	_, slashed = et.state().Delivered().GetSlashedProofHash(alice.Address, 2)
+	assert.False(slashed)
+}
+
+func TestSlashWatchtowerAPI(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, proposer, _ := setupForSlash(assert)
+	view := et.state().Delivered()
+	slashExec := et.executor.SlashTxExecutor()
+	treasury := types.MakeAcc("Treasury").Address
+	slashExec.SetSlashSplit(SlashSplit{BurnWeight: 10, TreasuryWeight: 60, ProposerWeight: 30}, treasury)
+	txFee := getMinimumTxFee()
+
+	// Build: the payments are put in the canonical order
+	payments := []types.ServicePaymentTx{
+		*createServicePaymentTx(et.chainID, &alice, &bob, 100*txFee, 1, 1, 2, 1, resourceID),
+		*createServicePaymentTx(et.chainID, &alice, &bob, 8000*txFee, 1, 1, 1, 1, resourceID),
+	}
+	slashedAccount := view.GetAccount(alice.Address)
+	proofBytes, err := BuildProof(slashedAccount, 1, payments)
+	assert.Nil(err)
+	proof, err := DecodeProof(proofBytes)
+	assert.Nil(err)
+	assert.Equal(uint64(1), proof.ReserveSequence)
+	assert.Equal(2, len(proof.ServicePayments))
+	assert.Equal(uint64(1), proof.ServicePayments[0].PaymentSequence)
+	assert.Equal(uint64(2), proof.ServicePayments[1].PaymentSequence)
+
+	_, err = BuildProof(slashedAccount, 2, payments)
+	assert.NotNil(err)
+	_, err = BuildProof(slashedAccount, 1, payments[:1])
+	assert.NotNil(err)
+	_, err = DecodeProof(common.Bytes("not a proof"))
+	assert.NotNil(err)
+
+	// Verify: the same result as the sanity check of a SlashTx carrying the proof
+	res := slashExec.VerifyProof(et.chainID, view, alice.Address, proofBytes)
+	assert.True(res.IsOK(), res.Message)
+	res = slashExec.VerifyProof(et.chainID, view, bob.Address, proofBytes)
+	assert.True(res.IsError())
+
+	// Estimate
+	distribution, res := slashExec.EstimateReward(et.chainID, view, alice.Address, proofBytes)
+	assert.True(res.IsOK(), res.Message)
+	assert.False(distribution.ProposerAmount.IsZero())
+
+	// Execute: the outcome matches the estimate
+	proposerBefore := view.GetAccount(proposer.Address)
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, proofBytes)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = slashExec.process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	events := view.GetEvents()
+	assert.Equal(1, len(events))
+	event := events[0].(*types.SlashEvent)
+	assert.True(distribution.SlashedAmount.IsEqual(event.SlashedAmount))
+	assert.True(distribution.ReturnedAmount.IsEqual(event.ReturnedAmount))
+	assert.True(distribution.BurntAmount.IsEqual(event.BurntAmount))
+	assert.True(distribution.TreasuryAmount.IsEqual(event.TreasuryAmount))
+	assert.True(distribution.ProposerAmount.IsEqual(event.ProposerAmount))
+	assert.True(proposerBefore.Balance.Plus(distribution.ProposerAmount).IsEqual(view.GetAccount(proposer.Address).Balance))
+
+	// The reserved fund is gone, so the proof is no longer valid
+	res = slashExec.VerifyProof(et.chainID, view, alice.Address, proofBytes)
+	assert.True(res.IsError())
+	_, res = slashExec.EstimateReward(et.chainID, view, alice.Address, proofBytes)
+	assert.True(res.IsError())
+}
+
+func TestSlashTxUnslashableAddress(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, bob, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	slashExec := et.executor.SlashTxExecutor()
+
+	slashExec.SetUnslashableAddresses([]common.Address{alice.Address})
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError(), res.Message)
+	assert.Equal(result.CodeSlashProtectedAddress, res.Code)
+
+	// Replacing the denylist lifts the protection
+	slashExec.SetUnslashableAddresses([]common.Address{bob.Address})
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+}
+
+func TestSlashTxStableAccountHash(t *testing.T) {
+	assert := assert.New(t)
+
+	slash := func() (slashedAccount, proposerAccount *types.Account) {
+		et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+		view := et.state().Delivered()
+
+		slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+		res := et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, view, slashTx)
+		assert.True(res.IsOK(), res.Message)
+		_, res = et.executor.getTxExecutor(slashTx).process(et.chainID, view, slashTx)
+		assert.True(res.IsOK(), res.Message)
+
+		return view.GetAccount(alice.Address), view.GetAccount(proposer.Address)
+	}
+
+	// The same slash from equal starting states yields equal account hashes
+	slashedAccount1, proposerAccount1 := slash()
+	slashedAccount2, proposerAccount2 := slash()
+	assert.Equal(slashedAccount1.Hash(), slashedAccount2.Hash())
+	assert.Equal(proposerAccount1.Hash(), proposerAccount2.Hash())
+
+	// The hash does not depend on how the reserved fund was removed
+	assert.Equal(0, len(slashedAccount1.ReservedFunds))
+	slashedAccount1.ReservedFunds = nil
+	assert.Equal(slashedAccount1.Hash(), slashedAccount2.Hash())
+}
+
+func TestSlashTxResourceCaps(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, bob, _, _, _, _ := setupForServicePayment(assert)
+	proposer := et.accProposer
+	et.acc2State(proposer)
+
+	// Alice reserves a second fund, with the spending on rid002 capped below the total
+	txFee := getMinimumTxFee()
+	reserveFundTx := createReserveFundTx(et.chainID, &alice, 1000*txFee, 1001*txFee, 2, []string{"rid002", "rid003"})
+	reserveFundTx.ResourceCaps = []types.ResourceCap{
+		{ResourceID: "rid002", Cap: types.NewCoins(0, 300*txFee)},
+	}
+	reserveFundTx.Source.Signature = alice.Sign(reserveFundTx.SignBytes(et.chainID))
+	res := et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(reserveFundTx).process(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+	et.state().Commit()
+
+	view := et.state().Delivered()
+	reservedFund := view.GetAccount(alice.Address).ReservedFunds[1]
+	assert.Equal(1, len(reservedFund.ResourceCaps))
+
+	createProof := func(payments ...*types.ServicePaymentTx) common.Bytes {
+		overspendingProof := &types.OverspendingProof{ReserveSequence: 2}
+		for _, payment := range payments {
+			overspendingProof.ServicePayments = append(overspendingProof.ServicePayments, *payment)
+		}
+		proof, err := types.ToBytes(overspendingProof)
+		assert.Nil(err)
+		return proof
+	}
+	slashExec := et.executor.SlashTxExecutor()
+
+	// The total is within the reserved fund, and so is the spending on the uncapped resource
+	uncappedPayment := createServicePaymentTx(et.chainID, &alice, &bob, 400*txFee, 1, 1, 1, 2, "rid003")
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 2, createProof(uncappedPayment))
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError(), res.Message)
+
+	// The total is within the reserved fund, but the spending on the capped resource is not
+	cappedPayment := createServicePaymentTx(et.chainID, &alice, &bob, 400*txFee, 1, 1, 2, 2, "rid002")
+	slashTx = createSlashTx(et.chainID, &proposer, 1, alice.Address, 2, createProof(uncappedPayment, cappedPayment))
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	proposerInitBalance := view.GetAccount(proposer.Address).Balance
+	_, res = slashExec.process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	expectedSlashedAmount := reservedFund.Collateral.Plus(reservedFund.InitialFund)
+	assert.True(proposerInitBalance.Plus(expectedSlashedAmount).IsEqual(view.GetAccount(proposer.Address).Balance))
+	assert.Equal(1, len(view.GetAccount(alice.Address).ReservedFunds))
+}
+
+func TestReserveFundTxResourceCaps(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, _, _, _, _ := setupForServicePayment(assert)
+	view := et.state().Delivered()
+
+	txFee := getMinimumTxFee()
+	checkResourceCaps := func(resourceCaps ...types.ResourceCap) result.Result {
+		reserveFundTx := createReserveFundTx(et.chainID, &alice, 1000*txFee, 1001*txFee, 2, []string{"rid002"})
+		reserveFundTx.ResourceCaps = resourceCaps
+		reserveFundTx.Source.Signature = alice.Sign(reserveFundTx.SignBytes(et.chainID))
+		return et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, view, reserveFundTx)
+	}
+
+	res := checkResourceCaps(types.ResourceCap{ResourceID: "rid002", Cap: types.NewCoins(0, 300*txFee)})
+	assert.True(res.IsOK(), res.Message)
+
+	// The capped resource is not reserved for
+	res = checkResourceCaps(types.ResourceCap{ResourceID: "rid003", Cap: types.NewCoins(0, 300*txFee)})
+	assert.Equal(result.CodeReserveFundCheckFailed, res.Code, res.Message)
+
+	// The resource is capped twice
+	res = checkResourceCaps(
+		types.ResourceCap{ResourceID: "rid002", Cap: types.NewCoins(0, 300*txFee)},
+		types.ResourceCap{ResourceID: "rid002", Cap: types.NewCoins(0, 400*txFee)})
+	assert.Equal(result.CodeReserveFundCheckFailed, res.Code, res.Message)
+
+	res = checkResourceCaps(types.ResourceCap{ResourceID: "rid002", Cap: types.NewCoins(0, -1)})
+	assert.Equal(result.CodeReserveFundCheckFailed, res.Code, res.Message)
+}
+
+func TestSlashTxServiceType(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, bob, _, _, _, _ := setupForServicePayment(assert)
+	proposer := et.accProposer
+	et.acc2State(proposer)
+
+	// Alice reserves a second fund, tagged with the service type
+	txFee := getMinimumTxFee()
+	reserveFundTx := createReserveFundTx(et.chainID, &alice, 1000*txFee, 1001*txFee, 2, []string{"rid002"})
+	reserveFundTx.ServiceType = "video"
+	reserveFundTx.Source.Signature = alice.Sign(reserveFundTx.SignBytes(et.chainID))
+	res := et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(reserveFundTx).process(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+	et.state().Commit()
+
+	view := et.state().Delivered()
+	aliceAccount := view.GetAccount(alice.Address)
+	assert.Equal("", aliceAccount.ReservedFunds[0].ServiceType)
+	assert.Equal("video", aliceAccount.ReservedFunds[1].ServiceType)
+
+	proof, err := types.ToBytes(&types.OverspendingProof{
+		ReserveSequence: 2,
+		ServicePayments: []types.ServicePaymentTx{
+			*createServicePaymentTx(et.chainID, &alice, &bob, 600*txFee, 1, 1, 1, 2, "rid002"),
+			*createServicePaymentTx(et.chainID, &alice, &bob, 600*txFee, 1, 1, 2, 2, "rid002"),
+		},
+	})
+	assert.Nil(err)
+	createTaggedSlashTx := func(reserveSeq uint64, serviceType string) *types.SlashTx {
+		slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, reserveSeq, proof)
+		slashTx.ServiceType = serviceType
+		slashTx.Proposer.Signature = proposer.Sign(slashTx.SignBytes(et.chainID))
+		return slashTx
+	}
+	slashExec := et.executor.SlashTxExecutor()
+
+	// Unrestricted slashes, and the ones restricted to the tag of the fund, go through
+	res = slashExec.sanityCheck(et.chainID, view, createTaggedSlashTx(2, ""))
+	assert.True(res.IsOK(), res.Message)
+	res = slashExec.sanityCheck(et.chainID, view, createTaggedSlashTx(2, "video"))
+	assert.True(res.IsOK(), res.Message)
+
+	// The slashes restricted to another tag are rejected, and so are the ones against an untagged fund
+	res = slashExec.sanityCheck(et.chainID, view, createTaggedSlashTx(2, "storage"))
+	assert.Equal(result.CodeSlashServiceTypeMismatch, res.Code, res.Message)
+	res = slashExec.sanityCheck(et.chainID, view, createTaggedSlashTx(1, "video"))
+	assert.Equal(result.CodeSlashServiceTypeMismatch, res.Code, res.Message)
+
+	_, res = slashExec.process(et.chainID, view, createTaggedSlashTx(2, "video"))
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(1, len(view.GetAccount(alice.Address).ReservedFunds))
+
+	// An oversized tag is rejected when reserving the fund
+	reserveFundTx = createReserveFundTx(et.chainID, &alice, 1000*txFee, 1001*txFee, 3, []string{"rid003"})
+	reserveFundTx.ServiceType = strings.Repeat("v", types.MaxServiceTypeLength+1)
+	reserveFundTx.Source.Signature = alice.Sign(reserveFundTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, view, reserveFundTx)
+	assert.Equal(result.CodeReserveFundCheckFailed, res.Code, res.Message)
+}
+
+func TestBatchSlashTx(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, bob, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	validEntry := types.SlashEntry{SlashedAddress: alice.Address, ReserveSequence: 1, SlashProof: slashIntent.Proof}
+	malformedEntry := types.SlashEntry{SlashedAddress: alice.Address, ReserveSequence: 1, SlashProof: []byte("malformed proof")}
+	unknownEntry := types.SlashEntry{SlashedAddress: bob.Address, ReserveSequence: 1, SlashProof: slashIntent.Proof}
+
+	// Batches without entries, without any valid entry, or not signed by the proposer are rejected
+	batchSlashExec := et.executor.getTxExecutor(&types.BatchSlashTx{})
+	res := batchSlashExec.sanityCheck(et.chainID, view, createBatchSlashTx(et.chainID, &proposer, 1))
+	assert.True(res.IsError(), res.Message)
+	res = batchSlashExec.sanityCheck(et.chainID, view, createBatchSlashTx(et.chainID, &proposer, 1, malformedEntry, unknownEntry))
+	assert.True(res.IsError(), res.Message)
+	forgedTx := createBatchSlashTx(et.chainID, &proposer, 1, validEntry)
+	forgedTx.Proposer.Signature = alice.Sign(forgedTx.SignBytes(et.chainID))
+	res = batchSlashExec.sanityCheck(et.chainID, view, forgedTx)
+	assert.True(res.IsError(), res.Message)
+
+	// The entries succeed or fail independently
+	batchSlashTx := createBatchSlashTx(et.chainID, &proposer, 1, malformedEntry, validEntry, unknownEntry, validEntry)
+	res = batchSlashExec.sanityCheck(et.chainID, view, batchSlashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	proposerInitBalance := view.GetAccount(proposer.Address).Balance
+	reservedFund := view.GetAccount(alice.Address).ReservedFunds[0]
+	_, res = batchSlashExec.process(et.chainID, view, batchSlashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(1, res.Info["num_slashed"])
+
+	entryResults := res.Info["entries"].([]SlashEntryResult)
+	assert.Equal(4, len(entryResults))
+	assert.NotEqual(result.CodeOK, entryResults[0].Code)
+	assert.Equal(result.CodeOK, entryResults[1].Code)
+	assert.NotEqual(result.CodeOK, entryResults[2].Code)
+	assert.NotEqual(result.CodeOK, entryResults[3].Code) // the reserved fund was slashed by the 2nd entry
+
+	expectedSlashedAmount := reservedFund.Collateral.Plus(reservedFund.InitialFund)
+	assert.True(proposerInitBalance.Plus(expectedSlashedAmount).IsEqual(view.GetAccount(proposer.Address).Balance))
+	assert.Equal(0, len(view.GetAccount(alice.Address).ReservedFunds))
+}
+
+func TestMultiFundSlashTx(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	// Alice overspends a second reserved fund as well
+	acc := view.GetAccount(alice.Address)
+	secondFund := acc.ReservedFunds[0]
+	secondFund.ReserveSequence = 2
+	secondFund.UsedFund = types.NewCoins(0, 0)
+	secondFund.TransferRecords = []types.TransferRecord{}
+	acc.ReservedFunds = append(acc.ReservedFunds, secondFund)
+	view.SetAccount(alice.Address, acc)
+	payment := createServicePaymentTx(et.chainID, &alice, &bob, 8000*getMinimumTxFee(), 1, 1, 1, 2, resourceID)
+	secondProof, err := types.ToBytes(&types.OverspendingProof{
+		ReserveSequence: 2,
+		ServicePayments: []types.ServicePaymentTx{*payment},
+	})
+	assert.Nil(err)
+
+	firstSlash := types.SlashedFund{ReserveSequence: 1, SlashProof: slashIntent.Proof}
+	secondSlash := types.SlashedFund{ReserveSequence: 2, SlashProof: secondProof}
+	unknownSlash := types.SlashedFund{ReserveSequence: 3, SlashProof: secondProof}
+	malformedSlash := types.SlashedFund{ReserveSequence: 2, SlashProof: []byte("malformed proof")}
+
+	// Slashes without reserved funds, slashing a reserved fund twice, or with any invalid reserved fund are rejected
+	multiFundSlashExec := et.executor.getTxExecutor(&types.MultiFundSlashTx{})
+	res := multiFundSlashExec.sanityCheck(et.chainID, view, createMultiFundSlashTx(et.chainID, &proposer, 1, alice.Address))
+	assert.True(res.IsError(), res.Message)
+	res = multiFundSlashExec.sanityCheck(et.chainID, view, createMultiFundSlashTx(et.chainID, &proposer, 1, alice.Address, firstSlash, firstSlash))
+	assert.Equal(result.CodeSlashDuplicateReserveSequence, res.Code, res.Message)
+	res = multiFundSlashExec.sanityCheck(et.chainID, view, createMultiFundSlashTx(et.chainID, &proposer, 1, alice.Address, firstSlash, unknownSlash))
+	assert.True(res.IsError(), res.Message)
+	res = multiFundSlashExec.sanityCheck(et.chainID, view, createMultiFundSlashTx(et.chainID, &proposer, 1, alice.Address, malformedSlash, firstSlash))
+	assert.True(res.IsError(), res.Message)
+
+	// A failure reverts the slashes of the earlier reserved funds
+	proposerInitBalance := view.GetAccount(proposer.Address).Balance
+	_, res = multiFundSlashExec.process(et.chainID, view, createMultiFundSlashTx(et.chainID, &proposer, 1, alice.Address, firstSlash, unknownSlash))
+	assert.True(res.IsError(), res.Message)
+	assert.Equal(2, len(view.GetAccount(alice.Address).ReservedFunds))
+	assert.True(proposerInitBalance.IsEqual(view.GetAccount(proposer.Address).Balance))
+	assert.Equal(0, len(view.GetEvents()))
+
+	// All the reserved funds are slashed at once
+	expectedSlashedAmount := types.NewCoins(0, 0)
+	for _, reservedFund := range view.GetAccount(alice.Address).ReservedFunds {
+		expectedSlashedAmount = expectedSlashedAmount.Plus(reservedFund.Collateral).Plus(reservedFund.InitialFund)
+	}
+	multiFundSlashTx := createMultiFundSlashTx(et.chainID, &proposer, 1, alice.Address, firstSlash, secondSlash)
+	res = multiFundSlashExec.sanityCheck(et.chainID, view, multiFundSlashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = multiFundSlashExec.process(et.chainID, view, multiFundSlashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.True(expectedSlashedAmount.IsEqual(res.Info["slashed_amount"].(types.Coins)))
+	assert.True(proposerInitBalance.Plus(expectedSlashedAmount).IsEqual(view.GetAccount(proposer.Address).Balance))
+	assert.Equal(0, len(view.GetAccount(alice.Address).ReservedFunds))
+	assert.Equal(2, len(view.GetEvents()))
+}
+
+func TestSlashTxBatchSanityCheck(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+	slashExec := et.executor.SlashTxExecutor()
+
+	// Alice overspends a second reserved fund as well
+	acc := view.GetAccount(alice.Address)
+	secondFund := acc.ReservedFunds[0]
+	secondFund.ReserveSequence = 2
+	secondFund.UsedFund = types.NewCoins(0, 0)
+	secondFund.TransferRecords = []types.TransferRecord{}
+	acc.ReservedFunds = append(acc.ReservedFunds, secondFund)
+	view.SetAccount(alice.Address, acc)
+	payment := createServicePaymentTx(et.chainID, &alice, &bob, 8000*getMinimumTxFee(), 1, 1, 1, 2, resourceID)
+	secondProof, err := types.ToBytes(&types.OverspendingProof{
+		ReserveSequence: 2,
+		ServicePayments: []types.ServicePaymentTx{*payment},
+	})
+	assert.Nil(err)
+
+	firstSlashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	secondSlashTx := createSlashTx(et.chainID, &proposer, 2, alice.Address, 2, secondProof)
+	accountHash := view.GetAccount(alice.Address).Hash()
+
+	// A clean batch
+	results := slashExec.BatchSanityCheck(et.chainID, view, []types.Tx{firstSlashTx, secondSlashTx})
+	assert.Equal(2, len(results))
+	for _, res := range results {
+		assert.True(res.IsOK(), res.Message)
+	}
+
+	// A batch with an invalid member, the other members are not affected
+	malformedSlashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 2, []byte("malformed proof"))
+	results = slashExec.BatchSanityCheck(et.chainID, view, []types.Tx{firstSlashTx, malformedSlashTx, &types.SendTx{}, secondSlashTx})
+	assert.Equal(4, len(results))
+	assert.True(results[0].IsOK(), results[0].Message)
+	assert.True(results[1].IsError(), results[1].Message)
+	assert.True(results[2].IsError(), results[2].Message)
+	assert.True(results[3].IsOK(), results[3].Message)
+
+	// A batch with SlashTxs of the same reserved fund, the later one conflicts with the earlier one. The
+	// invalid member does not claim the reserved fund
+	conflictingSlashTx := createSlashTx(et.chainID, &proposer, 3, alice.Address, 1, slashIntent.Proof)
+	results = slashExec.BatchSanityCheck(et.chainID, view, []types.Tx{malformedSlashTx, secondSlashTx, firstSlashTx, conflictingSlashTx})
+	assert.True(results[0].IsError(), results[0].Message)
+	assert.True(results[1].IsOK(), results[1].Message)
+	assert.True(results[2].IsOK(), results[2].Message)
+	assert.Equal(result.CodeSlashDuplicateReserveSequence, results[3].Code, results[3].Message)
+	assert.Contains(results[3].Message, "transaction 2 of the batch")
+
+	// The slash proofs of the batch share the verification budget of the block
+	slashExec.SetMaxProofVerificationsPerBlock(1)
+	results = slashExec.BatchSanityCheck(et.chainID, view, []types.Tx{firstSlashTx, secondSlashTx})
+	assert.True(results[0].IsOK(), results[0].Message)
+	assert.Equal(result.CodeSlashVerificationBudgetExceeded, results[1].Code, results[1].Message)
+
+	// Nothing is slashed
+	assert.Equal(accountHash, view.GetAccount(alice.Address).Hash())
+	assert.Equal(0, len(view.GetEvents()))
+}
+
+func TestSimulateSlashTx(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, bob, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+	stateRoot := view.Hash()
+	proposerBalance := view.GetAccount(proposer.Address).Balance
+
+	// An invalid slash is predicted to fail
+	invalidSlashTx := createSlashTx(et.chainID, &proposer, 1, bob.Address, 1, slashIntent.Proof)
+	simResult, err := et.executor.SimulateTx(view, invalidSlashTx)
+	assert.Nil(err)
+	assert.True(simResult.Result.IsError())
+	assert.Equal(invalidSlashTx.ID(et.chainID), simResult.TxHash)
+	assert.Equal(0, len(simResult.BalanceDeltas))
+	assert.Equal(stateRoot, view.Hash())
+
+	// A valid slash is predicted to succeed, without being committed
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	simResult, err = et.executor.SimulateTx(view, slashTx)
+	assert.Nil(err)
+	assert.True(simResult.Result.IsOK(), simResult.Result.Message)
+	assert.Equal(slashTx.ID(et.chainID), simResult.TxHash)
+	assert.Equal(stateRoot, view.Hash())
+	assert.Equal(1, len(view.GetAccount(alice.Address).ReservedFunds))
+	assert.True(proposerBalance.IsEqual(view.GetAccount(proposer.Address).Balance))
+	assert.Equal(0, len(view.GetEvents()))
+
+	// The prediction matches the actual execution
+	_, res := et.executor.ExecuteTx(slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(1, len(simResult.BalanceDeltas))
+	assert.Equal(proposer.Address, simResult.BalanceDeltas[0].Address)
+	assert.True(proposerBalance.Plus(simResult.BalanceDeltas[0].Delta).IsEqual(view.GetAccount(proposer.Address).Balance))
+}
+
+type testLogHook struct {
+	entries []*log.Entry
+}
+
+func (hook *testLogHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (hook *testLogHook) Fire(entry *log.Entry) error {
+	hook.entries = append(hook.entries, entry)
+	return nil
+}
+
+func TestSlashTxLogOverspendingMargin(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	hook := &testLogHook{}
+	standardLogger := log.StandardLogger()
+	level := log.GetLevel()
+	hooks := make(log.LevelHooks)
+	for l, levelHooks := range standardLogger.Hooks {
+		hooks[l] = levelHooks
+	}
+	log.SetLevel(log.DebugLevel)
+	log.AddHook(hook)
+	defer func() {
+		log.SetLevel(level)
+		standardLogger.Hooks = hooks
+	}()
+
+	findMarginEntries := func() []*log.Entry {
+		marginEntries := []*log.Entry{}
+		for _, entry := range hook.entries {
+			if _, ok := entry.Data["fundIntendedToSpend"]; ok {
+				marginEntries = append(marginEntries, entry)
+			}
+		}
+		return marginEntries
+	}
+
+	slashExec := et.executor.SlashTxExecutor()
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+
+	// Disabled by default
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(0, len(findMarginEntries()))
+
+	slashExec.SetLogOverspendingMargin(true)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	marginEntries := findMarginEntries()
+	assert.Equal(1, len(marginEntries))
+	proof, err := DecodeSlashProof(slashTx)
+	assert.Nil(err)
+	reservedFund := view.GetAccount(alice.Address).ReservedFunds[0]
+	assert.Equal(log.DebugLevel, marginEntries[0].Level)
+	assert.Equal(reservedFund.FundIntendedToSpend(proof.ServicePayments).String(), marginEntries[0].Data["fundIntendedToSpend"])
+	assert.Equal(reservedFund.InitialFund.String(), marginEntries[0].Data["initialFund"])
+}
+
+func TestSlashTxRemovedReservedFund(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	reservedFund := view.GetAccount(alice.Address).ReservedFunds[0]
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	res := et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(slashTx).process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// The snapshot holds the reserved fund as it was right before the slash
+	removedReservedFund := RemovedReservedFund(res)
+	if assert.NotNil(removedReservedFund) {
+		assert.Equal(uint64(1), removedReservedFund.ReserveSequence)
+		assert.True(reservedFund.Collateral.IsEqual(removedReservedFund.Collateral))
+		assert.True(reservedFund.InitialFund.IsEqual(removedReservedFund.InitialFund))
+		assert.True(reservedFund.UsedFund.IsEqual(removedReservedFund.UsedFund))
+		assert.Equal(reservedFund.ResourceIDs, removedReservedFund.ResourceIDs)
+		assert.Equal(reservedFund.EndBlockHeight, removedReservedFund.EndBlockHeight)
+	}
+	assert.Equal(0, len(view.GetAccount(alice.Address).ReservedFunds))
+	assert.Nil(RemovedReservedFund(result.OK))
+}
+
+func TestSlashTxMetrics(t *testing.T) {
+	assert := assert.New(t)
+	txFee := getMinimumTxFee()
+	et, resourceID, alice, bob, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+	slashExec := et.executor.SlashTxExecutor()
+	counters := NewSlashCounters()
+	slashExec.SetSlashMetrics(counters)
+
+	payment := createServicePaymentTx(et.chainID, &alice, &bob, 100*txFee, 1, 1, 1, 1, resourceID)
+	notOverspentProof, err := types.ToBytes(&types.OverspendingProof{
+		ReserveSequence: 1,
+		ServicePayments: []types.ServicePaymentTx{*payment},
+	})
+	assert.Nil(err)
+	badSignatureTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	badSignatureTx.Proposer.Signature = alice.Sign(badSignatureTx.SignBytes(et.chainID))
+
+	rejectedTxs := map[SlashRejectionReason]*types.SlashTx{
+		SlashRejectionBadSignature:         badSignatureTx,
+		SlashRejectionFundNotFound:         createSlashTx(et.chainID, &proposer, 1, alice.Address, 5, slashIntent.Proof),
+		SlashRejectionInvalidProof:         createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, notOverspentProof),
+		SlashRejectionNonValidatorProposer: createSlashTx(et.chainID, &bob, 1, alice.Address, 1, slashIntent.Proof),
+	}
+	for reason, slashTx := range rejectedTxs {
+		res := slashExec.sanityCheck(et.chainID, view, slashTx)
+		assert.True(res.IsError(), res.Message)
+		assert.Equal(reason, SlashRejectionReasonOf(res), res.Message)
+	}
+	res := slashExec.sanityCheck(et.chainID, view, createSlashTx(et.chainID, &proposer, 1, bob.Address, 1, slashIntent.Proof))
+	assert.Equal(SlashRejectionFundNotFound, SlashRejectionReasonOf(res), res.Message)
+
+	snapshot := counters.Snapshot()
+	assert.Equal(int64(5), snapshot.Attempts)
+	assert.Equal(int64(0), snapshot.Successes)
+	assert.True(snapshot.SlashedAmount.IsZero())
+	assert.Equal(map[SlashRejectionReason]int64{
+		SlashRejectionBadSignature:         1,
+		SlashRejectionFundNotFound:         2,
+		SlashRejectionInvalidProof:         1,
+		SlashRejectionNonValidatorProposer: 1,
+	}, snapshot.Rejections)
+
+	// A valid slash, the slashed amount adds up
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	view.SetSlashPause(alice.Address, view.Height()+10)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeSlashPausedAddress, res.Code, res.Message)
+	view.DeleteSlashPause(alice.Address)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = slashExec.process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	snapshot = counters.Snapshot()
+	assert.Equal(int64(7), snapshot.Attempts)
+	assert.Equal(int64(1), snapshot.Successes)
+	assert.True(res.Info["slashed_amount"].(types.Coins).IsEqual(snapshot.SlashedAmount))
+	assert.Equal(int64(1), snapshot.Rejections[SlashRejectionOther])
+	assert.Equal(int64(2), snapshot.Rejections[SlashRejectionFundNotFound])
+
+	// The counters are exposed through a metrics registry
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+	registry := metrics.NewRegistry()
+	assert.Nil(counters.Register("ledger/slash/", registry))
+	assert.Equal(int64(7), registry.Get("ledger/slash/attempts").(metrics.Gauge).Value())
+	assert.Equal(int64(1), registry.Get("ledger/slash/successes").(metrics.Gauge).Value())
+	assert.Equal(int64(2), registry.Get("ledger/slash/rejections/fund_not_found").(metrics.Gauge).Value())
+	tfuelWei, _ := new(big.Float).SetInt(snapshot.SlashedAmount.TFuelWei).Float64()
+	assert.Equal(tfuelWei, registry.Get("ledger/slash/slashed/tfuelwei").(metrics.GaugeFloat64).Value())
+	assert.NotNil(counters.Register("ledger/slash/", registry))
+}
+
+func TestSlashTxLogger(t *testing.T) {
+	assert := assert.New(t)
+	txFee := getMinimumTxFee()
+	et, resourceID, alice, bob, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	hook := &testLogHook{}
+	testLogger := log.New()
+	testLogger.SetLevel(log.DebugLevel)
+	testLogger.AddHook(hook)
+	slashExec := et.executor.SlashTxExecutor()
+	slashExec.SetLogger(log.NewEntry(testLogger))
+	defer slashExec.SetLogger(nil)
+
+	findEntries := func(message string) []*log.Entry {
+		entries := []*log.Entry{}
+		for _, entry := range hook.entries {
+			if entry.Message == message {
+				entries = append(entries, entry)
+			}
+		}
+		return entries
+	}
+
+	// A proof that does not show the overspending is rejected, with the reason
+	payment := createServicePaymentTx(et.chainID, &alice, &bob, 100*txFee, 1, 1, 1, 1, resourceID)
+	proof, err := types.ToBytes(&types.OverspendingProof{
+		ReserveSequence: 1,
+		ServicePayments: []types.ServicePaymentTx{*payment},
+	})
+	assert.Nil(err)
+	rejectedTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, proof)
+	res := slashExec.sanityCheck(et.chainID, view, rejectedTx)
+	assert.Equal(result.CodeSlashNotOverspent, res.Code, res.Message)
+
+	rejectedEntries := findEntries("Rejected the SlashTx")
+	if assert.Equal(1, len(rejectedEntries)) {
+		entry := rejectedEntries[0]
+		assert.Equal(log.InfoLevel, entry.Level)
+		assert.Equal(rejectedTx.ID(et.chainID).Hex(), entry.Data["txHash"])
+		assert.Equal(alice.Address.Hex(), entry.Data["slashedAddress"])
+		assert.Equal(proposer.Address.Hex(), entry.Data["proposer"])
+		assert.Equal(result.CodeSlashNotOverspent, entry.Data["code"])
+		assert.Equal(res.Message, entry.Data["reason"])
+	}
+	assert.Equal(0, len(findEntries("Slashed the reserved fund")))
+
+	// The slash logs the transfers
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	txHash, res := slashExec.process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	slashedEntries := findEntries("Slashed the reserved fund")
+	if assert.Equal(1, len(slashedEntries)) {
+		entry := slashedEntries[0]
+		assert.Equal(log.InfoLevel, entry.Level)
+		assert.Equal(txHash.Hex(), entry.Data["txHash"])
+		assert.Equal(alice.Address.Hex(), entry.Data["slashedAddress"])
+		assert.Equal(proposer.Address.Hex(), entry.Data["proposer"])
+		assert.Equal(uint64(1), entry.Data["reserveSequence"])
+		assert.Equal(res.Info["slashed_amount"].(types.Coins).String(), entry.Data["slashedAmount"])
+		assert.Equal(res.Info["returned_amount"].(types.Coins).String(), entry.Data["returnedAmount"])
+		assert.Equal(res.Info["burnt_amount"].(types.Coins).String(), entry.Data["burntAmount"])
+		assert.Equal(res.Info["treasury_amount"].(types.Coins).String(), entry.Data["treasuryAmount"])
+	}
+	assert.Equal(1, len(findEntries("Rejected the SlashTx")))
+}
+
+func TestSlashTxPausedAddress(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	slashExec := et.executor.SlashTxExecutor()
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+
+	// Paused
+	view.SetSlashPause(alice.Address, view.Height()+1)
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeSlashPausedAddress, res.Code, res.Message)
+
+	// The pause expired at the current height
+	view.SetSlashPause(alice.Address, view.Height())
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// Unpaused
+	view.SetSlashPause(alice.Address, view.Height()+100)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeSlashPausedAddress, res.Code, res.Message)
+	view.DeleteSlashPause(alice.Address)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+}
+
+func TestSlashTxUncoveredResource(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, bob, _, _, _, _ := setupForServicePayment(assert)
+	proposer := et.accProposer
+	et.acc2State(proposer)
+
+	// Alice reserves a second fund that only covers rid002
+	txFee := getMinimumTxFee()
+	reserveFundTx := createReserveFundTx(et.chainID, &alice, 1000*txFee, 1001*txFee, 2, []string{"rid002"})
+	res := et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(reserveFundTx).process(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+	et.state().Commit()
+
+	view := et.state().Delivered()
+	createProof := func(payments ...*types.ServicePaymentTx) common.Bytes {
+		overspendingProof := &types.OverspendingProof{ReserveSequence: 2}
+		for _, payment := range payments {
+			overspendingProof.ServicePayments = append(overspendingProof.ServicePayments, *payment)
+		}
+		proof, err := types.ToBytes(overspendingProof)
+		assert.Nil(err)
+		return proof
+	}
+	slashExec := et.executor.SlashTxExecutor()
+
+	// The covered payments alone overspend the reserved fund
+	coveredPayment1 := createServicePaymentTx(et.chainID, &alice, &bob, 600*txFee, 1, 1, 1, 2, "rid002")
+	coveredPayment2 := createServicePaymentTx(et.chainID, &alice, &bob, 500*txFee, 1, 1, 2, 2, "rid002")
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 2, createProof(coveredPayment1, coveredPayment2))
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// A payment for an uncovered resource cannot pad the overspending
+	uncoveredPayment := createServicePaymentTx(et.chainID, &alice, &bob, 500*txFee, 1, 1, 2, 2, "rid001")
+	slashTx = createSlashTx(et.chainID, &proposer, 1, alice.Address, 2, createProof(coveredPayment1, uncoveredPayment))
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError(), res.Message)
+	assert.Contains(res.Message, "does not cover")
+}
+
+func TestSlashTxUnderspendAttestation(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, carol, _, _, _ := setupForServicePayment(assert)
+	proposer := et.accProposer
+	et.acc2State(proposer)
+	et.state().Commit()
+
+	view := et.state().Delivered()
+	txFee := getMinimumTxFee()
+	payment := createServicePaymentTx(et.chainID, &alice, &bob, 400*txFee, 1, 1, 1, 1, resourceID)
+	overspendingProof := &types.OverspendingProof{
+		ReserveSequence: 1,
+		ServicePayments: []types.ServicePaymentTx{*payment},
+	}
+	proof, err := types.ToBytes(overspendingProof)
+	assert.Nil(err)
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, proof)
+	slashExec := et.executor.SlashTxExecutor()
+
+	// The underspend proof is rejected by default
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeSlashNotOverspent, res.Code, res.Message)
+
+	// With the attestation enabled, the reserved fund cannot be attested while payments can still settle
+	slashExec.SetAttestUnderspend(true)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeSlashNotOverspent, res.Code, res.Message)
+	assert.Contains(res.Message, "cannot be attested")
+
+	// A payment settled after the attestation invalidates it
+	view.SetReservedFundValidation(alice.Address, 1, view.Height())
+	settledPayment := createServicePaymentTx(et.chainID, &alice, &carol, 100*txFee, 1, 1, 1, 1, resourceID)
+	res = et.executor.getTxExecutor(settledPayment).sanityCheck(et.chainID, view, settledPayment)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(settledPayment).process(et.chainID, view, settledPayment)
+	assert.True(res.IsOK(), res.Message)
+	_, validated := view.GetReservedFundValidation(alice.Address, 1)
+	assert.False(validated)
+
+	// Once the reserved fund expired, the underspend proof marks it validated. The fee is still charged and burnt
+	endBlockHeight := view.GetAccount(alice.Address).ReservedFunds[0].EndBlockHeight
+	et.fastforwardTo(endBlockHeight + 1)
+	view = et.state().Delivered()
+	slashFee := types.NewCoins(0, 100)
+	slashExec.SetSlashFee(slashFee)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	aliceBalance := view.GetAccount(alice.Address).Balance
+	proposerBalance := view.GetAccount(proposer.Address).Balance
+	_, res = slashExec.process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(true, res.Info["validated"])
+
+	validatedHeight, validated := view.GetReservedFundValidation(alice.Address, 1)
+	assert.True(validated)
+	assert.Equal(view.Height(), validatedHeight)
+	assert.Equal(1, len(view.GetAccount(alice.Address).ReservedFunds))
+	assert.True(aliceBalance.IsEqual(view.GetAccount(alice.Address).Balance))
+	assert.True(proposerBalance.Minus(slashFee).IsEqual(view.GetAccount(proposer.Address).Balance))
+	assert.True(slashFee.IsEqual(view.GetSlashBurntSupply()))
+
+	// The validated reserved fund is still frozen
+	releaseFundTx := &types.ReleaseFundTx{
+		Fee: types.NewCoins(0, txFee),
+		Source: types.TxInput{
+			Address:  alice.Address,
+			Sequence: 2,
+		},
+		ReserveSequence: 1,
+	}
+	releaseFundTx.Source.Signature = alice.Sign(releaseFundTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(releaseFundTx).sanityCheck(et.chainID, view, releaseFundTx)
+	assert.Equal(result.CodeReleaseFundCheckFailed, res.Code, res.Message)
+	assert.Contains(res.Message, "cannot be released until")
+
+	// It is released at the end of the freeze period, as any other reserved fund
+	et.fastforwardTo(endBlockHeight + types.ReservedFundFreezePeriodDuration)
+	view = et.state().Delivered()
+	aliceAcc := view.GetAccount(alice.Address)
+	aliceAcc.UpdateToHeight(view.Height())
+	assert.Equal(0, len(aliceAcc.ReservedFunds))
+}
+
+func TestSlashTxUnvalidatedFundRelease(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, _, _, _, _ := setupForServicePayment(assert)
+	view := et.state().Delivered()
+
+	// Without the attestation, the reserved fund cannot be released before it expires
+	releaseFundTx := &types.ReleaseFundTx{
+		Fee: types.NewCoins(0, getMinimumTxFee()),
+		Source: types.TxInput{
+			Address:  alice.Address,
+			Sequence: 2,
+		},
+		ReserveSequence: 1,
+	}
+	releaseFundTx.Source.Signature = alice.Sign(releaseFundTx.SignBytes(et.chainID))
+	res := et.executor.getTxExecutor(releaseFundTx).sanityCheck(et.chainID, view, releaseFundTx)
+	assert.Equal(result.CodeReleaseFundCheckFailed, res.Code, res.Message)
+}
+
+func TestSlashTxSlashedFundRelease(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	res := et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(slashTx).process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	balance := view.GetAccount(alice.Address).Balance
+
+	// The slashed fund cannot be released, neither before nor after the time it would have expired
+	releaseFundTx := &types.ReleaseFundTx{
+		Fee: types.NewCoins(0, getMinimumTxFee()),
+		Source: types.TxInput{
+			Address:  alice.Address,
+			Sequence: 2,
+		},
+		ReserveSequence: 1,
+	}
+	releaseFundTx.Source.Signature = alice.Sign(releaseFundTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(releaseFundTx).sanityCheck(et.chainID, view, releaseFundTx)
+	assert.Equal(result.CodeReleaseFundCheckFailed, res.Code, res.Message)
+	assert.Contains(res.Message, "has been slashed")
+
+	et.fastforwardBy(1e5)
+	view = et.state().Delivered()
+	res = et.executor.getTxExecutor(releaseFundTx).sanityCheck(et.chainID, view, releaseFundTx)
+	assert.Equal(result.CodeReleaseFundCheckFailed, res.Code, res.Message)
+	assert.True(balance.IsEqual(view.GetAccount(alice.Address).Balance))
+}
+
+func TestSlashTxEvent(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	slashExec := et.executor.SlashTxExecutor()
+	treasury := types.MakeAcc("Treasury").Address
+	slashExec.SetSlashSplit(SlashSplit{BurnWeight: 10, TreasuryWeight: 60, ProposerWeight: 30}, treasury)
+	assert.Equal(0, len(view.GetEvents()))
+
+	slashedBefore := view.GetAccount(alice.Address)
+	proposerBefore := view.GetAccount(proposer.Address)
+	reservedFund := slashedBefore.ReservedFunds[0]
+
+	// Rejected SlashTxs emit no event
+	badSlashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 2, slashIntent.Proof)
+	_, res := slashExec.process(et.chainID, view, badSlashTx)
+	assert.True(res.IsError())
+	assert.Equal(0, len(view.GetEvents()))
+
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	txHash, res := slashExec.process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	events := view.GetEvents()
+	assert.Equal(1, len(events))
+	event, ok := events[0].(*types.SlashEvent)
+	assert.True(ok)
+	assert.Equal(types.EventTypeSlash, event.EventType())
+	assert.Equal(txHash, event.TxHash)
+	assert.Equal(alice.Address, event.SlashedAddress)
+	assert.Equal(proposer.Address, event.ProposerAddress)
+	assert.Equal(uint64(1), event.ReserveSequence)
+	assert.Equal(types.SlashReasonOverspending, event.Reason)
+	assert.Equal(treasury, event.Treasury)
+	assert.False(event.RewardVested)
+
+	// The payload matches the actual balance changes
+	slashedAfter := view.GetAccount(alice.Address)
+	proposerAfter := view.GetAccount(proposer.Address)
+	assert.True(slashedBefore.Balance.Plus(event.ReturnedAmount).IsEqual(slashedAfter.Balance))
+	assert.True(proposerBefore.Balance.Plus(event.ProposerAmount).IsEqual(proposerAfter.Balance))
+	assert.True(event.TreasuryAmount.IsEqual(view.GetAccount(treasury).Balance))
+	assert.True(event.SlashedAmount.IsEqual(event.BurntAmount.Plus(event.TreasuryAmount).Plus(event.ProposerAmount)))
+	assert.False(event.BurntAmount.IsZero())
+
+	// And the reserved fund changes: the whole remaining fund and collateral are either seized or returned
+	assert.Equal(0, len(slashedAfter.ReservedFunds))
+	remaining := reservedFund.Collateral.Plus(reservedFund.InitialFund).Minus(reservedFund.UsedFund)
+	assert.True(remaining.IsEqual(event.SlashedAmount.Plus(event.ReturnedAmount)))
+
+	// The event is exported to the indexers in the standard encoding
+	eventBytes, err := types.EncodeEventJSON(event)
+	assert.Nil(err)
+	var exported struct {
+		Type  string                     `json:"type"`
+		Event map[string]json.RawMessage `json:"event"`
+	}
+	assert.Nil(json.Unmarshal(eventBytes, &exported))
+	assert.Equal("slash", exported.Type)
+	assert.Equal(12, len(exported.Event))
+	var exportedTxHash common.Hash
+	var exportedSlashedAddress common.Address
+	assert.Nil(json.Unmarshal(exported.Event["tx_hash"], &exportedTxHash))
+	assert.Nil(json.Unmarshal(exported.Event["slashed_address"], &exportedSlashedAddress))
+	assert.Equal(txHash, exportedTxHash)
+	assert.Equal(alice.Address, exportedSlashedAddress)
+	assert.Equal(`"1"`, string(exported.Event["reserve_sequence"]))
+	assert.Equal(`"overspending"`, string(exported.Event["reason"]))
+	assert.Equal(`false`, string(exported.Event["reward_vested"]))
+	decoded, err := types.DecodeEventJSON(eventBytes)
+	assert.Nil(err)
+	assert.Equal(event.SlashedAmount.String(), decoded.(*types.SlashEvent).SlashedAmount.String())
+
+	view.ClearEvents()
+	assert.Equal(0, len(view.GetEvents()))
+}
+
+func TestSlashTxProposerSlashingItself(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	// The proposer reserves a fund and overspends it
+	txFee := getMinimumTxFee()
+	proposer := et.accProposer
+	proposer.Balance = types.NewCoins(0, 10000*txFee)
+	et.acc2State(proposer)
+	bob := types.MakeAcc("User Bob")
+	bob.Balance = types.NewCoins(0, 3000*txFee)
+	et.acc2State(bob)
+	et.fastforwardTo(1e2)
+
+	resourceID := "rid001"
+	reserveFundTx := createReserveFundTx(et.chainID, &proposer, 1000*txFee, 1001*txFee, 1, []string{resourceID})
+	res := et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(reserveFundTx).process(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+	et.state().Commit()
+
+	servicePaymentTx := createServicePaymentTx(et.chainID, &proposer, &bob, 8000*txFee, 1, 1, 1, 1, resourceID)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.Message)
+	slashIntent := et.state().Delivered().GetSlashIntents()[0]
+	et.state().Commit()
+
+	view := et.state().Delivered()
+	accountBefore := view.GetAccount(proposer.Address)
+	reservedFund := accountBefore.ReservedFunds[0]
+	slashedAmount, returnedAmount := calcSlashedAmountForOverspending(&reservedFund, false, true)
+
+	// The proposer may not slash its own reserved fund
+	slashTx := createSlashTx(et.chainID, &proposer, 1, proposer.Address, 1, slashIntent.Proof)
+	res = et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError())
+	assert.Equal(result.CodeSlashSelfSlash, res.Code)
+	assert.False(res.IsRetryable())
+	assert.Equal(1, len(view.GetAccount(proposer.Address).ReservedFunds))
+
+	// The process still handles it, for the blocks committed before the rule, replayed without the sanity check
+	_, res = et.executor.getTxExecutor(slashTx).process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// The reward and the removal of the reserved fund are both reflected in the single write
+	accountAfter := view.GetAccount(proposer.Address)
+	assert.Equal(0, len(accountAfter.ReservedFunds))
+	expectedBalance := accountBefore.Balance.Plus(slashedAmount).Plus(returnedAmount)
+	assert.True(expectedBalance.IsEqual(accountAfter.Balance), accountAfter.Balance.String())
+}
+
+func TestSlashTxErrorConditions(t *testing.T) {
+	assert := assert.New(t)
+	txFee := getMinimumTxFee()
+
+	// Each scenario exercises the path producing the error code
+	scenarios := map[result.ErrorCode]func() result.Result{
+		result.CodeGenericError: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			slashTx.Proposer.Address = common.Address{}
+			slashTx.Proposer.Coins = types.Coins{TFuelWei: big.NewInt(-1)}
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashAccountNotFound: func() result.Result {
+			et, _, _, _, proposer, slashIntent := setupForSlash(assert)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, types.MakeAcc("User Nobody").Address, 1, slashIntent.Proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashReservedFundNotFound: func() result.Result {
+			et, _, _, bob, proposer, slashIntent := setupForSlash(assert)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, bob.Address, 1, slashIntent.Proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashNonValidatorProposer: func() result.Result {
+			et, _, alice, bob, _, slashIntent := setupForSlash(assert)
+			slashTx := createSlashTx(et.chainID, &bob, 1, alice.Address, 1, slashIntent.Proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashInvalidProposerSignature: func() result.Result {
+			et, _, alice, bob, proposer, slashIntent := setupForSlash(assert)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			slashTx.Proposer.Signature = bob.Sign(slashTx.SignBytes(et.chainID))
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashInvalidProof: func() result.Result {
+			et, _, alice, _, proposer, _ := setupForSlash(assert)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, []byte("malformed proof"))
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashNoEvidence: func() result.Result {
+			et, _, alice, _, proposer, _ := setupForSlash(assert)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, nil)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeInsufficientFund: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			et.executor.SlashTxExecutor().SetSlashBond(types.Coins{TFuelWei: new(big.Int).Mul(big.NewInt(txFee), big.NewInt(1e12))}, 10)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeInsufficientStake: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			et.executor.SlashTxExecutor().SetMinimumProposerStake(core.MinValidatorStakeDeposit)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashReservedFundTooOld: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			et.executor.SlashTxExecutor().SetMaxReservedFundAge(1)
+			et.fastforwardBy(10)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashDuplicateReserveSequence: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			view := et.state().Delivered()
+			acc := view.GetAccount(alice.Address)
+			acc.ReservedFunds = append(acc.ReservedFunds, acc.ReservedFunds[0])
+			view.SetAccount(alice.Address, acc)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, view, slashTx)
+		},
+		result.CodeSlashConflictedProposer: func() result.Result {
+			et, _, alice, bob, _, slashIntent := setupForSlash(assert)
+			valSet := core.NewValidatorSet()
+			valSet.AddValidator(core.NewValidator(bob.Address.String(), big.NewInt(100)))
+			slashExec := et.executor.SlashTxExecutor()
+			slashExec.SetValidatorSetProvider(&testValidatorSetProvider{valSet: valSet})
+			slashExec.SetRejectConflictedProposer(true)
+			slashTx := createSlashTx(et.chainID, &bob, 1, alice.Address, 1, slashIntent.Proof)
+			return slashExec.sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashVerificationAborted: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			return et.executor.SlashTxExecutor().SanityCheckWithContext(ctx, et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashSelfSlash: func() result.Result {
+			et, _, _, _, proposer, slashIntent := setupForSlash(assert)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, proposer.Address, 1, slashIntent.Proof)
+			return et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashWrongReserveSequence: func() result.Result {
+			et, resourceID, alice, bob, proposer, _ := setupForSlash(assert)
+			payment := createServicePaymentTx(et.chainID, &alice, &bob, 8000*txFee, 1, 1, 1, 2, resourceID)
+			proof, err := types.ToBytes(&types.OverspendingProof{
+				ReserveSequence: 1,
+				ServicePayments: []types.ServicePaymentTx{*payment},
+			})
+			assert.Nil(err)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashUnsupportedSignatureScheme: func() result.Result {
+			et, resourceID, alice, bob, proposer, _ := setupForSlash(assert)
+			payment := createServicePaymentTx(et.chainID, &alice, &bob, 8000*txFee, 1, 1, 1, 1, resourceID)
+			payment.Source.Signature, _ = crypto.SignatureFromBytes(make([]byte, 96))
+			proof, err := types.ToBytes(&types.OverspendingProof{
+				ReserveSequence: 1,
+				ServicePayments: []types.ServicePaymentTx{*payment},
+			})
+			assert.Nil(err)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashServiceTypeMismatch: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			slashTx.ServiceType = "video"
+			slashTx.Proposer.Signature = proposer.Sign(slashTx.SignBytes(et.chainID))
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashProofTimestampOutOfSkew: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			et.executor.SlashTxExecutor().SetMaxProofTimestampSkew(10)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashProtectedAddress: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			et.executor.SlashTxExecutor().SetUnslashableAddresses([]common.Address{alice.Address})
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashReservedFundTooNew: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			et.executor.SlashTxExecutor().SetSlashGracePeriod(100)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashInvalidSnapshot: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			view := et.state().Delivered()
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			slashTx.BindSnapshot(view.Height()+10, common.Hash{})
+			slashTx.Proposer.Signature = proposer.Sign(slashTx.SignBytes(et.chainID))
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, view, slashTx)
+		},
+		result.CodeSlashPausedAddress: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			view := et.state().Delivered()
+			view.SetSlashPause(alice.Address, view.Height()+10)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, view, slashTx)
+		},
+		result.CodeSlashNotOverspent: func() result.Result {
+			et, resourceID, alice, bob, proposer, _ := setupForSlash(assert)
+			payment := createServicePaymentTx(et.chainID, &alice, &bob, 100*txFee, 1, 1, 1, 1, resourceID)
+			proof, err := types.ToBytes(&types.OverspendingProof{
+				ReserveSequence: 1,
+				ServicePayments: []types.ServicePaymentTx{*payment},
+			})
+			assert.Nil(err)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashZeroCollateral: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			view := et.state().Delivered()
+			acc := view.GetAccount(alice.Address)
+			acc.ReservedFunds[0].Collateral = types.NewCoins(0, 0)
+			view.SetAccount(alice.Address, acc)
+			et.executor.SlashTxExecutor().SetRejectZeroCollateral(true)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, view, slashTx)
+		},
+		result.CodeSlashReserveTxHashMismatch: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			et.executor.SlashTxExecutor().SetRequireReserveTxHash(true)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashReasonMismatch: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			slashTx := createSlashTxWithReason(et.chainID, &proposer, 1, alice.Address, 1, types.SlashReasonChannelRevocation, slashIntent.Proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashAlreadySlashed: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			view := et.state().Delivered()
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			_, res := et.executor.SlashTxExecutor().process(et.chainID, view, slashTx)
+			assert.True(res.IsOK(), res.Message)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, view, slashTx)
+		},
+		result.CodeValidatorSetUnavailable: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			valMgr := &flakyValidatorManager{ValidatorManager: et.executor.valMgr, failures: 1}
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			return NewSlashTxExecutor(et.executor.consensus, valMgr).sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+		result.CodeSlashProofExpired: func() result.Result {
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			et.executor.SlashTxExecutor().SetSlashDisputeWindow(1)
+			reservedFund := et.state().Delivered().GetAccount(alice.Address).ReservedFunds[0]
+			et.fastforwardTo(reservedFund.MinimumReleaseBlockHeight() + 2)
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			return et.executor.SlashTxExecutor().sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+		},
+	}
+
+	declared := make(map[result.ErrorCode]bool)
+	for _, condition := range NewExecTest().executor.SlashTxExecutor().ErrorConditions() {
+		assert.False(declared[condition.Code], "error code %v is declared more than once", condition.Code)
+		assert.NotEmpty(condition.Description)
+		declared[condition.Code] = true
+	}
+
+	produced := make(map[result.ErrorCode]bool)
+	for code, scenario := range scenarios {
+		res := scenario()
+		assert.Equal(code, res.Code, res.Message)
+		produced[res.Code] = true
+	}
+	assert.Equal(declared, produced)
+}
+
+func TestSlashTxInvariants(t *testing.T) {
+	txFee := getMinimumTxFee()
+
+	scenarios := []struct {
+		name      string
+		configure func(slashExec *SlashTxExecutor)
+		burnt     func(slashedAmount types.Coins) types.Coins
+	}{
+		{
+			name: "default",
+		},
+		{
+			name: "reward vesting",
+			configure: func(slashExec *SlashTxExecutor) {
+				slashExec.SetRewardVestingDuration(100)
+			},
+		},
+		{
+			name: "slash bond",
+			configure: func(slashExec *SlashTxExecutor) {
+				slashExec.SetSlashBond(types.NewCoins(0, 10*txFee), 100)
+			},
+		},
+		{
+			name: "quantization with the remainder returned",
+			configure: func(slashExec *SlashTxExecutor) {
+				slashExec.SetSlashQuantization(types.NewCoins(0, 7*txFee+3), SlashRemainderReturned)
+			},
+		},
+		{
+			name: "quantization with the remainder burnt",
+			configure: func(slashExec *SlashTxExecutor) {
+				slashExec.SetSlashQuantization(types.NewCoins(0, 7*txFee+3), SlashRemainderBurnt)
+			},
+			burnt: func(slashedAmount types.Coins) types.Coins {
+				_, remainder := quantizeSlashedAmount(slashedAmount, types.NewCoins(0, 7*txFee+3))
+				return remainder
+			},
+		},
+		{
+			name: "reward vesting with a slash bond",
+			configure: func(slashExec *SlashTxExecutor) {
+				slashExec.SetRewardVestingDuration(100)
+				slashExec.SetSlashBond(types.NewCoins(0, 10*txFee), 100)
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			assert := assert.New(t)
+			et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+			view := et.state().Delivered()
+			if scenario.configure != nil {
+				scenario.configure(et.executor.SlashTxExecutor())
+			}
+
+			burnt := types.NewCoins(0, 0)
+			if scenario.burnt != nil {
+				reservedFund := view.GetAccount(alice.Address).ReservedFunds[0]
+				slashedAmount, _ := calcSlashedAmountForOverspending(&reservedFund, false, true)
+				burnt = scenario.burnt(slashedAmount)
+			}
+
+			slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+			executeSlashWithInvariants(assert, et, view, slashTx, burnt)
+		})
+	}
+}
+
+func TestSlashTxIDBeforeBroadcast(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	slashTx := &types.SlashTx{
+		Proposer:        types.TxInput{Address: proposer.Address},
+		SlashedAddress:  alice.Address,
+		ReserveSequence: 1,
+		SlashProof:      slashIntent.Proof,
+	}
+	id := slashTx.ID(et.chainID)
+	slashTx.Proposer.Signature = proposer.Sign(slashTx.SignBytes(et.chainID))
+
+	raw, err := types.TxToBytes(slashTx)
+	assert.Nil(err)
+	tx, err := types.TxFromBytes(raw)
+	assert.Nil(err)
+
+	res := et.executor.getTxExecutor(tx).sanityCheck(et.chainID, view, tx)
+	assert.True(res.IsOK(), res.Message)
+	txHash, res := et.executor.getTxExecutor(tx).process(et.chainID, view, tx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(id, txHash)
+}
+
+func TestSlashTxAccountSlashFlag(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	et.executor.SlashTxExecutor().SetSlashFlagPolicy(types.AccountSlashStatusUnderReview, 10)
+
+	view := et.state().Delivered()
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	res := et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(slashTx).process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	clearHeight := view.Height() + 10
+	flag := view.GetAccountSlashFlag(alice.Address)
+	assert.NotNil(flag)
+	assert.Equal(types.AccountSlashStatusUnderReview, flag.Status)
+	assert.Equal(clearHeight, flag.ClearBlockHeight)
+	assert.Nil(view.GetAccountSlashFlag(proposer.Address))
+	et.state().Commit()
+
+	// The flagged account cannot reserve new funds
+	txFee := getMinimumTxFee()
+	reserveFundTx := createReserveFundTx(et.chainID, &alice, 10*txFee, 11*txFee, 2, []string{"rid002"})
+	res = et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.Equal(result.CodeReserveFundCheckFailed, res.Code, res.Message)
+	assert.Contains(res.Message, "under_review")
+
+	// Until the flag is cleared
+	et.fastforwardTo(clearHeight)
+	res = et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+}
+
+func TestSlashTxNoAccountSlashFlag(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+
+	view := et.state().Delivered()
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	_, res := et.executor.getTxExecutor(slashTx).process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Nil(view.GetAccountSlashFlag(alice.Address))
+}
+
+func TestSlashIntentBoundToReserveTx(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, _, _, _, _ := setupForServicePayment(assert)
+	et.executor.SlashTxExecutor().SetRequireReserveTxHash(true)
+
+	txFee := getMinimumTxFee()
+	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &bob, 8000*txFee, 1, 1, 1, 1, resourceID)
+	_, res := et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// The slash intents are bound to the ReserveFundTx when the binding is required
+	slashIntents := et.state().Delivered().GetSlashIntents()
+	assert.Equal(1, len(slashIntents))
+	overspendingProof, reserveTxHash, err := decodeBoundOverspendingProof(slashIntents[0].Proof)
+	assert.Nil(err)
+	assert.NotNil(reserveTxHash)
+	recordedTxHash, _ := et.state().Delivered().GetReserveFundTxHash(alice.Address, 1)
+	assert.Equal(recordedTxHash, *reserveTxHash)
+	assert.Equal(uint64(1), overspendingProof.ReserveSequence)
+}
+
+func TestSlashDecisionReplay(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+	preSlashView, err := view.Copy()
+	assert.Nil(err)
+
+	slashExec := et.executor.SlashTxExecutor()
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = slashExec.process(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	decision, err := NewSlashDecision(slashTx, view.Height(), res)
+	assert.Nil(err)
+	reservedFund := preSlashView.GetAccount(alice.Address).ReservedFunds[0]
+	expectedSlashedAmount, expectedReturnedAmount := calcSlashedAmountForOverspending(&reservedFund, false, true)
+	assert.True(expectedSlashedAmount.IsEqual(decision.SlashedAmount))
+	assert.True(expectedReturnedAmount.IsEqual(decision.ReturnedAmount))
+
+	// The decision embedded in the block metadata reproduces the slash when replayed
+	data, err := decision.Encode()
+	assert.Nil(err)
+	embedded, err := types.DecodeSlashDecision(data)
+	assert.Nil(err)
+
+	replayView, err := preSlashView.Copy()
+	assert.Nil(err)
+	res = slashExec.ReplaySlashDecision(et.chainID, replayView, embedded)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(view.Hash(), replayView.Hash())
+
+	// A tampered decision does not
+	embedded.SlashedAmount = embedded.SlashedAmount.Plus(types.NewCoins(0, 1))
+	replayView, err = preSlashView.Copy()
+	assert.Nil(err)
+	res = slashExec.ReplaySlashDecision(et.chainID, replayView, embedded)
+	assert.True(res.IsError())
+	assert.Contains(res.Message, "does not match")
+
+	// The decision cannot be replayed against the state after the slash
+	embedded, err = types.DecodeSlashDecision(data)
+	assert.Nil(err)
+	res = slashExec.ReplaySlashDecision(et.chainID, view, embedded)
+	assert.True(res.IsError())
+
+	// Failed SlashTxs make no decisions
+	_, err = NewSlashDecision(slashTx, view.Height(), result.Error("failed"))
+	assert.NotNil(err)
+}
+
+func TestSlashTxMinimumProposerStake(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, bob, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	slashExec := et.executor.SlashTxExecutor()
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	minStake := new(big.Int).Mul(core.MinValidatorStakeDeposit, big.NewInt(2))
+	slashExec.SetMinimumProposerStake(minStake)
+
+	// No stake at all
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeInsufficientStake, res.Code, res.Message)
+
+	// Below the minimum stake
+	vcp := &core.ValidatorCandidatePool{}
+	assert.Nil(vcp.DepositStake(alice.Address, proposer.Address, core.MinValidatorStakeDeposit))
+	view.UpdateValidatorCandidatePool(vcp)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeInsufficientStake, res.Code, res.Message)
+
+	// Withdrawn stakes are not active
+	assert.Nil(vcp.DepositStake(bob.Address, proposer.Address, core.MinValidatorStakeDeposit))
+	assert.Nil(vcp.WithdrawStake(bob.Address, proposer.Address, view.Height()))
+	view.UpdateValidatorCandidatePool(vcp)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeInsufficientStake, res.Code, res.Message)
+
+	// At the minimum stake
+	vcp = &core.ValidatorCandidatePool{}
+	assert.Nil(vcp.DepositStake(alice.Address, proposer.Address, core.MinValidatorStakeDeposit))
+	assert.Nil(vcp.DepositStake(bob.Address, proposer.Address, core.MinValidatorStakeDeposit))
+	view.UpdateValidatorCandidatePool(vcp)
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// Disabled
+	slashExec.SetMinimumProposerStake(nil)
+	view.UpdateValidatorCandidatePool(&core.ValidatorCandidatePool{})
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+}
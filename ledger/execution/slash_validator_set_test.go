@@ -0,0 +1,279 @@
+package execution
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+type testValidatorSetProvider struct {
+	valSet *core.ValidatorSet
+}
+
+func (p *testValidatorSetProvider) GetValidatorSet(blockHeight uint64, parentBlockHash common.Hash) (*core.ValidatorSet, error) {
+	return p.valSet, nil
+}
+
+// rotatingValidatorSetProvider rotates the validator set at the given block height
+type rotatingValidatorSetProvider struct {
+	rotationHeight uint64
+	before         *core.ValidatorSet
+	after          *core.ValidatorSet
+}
+
+func (p *rotatingValidatorSetProvider) GetValidatorSet(blockHeight uint64, parentBlockHash common.Hash) (*core.ValidatorSet, error) {
+	if blockHeight < p.rotationHeight {
+		return p.before, nil
+	}
+	return p.after, nil
+}
+
+// finalizedConsensusEngine reports the given block as the last finalized block
+type finalizedConsensusEngine struct {
+	core.ConsensusEngine
+	lastFinalizedBlock *core.ExtendedBlock
+}
+
+func (e *finalizedConsensusEngine) GetLastFinalizedBlock() *core.ExtendedBlock {
+	return e.lastFinalizedBlock
+}
+
+func TestSlashTxValidatorSetRotation(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	carol := types.MakeAcc("User Carol")
+	et.acc2State(carol)
+
+	proposerOnly := core.NewValidatorSet()
+	proposerOnly.AddValidator(core.NewValidator(proposer.Address.String(), big.NewInt(100)))
+	carolOnly := core.NewValidatorSet()
+	carolOnly.AddValidator(core.NewValidator(carol.Address.String(), big.NewInt(100)))
+
+	// The proposer observed the overspending while being a validator, but is rotated out before the
+	// SlashTx is processed
+	rotationHeight := et.state().Height() + 5
+	slashExec := et.executor.SlashTxExecutor()
+	slashExec.SetValidatorSetProvider(&rotatingValidatorSetProvider{
+		rotationHeight: rotationHeight,
+		before:         proposerOnly,
+		after:          carolOnly,
+	})
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	res := slashExec.sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	et.fastforwardTo(rotationHeight)
+	res = slashExec.sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsError())
+	assert.Contains(res.Message, fmt.Sprintf("is not a validator at block height %v", rotationHeight))
+
+	// Whereas a validator rotated in can propose the slash
+	carolSlashTx := createSlashTx(et.chainID, &carol, 1, alice.Address, 1, slashIntent.Proof)
+	res = slashExec.sanityCheck(et.chainID, et.state().Delivered(), carolSlashTx)
+	assert.True(res.IsOK(), res.Message)
+}
+
+// childValidatorManager selects the validator set of a block by its parent
+type childValidatorManager struct {
+	core.ValidatorManager
+	nextValidatorSets map[common.Hash]*core.ValidatorSet
+}
+
+func (m *childValidatorManager) GetNextValidatorSet(blockHash common.Hash) *core.ValidatorSet {
+	return m.nextValidatorSets[blockHash]
+}
+
+func TestConsensusValidatorSetProviderHeight(t *testing.T) {
+	assert := assert.New(t)
+	et, _, _, _, proposer, _ := setupForSlash(assert)
+
+	consensus := &finalizedConsensusEngine{
+		ConsensusEngine:    et.executor.consensus,
+		lastFinalizedBlock: &core.ExtendedBlock{Block: &core.Block{BlockHeader: &core.BlockHeader{Height: 10}}},
+	}
+	provider := NewValidatorSetProvider(consensus, et.executor.valMgr)
+	viewAt := func(blockHeight uint64) *st.StoreView {
+		view := et.state().Delivered()
+		return st.NewStoreView(blockHeight, view.Hash(), view.GetDB())
+	}
+
+	// Without a parent block, the validator set of the last finalized block is in effect from its height on
+	for _, blockHeight := range []uint64{10, 11, 100} {
+		validatorAddresses, res := getValidatorAddresses(provider, viewAt(blockHeight))
+		assert.True(res.IsOK(), res.Message)
+		assert.True(isAValidator(proposer.Address, validatorAddresses, blockHeight).IsOK())
+	}
+
+	// The set effective before the last finalized block is no longer tracked, which is not retryable
+	_, res := getValidatorAddresses(provider, viewAt(9))
+	assert.True(res.IsError())
+	assert.False(res.IsRetryable())
+	assert.Contains(res.Message, "superseded")
+}
+
+func TestConsensusValidatorSetProviderParentBlock(t *testing.T) {
+	assert := assert.New(t)
+	et, _, _, bob, proposer, _ := setupForSlash(assert)
+
+	proposerOnly := core.NewValidatorSet()
+	proposerOnly.AddValidator(core.NewValidator(proposer.Address.String(), big.NewInt(100)))
+	bobOnly := core.NewValidatorSet()
+	bobOnly.AddValidator(core.NewValidator(bob.Address.String(), big.NewInt(100)))
+	parent1 := common.BytesToHash([]byte("parent1"))
+	parent2 := common.BytesToHash([]byte("parent2"))
+	valMgr := &childValidatorManager{
+		ValidatorManager:  et.executor.valMgr,
+		nextValidatorSets: map[common.Hash]*core.ValidatorSet{parent1: proposerOnly, parent2: bobOnly},
+	}
+
+	// The validator set only depends on the parent block, not on the last finalized block of the node
+	consensus := &finalizedConsensusEngine{
+		ConsensusEngine:    et.executor.consensus,
+		lastFinalizedBlock: &core.ExtendedBlock{Block: &core.Block{BlockHeader: &core.BlockHeader{Height: 1000}}},
+	}
+	provider := NewValidatorSetProvider(consensus, valMgr)
+	view := et.state().Delivered()
+	blockHeight := view.Height()
+
+	view.SetParentBlockHash(parent1)
+	validatorAddresses, res := getValidatorAddresses(provider, view)
+	assert.True(res.IsOK(), res.Message)
+	assert.True(isAValidator(proposer.Address, validatorAddresses, blockHeight).IsOK())
+	assert.True(isAValidator(bob.Address, validatorAddresses, blockHeight).IsError())
+
+	view.SetParentBlockHash(parent2)
+	validatorAddresses, res = getValidatorAddresses(provider, view)
+	assert.True(res.IsOK(), res.Message)
+	assert.True(isAValidator(proposer.Address, validatorAddresses, blockHeight).IsError())
+	assert.True(isAValidator(bob.Address, validatorAddresses, blockHeight).IsOK())
+
+	// The validator set of an unknown parent block may become available later
+	view.SetParentBlockHash(common.BytesToHash([]byte("unknown")))
+	_, res = getValidatorAddresses(provider, view)
+	assert.Equal(result.CodeValidatorSetUnavailable, res.Code, res.Message)
+}
+
+func TestSlashTxValidatorSetProvider(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, bob, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+	signBytes := slashTx.SignBytes(et.chainID)
+
+	// A standalone provider, without the consensus engine and validator manager
+	bobOnly := core.NewValidatorSet()
+	bobOnly.AddValidator(core.NewValidator(bob.Address.String(), big.NewInt(100)))
+	slashExec := NewSlashTxExecutor(nil, nil)
+	slashExec.SetValidatorSetProvider(&testValidatorSetProvider{valSet: bobOnly})
+	res := slashExec.checkProposer(view, slashTx.Proposer, signBytes)
+	assert.True(res.IsError(), res.Message)
+
+	withProposer := core.NewValidatorSet()
+	withProposer.AddValidator(core.NewValidator(proposer.Address.String(), big.NewInt(100)))
+	slashExec.SetValidatorSetProvider(&testValidatorSetProvider{valSet: withProposer})
+	res = slashExec.checkProposer(view, slashTx.Proposer, signBytes)
+	assert.True(res.IsOK(), res.Message)
+
+	// The provider set on the executor applies to all the transactions checking the proposer
+	provider := &testValidatorSetProvider{valSet: bobOnly}
+	et.executor.SetValidatorSetProvider(provider)
+	assert.Equal(provider, et.executor.coinbaseTxExec.valSetProvider)
+	res = et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError(), res.Message)
+}
+
+// flakyValidatorManager fails the given number of validator set lookups, the way the validator manager
+// does when it cannot retrieve the validator candidate pool
+type flakyValidatorManager struct {
+	core.ValidatorManager
+	failures int
+}
+
+func (m *flakyValidatorManager) GetValidatorSet(blockHash common.Hash) *core.ValidatorSet {
+	if m.failures > 0 {
+		m.failures--
+		panic("Failed to retrieve the validator candidate pool")
+	}
+	return m.ValidatorManager.GetValidatorSet(blockHash)
+}
+
+func TestSlashTxValidatorSetUnavailable(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, _, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+
+	sink := &testRejectedSlashSink{rejected: make(chan rejectedSlash, 1)}
+	valMgr := &flakyValidatorManager{ValidatorManager: et.executor.valMgr, failures: 1}
+	slashExec := NewSlashTxExecutor(et.executor.consensus, valMgr)
+	slashExec.SetRejectedSlashSink(sink)
+
+	// A transient failure is retryable, and is not recorded as a rejection
+	res := slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.Equal(result.CodeValidatorSetUnavailable, res.Code, res.Message)
+	assert.True(res.IsRetryable())
+	select {
+	case rejected := <-sink.rejected:
+		assert.Fail("Retryable slash recorded as rejected", rejected.reason.Message)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// The retry succeeds once the validator set is available again
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// A missing validator manager is a permanent failure
+	res = NewSlashTxExecutor(et.executor.consensus, nil).sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError())
+	assert.False(res.IsRetryable(), res.Message)
+
+	// Hard rejections are not retryable
+	res = slashExec.sanityCheck(et.chainID, view, createSlashTx(et.chainID, &alice, 2, alice.Address, 1, slashIntent.Proof))
+	assert.True(res.IsError())
+	assert.False(res.IsRetryable(), res.Message)
+}
+
+func TestSlashTxProposerValidatorCheck(t *testing.T) {
+	assert := assert.New(t)
+	et, _, alice, bob, proposer, slashIntent := setupForSlash(assert)
+	view := et.state().Delivered()
+
+	slashTx := createSlashTx(et.chainID, &proposer, 1, alice.Address, 1, slashIntent.Proof)
+
+	// The executor wired to the validator manager checks the proposer against the current validator set
+	assert.NotNil(et.executor.slashTxExec.valMgr)
+	res := et.executor.slashTxExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// A proposer removed from the validator set
+	bobOnly := core.NewValidatorSet()
+	bobOnly.AddValidator(core.NewValidator(bob.Address.String(), big.NewInt(100)))
+	slashExec := NewSlashTxExecutor(et.executor.consensus, et.executor.valMgr)
+	slashExec.SetValidatorSetProvider(&testValidatorSetProvider{valSet: bobOnly})
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError())
+	assert.Contains(res.Message, "is not a validator")
+
+	// Misconfigured executors reject the slashes instead of panicking or accepting them
+	for _, slashExec := range []*SlashTxExecutor{
+		NewSlashTxExecutor(et.executor.consensus, nil),
+		NewSlashTxExecutor(nil, et.executor.valMgr),
+	} {
+		res = slashExec.sanityCheck(et.chainID, view, slashTx)
+		assert.True(res.IsError())
+		assert.Contains(res.Message, "validator set is not available")
+	}
+	slashExec.SetValidatorSetProvider(&testValidatorSetProvider{})
+	res = slashExec.sanityCheck(et.chainID, view, slashTx)
+	assert.True(res.IsError())
+	assert.Contains(res.Message, "validator set is not available")
+}
@@ -0,0 +1,107 @@
+package execution
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// ------------------------------- Watchtower API -----------------------------------
+//
+// The functions below let the watchtowers build an overspending proof, verify it, and estimate the reward
+// of slashing the reserved fund with it, before broadcasting a SlashTx. They go through the same code as the
+// SlashTxExecutor, so that a proof they accept is accepted on-chain, with the same outcome, provided the
+// state and the SlashParams do not change in the meantime. The conditions concerning the SlashTx as a whole,
+// e.g. its proposer and the slashable window of the reserved fund, are not checked.
+
+// DecodeProof decodes an overspending proof in any of the encodings a SlashTx accepts, i.e. the native RLP
+// encoding, bound to the ReserveFundTx or not, and the canonical JSON encoding
+func DecodeProof(proofBytes []byte) (*types.OverspendingProof, error) {
+	return decodeOverspendingProof(proofBytes)
+}
+
+// BuildProof builds the overspending proof of the reserved fund of the account with the given service
+// payments, in the native encoding a SlashTx carries. The payments are put in the canonical order. An error
+// is returned if the account has no such reserved fund, or if the payments cannot make an overspending
+// proof at all (see types.NewOverspendingProof). Whether the reserved fund is actually overspent is told by
+// VerifyProof
+func BuildProof(account *types.Account, reserveSequence uint64, servicePayments []types.ServicePaymentTx) (common.Bytes, error) {
+	if account == nil {
+		return nil, errors.New("Account is nil")
+	}
+	reservedFund, _, res := findReservedFund(account, reserveSequence)
+	if res.IsError() {
+		return nil, errors.New(res.Message)
+	}
+
+	// The payments must at least exceed what remains of the reserved fund. The spending on a capped resource
+	// may be overspent within the remaining fund though, the per-resource caps are left to VerifyProof
+	limit := types.NewCoins(0, 0)
+	if len(reservedFund.ResourceCaps) == 0 {
+		limit = reservedFund.InitialFund.NoNil().Minus(reservedFund.UsedFund.NoNil())
+	}
+	proof, err := types.NewOverspendingProof(reserveSequence, servicePayments, limit)
+	if err != nil {
+		return nil, err
+	}
+	return types.ToBytes(proof)
+}
+
+// VerifyProof verifies the overspending proof against the slashed account as the sanity check of a SlashTx
+// carrying it would, i.e. with the current SlashParams. The result carries the same error code as the
+// sanity check would on failure
+func (exec *SlashTxExecutor) VerifyProof(chainID string, view *st.StoreView, slashedAddress common.Address, proofBytes []byte) result.Result {
+	overspendingProof, err := decodeOverspendingProof(proofBytes)
+	if err != nil {
+		return invalidSlashProofEncoding(err)
+	}
+
+	slashedAccount := view.GetAccount(slashedAddress)
+	if slashedAccount == nil {
+		return result.Error("Account %v does not exist", slashedAddress)
+	}
+
+	reserveSequence := overspendingProof.ReserveSequence
+	res := exec.checkReserveTxHash(view, slashedAddress, reserveSequence, proofBytes)
+	if res.IsError() {
+		return res
+	}
+	return exec.verifySlashProof(context.Background(), chainID, view, slashedAccount, reserveSequence, proofBytes)
+}
+
+// EstimateReward verifies the overspending proof with VerifyProof, and tells how executing a SlashTx
+// carrying it would slash the reserved fund, in particular the reward of the proposer. The reward may vest,
+// or be paid out at the end of the block, depending on the SlashParams. If the reward is shared among the
+// validators, the ProposerAmount is the whole reward to share. The fee and the bond of the SlashTx are not
+// taken into account
+func (exec *SlashTxExecutor) EstimateReward(chainID string, view *st.StoreView, slashedAddress common.Address, proofBytes []byte) (SlashDistribution, result.Result) {
+	res := exec.VerifyProof(chainID, view, slashedAddress, proofBytes)
+	if res.IsError() {
+		return SlashDistribution{}, res
+	}
+
+	overspendingProof, err := decodeOverspendingProof(proofBytes)
+	if err != nil {
+		return SlashDistribution{}, invalidSlashProofEncoding(err)
+	}
+	reservedFund, _, res := findReservedFund(view.GetAccount(slashedAddress), overspendingProof.ReserveSequence)
+	if res.IsError() {
+		return SlashDistribution{}, res
+	}
+
+	distribution, overspent, res := exec.calcSlashDistribution(slashedAddress, types.SlashReasonOverspending,
+		reservedFund, reservedFund, proofBytes)
+	if res.IsError() {
+		return SlashDistribution{}, res
+	}
+	if !overspent {
+		return SlashDistribution{}, result.Error("Reserved fund %v is not overspent, nothing to slash",
+			overspendingProof.ReserveSequence).WithErrorCode(result.CodeSlashNotOverspent)
+	}
+	return distribution, result.OK
+}
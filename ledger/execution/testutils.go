@@ -24,11 +24,12 @@ import (
 
 type TestConsensusEngine struct {
 	privKey *crypto.PrivateKey
+	tip     *core.ExtendedBlock
 }
 
 func (tce *TestConsensusEngine) ID() string                        { return tce.privKey.PublicKey().Address().Hex() }
 func (tce *TestConsensusEngine) PrivateKey() *crypto.PrivateKey    { return tce.privKey }
-func (tce *TestConsensusEngine) GetTip(bool) *core.ExtendedBlock   { return nil }
+func (tce *TestConsensusEngine) GetTip(bool) *core.ExtendedBlock   { return tce.tip }
 func (tce *TestConsensusEngine) GetEpoch() uint64                  { return 100 }
 func (tce *TestConsensusEngine) AddMessage(msg interface{})        {}
 func (tce *TestConsensusEngine) FinalizedBlocks() chan *core.Block { return nil }
@@ -39,7 +40,7 @@ func (tce *TestConsensusEngine) GetLastFinalizedBlock() *core.ExtendedBlock {
 
 func NewTestConsensusEngine(seed string) *TestConsensusEngine {
 	privKey, _, _ := crypto.TEST_GenerateKeyPairWithSeed(seed)
-	return &TestConsensusEngine{privKey}
+	return &TestConsensusEngine{privKey: privKey}
 }
 
 type TestValidatorManager struct {
@@ -265,6 +266,106 @@ func setupForServicePayment(ast *assert.Assertions) (et *execTest, resourceID st
 	return et, resourceID, alice, bob, carol, aliceInitBalance, bobInitBalance, carolInitBalance
 }
 
+func createReserveFundTx(chainID string, source *types.PrivAccount, fund, collateral int64, srcSeq int, resourceIDs []string) *types.ReserveFundTx {
+	reserveFundTx := &types.ReserveFundTx{
+		Fee: types.NewCoins(0, getMinimumTxFee()),
+		Source: types.TxInput{
+			Address:  source.Address,
+			Coins:    types.Coins{TFuelWei: big.NewInt(fund), ThetaWei: big.NewInt(0)},
+			Sequence: uint64(srcSeq),
+		},
+		Collateral:  types.Coins{TFuelWei: big.NewInt(collateral), ThetaWei: big.NewInt(0)},
+		ResourceIDs: resourceIDs,
+		Duration:    1000,
+	}
+	reserveFundTx.Source.Signature = source.Sign(reserveFundTx.SignBytes(chainID))
+	return reserveFundTx
+}
+
+func createSlashTx(chainID string, proposer *types.PrivAccount, proposerSeq int, slashedAddress common.Address, reserveSeq uint64, slashProof common.Bytes) *types.SlashTx {
+	return createSlashTxWithReason(chainID, proposer, proposerSeq, slashedAddress, reserveSeq, types.SlashReasonOverspending, slashProof)
+}
+
+func createSlashTxWithReason(chainID string, proposer *types.PrivAccount, proposerSeq int, slashedAddress common.Address, reserveSeq uint64, reason types.SlashReason, slashProof common.Bytes) *types.SlashTx {
+	slashTx := &types.SlashTx{
+		Proposer: types.TxInput{
+			Address:  proposer.Address,
+			Sequence: uint64(proposerSeq),
+		},
+		SlashedAddress:  slashedAddress,
+		ReserveSequence: reserveSeq,
+		SlashProof:      slashProof,
+		Reason:          reason,
+	}
+	slashTx.Proposer.Signature = proposer.Sign(slashTx.SignBytes(chainID))
+	return slashTx
+}
+
+func createBatchSlashTx(chainID string, proposer *types.PrivAccount, proposerSeq int, entries ...types.SlashEntry) *types.BatchSlashTx {
+	batchSlashTx := &types.BatchSlashTx{
+		Proposer: types.TxInput{
+			Address:  proposer.Address,
+			Sequence: uint64(proposerSeq),
+		},
+		Entries: entries,
+	}
+	batchSlashTx.Proposer.Signature = proposer.Sign(batchSlashTx.SignBytes(chainID))
+	return batchSlashTx
+}
+
+func createMultiFundSlashTx(chainID string, proposer *types.PrivAccount, proposerSeq int, slashedAddress common.Address, funds ...types.SlashedFund) *types.MultiFundSlashTx {
+	multiFundSlashTx := &types.MultiFundSlashTx{
+		Proposer: types.TxInput{
+			Address:  proposer.Address,
+			Sequence: uint64(proposerSeq),
+		},
+		SlashedAddress: slashedAddress,
+		Funds:          funds,
+	}
+	multiFundSlashTx.Proposer.Signature = proposer.Sign(multiFundSlashTx.SignBytes(chainID))
+	return multiFundSlashTx
+}
+
+func createSlashEvidenceTx(chainID string, submitter *types.PrivAccount, submitterSeq int, slashedAddress common.Address, reserveSeq uint64, servicePayments ...*types.ServicePaymentTx) *types.SlashEvidenceTx {
+	slashEvidenceTx := &types.SlashEvidenceTx{
+		Fee: types.NewCoins(0, getMinimumTxFee()),
+		Submitter: types.TxInput{
+			Address:  submitter.Address,
+			Sequence: uint64(submitterSeq),
+		},
+		SlashedAddress:  slashedAddress,
+		ReserveSequence: reserveSeq,
+	}
+	for _, servicePaymentTx := range servicePayments {
+		slashEvidenceTx.ServicePayments = append(slashEvidenceTx.ServicePayments, *servicePaymentTx)
+	}
+	slashEvidenceTx.Submitter.Signature = submitter.Sign(slashEvidenceTx.SignBytes(chainID))
+	return slashEvidenceTx
+}
+
+// setupForSlash has Alice overspend her reserved fund (reserve sequence 1) with a service payment to Bob,
+// and returns the resulting slash intent
+func setupForSlash(ast *assert.Assertions) (et *execTest, resourceID string, alice, bob, proposer types.PrivAccount, slashIntent types.SlashIntent) {
+	et, resourceID, alice, bob, _, _, _, _ = setupForServicePayment(ast)
+
+	proposer = et.accProposer
+	et.acc2State(proposer)
+	et.state().Commit()
+
+	txFee := getMinimumTxFee()
+	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &bob, 8000*txFee, 1, 1, 1, 1, resourceID)
+	res := et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	ast.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	ast.True(res.IsOK(), res.Message)
+	ast.Equal(1, len(et.state().Delivered().GetSlashIntents()))
+
+	slashIntent = et.state().Delivered().GetSlashIntents()[0]
+	et.state().Commit()
+
+	return et, resourceID, alice, bob, proposer, slashIntent
+}
+
 type contractByteCode struct {
 	DeploymentCode string `json:"deployment_code"`
 	Code           string `json:"code"`
@@ -311,3 +412,63 @@ func setupForSmartContract(ast *assert.Assertions, numAccounts int) (et *execTes
 
 	return et, privAccounts
 }
+
+// slashHoldings sums up the coins held by the accounts involved in the given slash, including the remaining
+// fund and collateral of their reserved funds and the rewards and bonds the slash locks in the state
+func slashHoldings(view *st.StoreView, slashTx *types.SlashTx, slashTxHash common.Hash) types.Coins {
+	holdings := types.NewCoins(0, 0)
+	addresses := []common.Address{slashTx.SlashedAddress}
+	if slashTx.Proposer.Address != slashTx.SlashedAddress {
+		addresses = append(addresses, slashTx.Proposer.Address)
+	}
+	for _, address := range addresses {
+		acc := view.GetAccount(address)
+		holdings = holdings.Plus(acc.Balance)
+		for _, reservedFund := range acc.ReservedFunds {
+			holdings = holdings.Plus(reservedFund.Collateral).Plus(reservedFund.InitialFund).Minus(reservedFund.UsedFund)
+		}
+	}
+	if vesting := view.GetSlashRewardVesting(slashTxHash); vesting != nil {
+		holdings = holdings.Plus(vesting.Amount)
+	}
+	if bond := view.GetSlashBond(slashTxHash); bond != nil {
+		holdings = holdings.Plus(bond.Amount)
+	}
+	return holdings
+}
+
+// executeSlashWithInvariants executes the SlashTx, and asserts the invariants every successful slash upholds:
+//   - the coins of the involved accounts are conserved, less the given burnt amount
+//   - the slashed reserved fund is removed
+//   - the proposer is credited, either in its balance or with a vesting reward
+//   - the proposer sequence is unchanged, since SlashTxs are special transactions without a sequence
+func executeSlashWithInvariants(ast *assert.Assertions, et *execTest, view *st.StoreView, slashTx *types.SlashTx, burnt types.Coins) {
+	slashTxHash := types.TxID(et.chainID, slashTx)
+	holdingsBefore := slashHoldings(view, slashTx, slashTxHash)
+	proposerBefore := view.GetAccount(slashTx.Proposer.Address)
+
+	txExec := et.executor.getTxExecutor(slashTx)
+	res := txExec.sanityCheck(et.chainID, view, slashTx)
+	ast.True(res.IsOK(), res.Message)
+	_, res = txExec.process(et.chainID, view, slashTx)
+	ast.True(res.IsOK(), res.Message)
+
+	holdingsAfter := slashHoldings(view, slashTx, slashTxHash)
+	ast.True(holdingsBefore.IsEqual(holdingsAfter.Plus(burnt)), "coins not conserved: %v before, %v after, %v burnt",
+		holdingsBefore, holdingsAfter, burnt)
+
+	_, _, res = findReservedFund(view.GetAccount(slashTx.SlashedAddress), slashTx.ReserveSequence)
+	ast.True(res.IsError(), "reserved fund %v is not removed", slashTx.ReserveSequence)
+
+	proposerAfter := view.GetAccount(slashTx.Proposer.Address)
+	credited := proposerAfter.Balance.Minus(proposerBefore.Balance)
+	if vesting := view.GetSlashRewardVesting(slashTxHash); vesting != nil {
+		credited = credited.Plus(vesting.Amount)
+	}
+	if bond := view.GetSlashBond(slashTxHash); bond != nil {
+		credited = credited.Plus(bond.Amount)
+	}
+	ast.True(credited.IsPositive(), "proposer is not credited: %v", credited)
+
+	ast.Equal(proposerBefore.Sequence, proposerAfter.Sequence)
+}
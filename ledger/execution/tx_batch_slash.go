@@ -0,0 +1,127 @@
+package execution
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+var _ TxExecutor = (*BatchSlashTxExecutor)(nil)
+
+// ------------------------------- BatchSlash Transaction -----------------------------------
+
+// SlashEntryResult is the outcome of an entry of a BatchSlashTx
+type SlashEntryResult struct {
+	Index   int
+	Code    result.ErrorCode
+	Message string
+}
+
+// BatchSlashTxExecutor implements the TxExecutor interface. It executes the entries of a BatchSlashTx
+// as independent SlashTxs, following the slashing rules of the SlashTxExecutor
+type BatchSlashTxExecutor struct {
+	slashTxExec *SlashTxExecutor
+}
+
+// NewBatchSlashTxExecutor creates a new instance of BatchSlashTxExecutor
+func NewBatchSlashTxExecutor(slashTxExec *SlashTxExecutor) *BatchSlashTxExecutor {
+	return &BatchSlashTxExecutor{
+		slashTxExec: slashTxExec,
+	}
+}
+
+func (exec *BatchSlashTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.BatchSlashTx)
+
+	numEntries := len(tx.Entries)
+	if numEntries == 0 {
+		return result.Error("BatchSlashTx has no entries")
+	}
+	maxEntries := exec.slashTxExec.params.MaxBatchSlashTxEntries
+	if numEntries > maxEntries {
+		return result.Error("BatchSlashTx has %v entries, at most %v are allowed",
+			numEntries, maxEntries)
+	}
+
+	res := exec.slashTxExec.checkProposer(view, tx.Proposer, tx.SignBytes(chainID))
+	if res.IsError() {
+		return res
+	}
+
+	// The entries succeed or fail independently, but a batch without any valid entry is rejected
+	for idx := range tx.Entries {
+		if exec.slashTxExec.checkSlash(context.Background(), chainID, view, tx.SlashTx(idx)).IsOK() {
+			return result.OK
+		}
+	}
+	return result.Error("None of the %v entries of the BatchSlashTx is valid", numEntries)
+}
+
+func (exec *BatchSlashTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.BatchSlashTx)
+
+	entryResults := []SlashEntryResult{}
+	numSlashed := 0
+	for idx := range tx.Entries {
+		res := exec.processEntry(chainID, view, tx.SlashTx(idx))
+		if res.IsError() {
+			logger.Infof("Entry #%v of the BatchSlashTx failed: %v", idx, res.Message)
+		} else if validated, _ := res.Info["validated"].(bool); !validated {
+			numSlashed++ // entries attesting the reserved fund is not overspent slash nothing
+		}
+		entryResults = append(entryResults, SlashEntryResult{
+			Index:   idx,
+			Code:    res.Code,
+			Message: res.Message,
+		})
+	}
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OKWith(result.Info{
+		"num_slashed": numSlashed,
+		"entries":     entryResults,
+	})
+}
+
+// processEntry executes an entry of the BatchSlashTx. The state changes of a failed entry are reverted.
+func (exec *BatchSlashTxExecutor) processEntry(chainID string, view *st.StoreView, slashTx *types.SlashTx) result.Result {
+	res := exec.slashTxExec.checkSlash(context.Background(), chainID, view, slashTx)
+	if res.IsError() {
+		return res
+	}
+
+	snapshot := view.Snapshot()
+	_, res = exec.slashTxExec.process(chainID, view, slashTx)
+	if res.IsError() {
+		view.RevertToSnapshot(snapshot)
+	}
+	return res
+}
+
+func (exec *BatchSlashTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.BatchSlashTx)
+	return &core.TxInfo{
+		Address:           tx.Proposer.Address,
+		Sequence:          tx.Proposer.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+// getFee returns the fee of the entries combined, each entry is charged as a SlashTx
+func (exec *BatchSlashTxExecutor) getFee(transaction types.Tx) types.Coins {
+	tx := transaction.(*types.BatchSlashTx)
+	fee := types.NewCoins(0, 0)
+	for idx := range tx.Entries {
+		fee = fee.Plus(exec.slashTxExec.getFee(tx.SlashTx(idx)))
+	}
+	return fee
+}
+
+func (exec *BatchSlashTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	return new(big.Int).SetUint64(0)
+}
@@ -3,6 +3,8 @@ package execution
 import (
 	"math/big"
 
+	"github.com/pkg/errors"
+
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
@@ -16,26 +18,50 @@ var _ TxExecutor = (*CoinbaseTxExecutor)(nil)
 
 // CoinbaseTxExecutor implements the TxExecutor interface
 type CoinbaseTxExecutor struct {
-	state     *st.LedgerState
-	consensus core.ConsensusEngine
-	valMgr    core.ValidatorManager
+	state          *st.LedgerState
+	consensus      core.ConsensusEngine
+	valMgr         core.ValidatorManager
+	valSetProvider ValidatorSetProvider
 }
 
 // NewCoinbaseTxExecutor creates a new instance of CoinbaseTxExecutor
 func NewCoinbaseTxExecutor(state *st.LedgerState, consensus core.ConsensusEngine, valMgr core.ValidatorManager) *CoinbaseTxExecutor {
 	return &CoinbaseTxExecutor{
-		state:     state,
-		consensus: consensus,
-		valMgr:    valMgr,
+		state:          state,
+		consensus:      consensus,
+		valMgr:         valMgr,
+		valSetProvider: NewValidatorSetProvider(consensus, valMgr),
 	}
 }
 
+// SetValidatorSetProvider sets the source of the validator set the coinbase proposer is checked against
+func (exec *CoinbaseTxExecutor) SetValidatorSetProvider(valSetProvider ValidatorSetProvider) {
+	exec.valSetProvider = valSetProvider
+}
+
+// ValidateWiring verifies the dependencies required by the executor are wired up
+func (exec *CoinbaseTxExecutor) ValidateWiring() error {
+	if exec.state == nil {
+		return errors.New("CoinbaseTxExecutor: ledger state is not set")
+	}
+	if exec.consensus == nil {
+		return errors.New("CoinbaseTxExecutor: consensus engine is not set")
+	}
+	if exec.valMgr == nil {
+		return errors.New("CoinbaseTxExecutor: validator manager is not set")
+	}
+	return nil
+}
+
 func (exec *CoinbaseTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
 	tx := transaction.(*types.CoinbaseTx)
-	validatorAddresses := getValidatorAddresses(exec.consensus, exec.valMgr)
+	validatorAddresses, res := getValidatorAddresses(exec.valSetProvider, view)
+	if res.IsError() {
+		return res
+	}
 
 	// Validate proposer, basic
-	res := tx.Proposer.ValidateBasic()
+	res = tx.Proposer.ValidateBasic()
 	if res.IsError() {
 		return res
 	}
@@ -46,7 +72,7 @@ func (exec *CoinbaseTxExecutor) sanityCheck(chainID string, view *st.StoreView,
 	}
 
 	// verify the proposer is one of the validators
-	res = isAValidator(tx.Proposer.Address, validatorAddresses)
+	res = isAValidator(tx.Proposer.Address, validatorAddresses, view.Height())
 	if res.IsError() {
 		return res
 	}
@@ -134,6 +160,10 @@ func (exec *CoinbaseTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
 	}
 }
 
+func (exec *CoinbaseTxExecutor) getFee(transaction types.Tx) types.Coins {
+	return types.NewCoins(0, 0)
+}
+
 func (exec *CoinbaseTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	return new(big.Int).SetUint64(0)
 }
@@ -139,6 +139,11 @@ func (exec *DepositStakeExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
 	}
 }
 
+func (exec *DepositStakeExecutor) getFee(transaction types.Tx) types.Coins {
+	tx := transaction.(*types.DepositStakeTx)
+	return tx.Fee
+}
+
 func (exec *DepositStakeExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	tx := transaction.(*types.DepositStakeTx)
 	fee := tx.Fee
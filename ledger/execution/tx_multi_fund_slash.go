@@ -0,0 +1,136 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+var _ TxExecutor = (*MultiFundSlashTxExecutor)(nil)
+
+// ------------------------------- MultiFundSlash Transaction -----------------------------------
+
+// MultiFundSlashTxExecutor implements the TxExecutor interface. It slashes the reserved funds of a
+// MultiFundSlashTx as SlashTxs, following the slashing rules of the SlashTxExecutor, but atomically:
+// if any of them cannot be slashed, none of them is
+type MultiFundSlashTxExecutor struct {
+	slashTxExec *SlashTxExecutor
+}
+
+// NewMultiFundSlashTxExecutor creates a new instance of MultiFundSlashTxExecutor
+func NewMultiFundSlashTxExecutor(slashTxExec *SlashTxExecutor) *MultiFundSlashTxExecutor {
+	return &MultiFundSlashTxExecutor{
+		slashTxExec: slashTxExec,
+	}
+}
+
+func (exec *MultiFundSlashTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.MultiFundSlashTx)
+
+	numFunds := len(tx.Funds)
+	if numFunds == 0 {
+		return result.Error("MultiFundSlashTx has no reserved funds to slash")
+	}
+	maxFunds := exec.slashTxExec.params.MaxBatchSlashTxEntries
+	if numFunds > maxFunds {
+		return result.Error("MultiFundSlashTx slashes %v reserved funds, at most %v are allowed",
+			numFunds, maxFunds)
+	}
+	reserveSequences := make(map[uint64]bool)
+	for _, fund := range tx.Funds {
+		if reserveSequences[fund.ReserveSequence] {
+			return result.Error("MultiFundSlashTx slashes reserved fund %v more than once", fund.ReserveSequence).
+				WithErrorCode(result.CodeSlashDuplicateReserveSequence)
+		}
+		reserveSequences[fund.ReserveSequence] = true
+	}
+
+	res := exec.slashTxExec.checkProposer(view, tx.Proposer, tx.SignBytes(chainID))
+	if res.IsError() {
+		return res
+	}
+
+	for idx := range tx.Funds {
+		res := exec.slashTxExec.checkSlash(context.Background(), chainID, view, tx.SlashTx(idx))
+		if res.IsError() {
+			return res.WithMessage(fmt.Sprintf(" (reserved fund %v)", tx.Funds[idx].ReserveSequence))
+		}
+	}
+	return result.OK
+}
+
+func (exec *MultiFundSlashTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.MultiFundSlashTx)
+
+	snapshot := view.Snapshot()
+	numEvents := len(view.GetEvents())
+	slashedAmount := types.NewCoins(0, 0)
+	returnedAmount := types.NewCoins(0, 0)
+	burntAmount := types.NewCoins(0, 0)
+	treasuryAmount := types.NewCoins(0, 0)
+	for idx := range tx.Funds {
+		res := exec.processFund(chainID, view, tx.SlashTx(idx))
+		if res.IsError() {
+			view.RevertToSnapshot(snapshot)
+			view.RevertEvents(numEvents)
+			return common.Hash{}, res.WithMessage(fmt.Sprintf(" (reserved fund %v)", tx.Funds[idx].ReserveSequence))
+		}
+		if validated, _ := res.Info["validated"].(bool); validated {
+			continue // the reserved fund is attested not to be overspent, nothing is slashed
+		}
+		slashedAmount = slashedAmount.Plus(res.Info["slashed_amount"].(types.Coins))
+		returnedAmount = returnedAmount.Plus(res.Info["returned_amount"].(types.Coins))
+		burntAmount = burntAmount.Plus(res.Info["burnt_amount"].(types.Coins))
+		treasuryAmount = treasuryAmount.Plus(res.Info["treasury_amount"].(types.Coins))
+	}
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OKWith(result.Info{
+		"slashed_amount":  slashedAmount,
+		"returned_amount": returnedAmount,
+		"burnt_amount":    burntAmount,
+		"treasury_amount": treasuryAmount,
+	})
+}
+
+// processFund slashes a reserved fund of the MultiFundSlashTx. The slash of an earlier reserved fund
+// may affect the checks of a later one, e.g. whether the proposer can afford the bond, hence the
+// reserved funds are checked again against the updated state.
+func (exec *MultiFundSlashTxExecutor) processFund(chainID string, view *st.StoreView, slashTx *types.SlashTx) result.Result {
+	res := exec.slashTxExec.checkSlash(context.Background(), chainID, view, slashTx)
+	if res.IsError() {
+		return res
+	}
+
+	_, res = exec.slashTxExec.process(chainID, view, slashTx)
+	return res
+}
+
+func (exec *MultiFundSlashTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.MultiFundSlashTx)
+	return &core.TxInfo{
+		Address:           tx.Proposer.Address,
+		Sequence:          tx.Proposer.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+// getFee returns the fee of the reserved funds combined, each slash is charged as a SlashTx
+func (exec *MultiFundSlashTxExecutor) getFee(transaction types.Tx) types.Coins {
+	tx := transaction.(*types.MultiFundSlashTx)
+	fee := types.NewCoins(0, 0)
+	for idx := range tx.Funds {
+		fee = fee.Plus(exec.slashTxExec.getFee(tx.SlashTx(idx)))
+	}
+	return fee
+}
+
+func (exec *MultiFundSlashTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	return new(big.Int).SetUint64(0)
+}
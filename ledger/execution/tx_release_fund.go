@@ -64,6 +64,17 @@ func (exec *ReleaseFundTxExecutor) sanityCheck(chainID string, view *st.StoreVie
 
 	currentBlockHeight := exec.state.Height()
 	reserveSequence := tx.ReserveSequence
+	if _, slashed := view.GetSlashedProofHash(tx.Source.Address, reserveSequence); slashed {
+		return result.Error("Reserved fund %v has been slashed, nothing to release", reserveSequence).
+			WithErrorCode(result.CodeReleaseFundCheckFailed)
+	}
+
+	// The same lookup as the slash path, so that a reserved fund the slash would not locate, e.g. one
+	// sharing its reserve sequence with another, cannot be released either
+	_, _, res = findReservedFund(sourceAccount, reserveSequence)
+	if res.IsError() {
+		return result.Error(res.Message).WithErrorCode(result.CodeReleaseFundCheckFailed)
+	}
 	err := sourceAccount.CheckReleaseFund(currentBlockHeight, reserveSequence)
 	if err != nil {
 		return result.Error(err.Error()).WithErrorCode(result.CodeReleaseFundCheckFailed)
@@ -94,6 +105,8 @@ func (exec *ReleaseFundTxExecutor) process(chainID string, view *st.StoreView, t
 
 	sourceAccount.Sequence++
 	view.SetAccount(sourceAddress, sourceAccount)
+	view.DeleteReservedFundValidation(sourceAddress, reserveSequence)
+	view.DeleteReserveFundTxHash(sourceAddress, reserveSequence)
 
 	txHash := types.TxID(chainID, tx)
 	return txHash, result.OK
@@ -108,6 +121,11 @@ func (exec *ReleaseFundTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo
 	}
 }
 
+func (exec *ReleaseFundTxExecutor) getFee(transaction types.Tx) types.Coins {
+	tx := transaction.(*types.ReleaseFundTx)
+	return tx.Fee
+}
+
 func (exec *ReleaseFundTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	tx := transaction.(*types.ReleaseFundTx)
 	fee := tx.Fee
@@ -17,13 +17,16 @@ var _ TxExecutor = (*ReserveFundTxExecutor)(nil)
 
 // ReserveFundTxExecutor implements the TxExecutor interface
 type ReserveFundTxExecutor struct {
-	state *st.LedgerState
+	state       *st.LedgerState
+	slashTxExec *SlashTxExecutor
 }
 
-// NewReserveFundTxExecutor creates a new instance of ReserveFundTxExecutor
-func NewReserveFundTxExecutor(state *st.LedgerState) *ReserveFundTxExecutor {
+// NewReserveFundTxExecutor creates a new instance of ReserveFundTxExecutor. The slashing rules of
+// slashTxExec determine the permitted reserve durations.
+func NewReserveFundTxExecutor(state *st.LedgerState, slashTxExec *SlashTxExecutor) *ReserveFundTxExecutor {
 	return &ReserveFundTxExecutor{
-		state: state,
+		state:       state,
+		slashTxExec: slashTxExec,
 	}
 }
 
@@ -67,6 +70,12 @@ func (exec *ReserveFundTxExecutor) sanityCheck(chainID string, view *st.StoreVie
 			types.MinimumTransactionFeeTFuelWei).WithErrorCode(result.CodeInvalidFee)
 	}
 
+	if view.IsAccountSlashFlagged(tx.Source.Address) {
+		return result.Error("Account %v is flagged as %v after a slash, cannot reserve fund",
+			tx.Source.Address.Hex(), view.GetAccountSlashFlag(tx.Source.Address).Status).
+			WithErrorCode(result.CodeReserveFundCheckFailed)
+	}
+
 	fund := tx.Source.Coins
 	collateral := tx.Collateral
 	duration := tx.Duration
@@ -84,6 +93,26 @@ func (exec *ReserveFundTxExecutor) sanityCheck(chainID string, view *st.StoreVie
 		return result.Error(err.Error()).WithErrorCode(result.CodeReserveFundCheckFailed)
 	}
 
+	err = exec.slashTxExec.checkCollateral(collateral)
+	if err != nil {
+		return result.Error(err.Error()).WithErrorCode(result.CodeReserveFundCheckFailed)
+	}
+
+	err = exec.slashTxExec.checkSlashableWindow(duration)
+	if err != nil {
+		return result.Error(err.Error()).WithErrorCode(result.CodeReserveFundCheckFailed)
+	}
+
+	err = types.CheckResourceCaps(tx.ResourceIDs, tx.ResourceCaps)
+	if err != nil {
+		return result.Error(err.Error()).WithErrorCode(result.CodeReserveFundCheckFailed)
+	}
+
+	err = types.CheckServiceType(tx.ServiceType)
+	if err != nil {
+		return result.Error(err.Error()).WithErrorCode(result.CodeReserveFundCheckFailed)
+	}
+
 	return result.OK
 }
 
@@ -101,9 +130,14 @@ func (exec *ReserveFundTxExecutor) process(chainID string, view *st.StoreView, t
 	resourceIDs := tx.ResourceIDs
 	duration := tx.Duration
 	reserveSequence := tx.Source.Sequence
-	endBlockHeight := exec.state.Height() + duration
+	startBlockHeight := exec.state.Height()
+	endBlockHeight := startBlockHeight + duration
 
-	sourceAccount.ReserveFund(collateral, fund, resourceIDs, endBlockHeight, reserveSequence)
+	sourceAccount.ReserveFund(collateral, fund, resourceIDs, startBlockHeight, endBlockHeight, reserveSequence)
+	if len(tx.ResourceCaps) > 0 {
+		sourceAccount.ReservedFunds[len(sourceAccount.ReservedFunds)-1].ResourceCaps = tx.ResourceCaps
+	}
+	sourceAccount.ReservedFunds[len(sourceAccount.ReservedFunds)-1].ServiceType = tx.ServiceType
 	if !chargeFee(sourceAccount, tx.Fee) {
 		return common.Hash{}, result.Error("failed to charge transaction fee")
 	}
@@ -112,6 +146,7 @@ func (exec *ReserveFundTxExecutor) process(chainID string, view *st.StoreView, t
 	view.SetAccount(sourceAddress, sourceAccount)
 
 	txHash := types.TxID(chainID, tx)
+	view.SetReserveFundTxHash(sourceAddress, reserveSequence, txHash)
 	return txHash, result.OK
 }
 
@@ -124,6 +159,11 @@ func (exec *ReserveFundTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo
 	}
 }
 
+func (exec *ReserveFundTxExecutor) getFee(transaction types.Tx) types.Coins {
+	tx := transaction.(*types.ReserveFundTx)
+	return tx.Fee
+}
+
 func (exec *ReserveFundTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	tx := transaction.(*types.ReserveFundTx)
 	fee := tx.Fee
@@ -131,3 +171,19 @@ func (exec *ReserveFundTxExecutor) calculateEffectiveGasPrice(transaction types.
 	effectiveGasPrice := new(big.Int).Div(fee.TFuelWei, gas)
 	return effectiveGasPrice
 }
+
+// GetReservedFunds returns a copy of the reserved funds of the account, e.g. for the RPC layers to tell
+// whether the funds can be released or slashed. Mutating the copy does not affect the view. An error is
+// returned if the account does not exist
+func GetReservedFunds(view *st.StoreView, addr common.Address) ([]types.ReservedFund, result.Result) {
+	account := view.GetAccount(addr)
+	if account == nil {
+		return nil, result.Error("Account %v does not exist", addr)
+	}
+
+	reservedFunds := make([]types.ReservedFund, 0, len(account.ReservedFunds))
+	for idx := range account.ReservedFunds {
+		reservedFunds = append(reservedFunds, *account.ReservedFunds[idx].Copy())
+	}
+	return reservedFunds, result.OK
+}
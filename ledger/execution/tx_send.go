@@ -109,6 +109,11 @@ func (exec *SendTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
 	}
 }
 
+func (exec *SendTxExecutor) getFee(transaction types.Tx) types.Coins {
+	tx := transaction.(*types.SendTx)
+	return tx.Fee
+}
+
 func (exec *SendTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	tx := transaction.(*types.SendTx)
 	fee := tx.Fee
@@ -17,13 +17,15 @@ var _ TxExecutor = (*ServicePaymentTxExecutor)(nil)
 
 // ServicePaymentTxExecutor implements the TxExecutor interface
 type ServicePaymentTxExecutor struct {
-	state *st.LedgerState
+	state       *st.LedgerState
+	slashTxExec *SlashTxExecutor
 }
 
 // NewServicePaymentTxExecutor creates a new instance of ServicePaymentTxExecutor
-func NewServicePaymentTxExecutor(state *st.LedgerState) *ServicePaymentTxExecutor {
+func NewServicePaymentTxExecutor(state *st.LedgerState, slashTxExec *SlashTxExecutor) *ServicePaymentTxExecutor {
 	return &ServicePaymentTxExecutor{
-		state: state,
+		state:       state,
+		slashTxExec: slashTxExec,
 	}
 }
 
@@ -129,7 +131,12 @@ func (exec *ServicePaymentTxExecutor) process(chainID string, view *st.StoreView
 	reserveSequence := tx.ReserveSequence
 	shouldSlash, slashIntent := sourceAccount.TransferReservedFund(coinsMap, currentBlockHeight, reserveSequence, tx)
 	if shouldSlash {
+		if exec.slashTxExec.params.RequireReserveTxHash {
+			slashIntent.Proof = bindOverspendingProof(view, slashIntent.Address, reserveSequence, slashIntent.Proof)
+		}
 		view.AddSlashIntent(slashIntent)
+	} else if _, validated := view.GetReservedFundValidation(sourceAddress, reserveSequence); validated {
+		view.DeleteReservedFundValidation(sourceAddress, reserveSequence) // the attestation predates the payment
 	}
 	if !chargeFee(targetAccount, tx.Fee) {
 		return common.Hash{}, result.Error("failed to charge transaction fee")
@@ -203,6 +210,11 @@ func (exec *ServicePaymentTxExecutor) getTxInfo(transaction types.Tx) *core.TxIn
 	}
 }
 
+func (exec *ServicePaymentTxExecutor) getFee(transaction types.Tx) types.Coins {
+	tx := transaction.(*types.ServicePaymentTx)
+	return tx.Fee
+}
+
 func (exec *ServicePaymentTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	tx := transaction.(*types.ServicePaymentTx)
 	fee := tx.Fee
@@ -1,11 +1,19 @@
 package execution
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"math/big"
+	"sort"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
 	st "github.com/thetatoken/theta/ledger/state"
 	"github.com/thetatoken/theta/ledger/types"
 )
@@ -15,74 +23,451 @@ var _ TxExecutor = (*SlashTxExecutor)(nil)
 // ------------------------------- Slash Transaction -----------------------------------
 
 type SlashTxExecutor struct {
-	consensus core.ConsensusEngine
-	valMgr    core.ValidatorManager
+	consensus      core.ConsensusEngine
+	valMgr         core.ValidatorManager
+	valSetProvider ValidatorSetProvider
+
+	params                SlashParams
+	unslashableAddresses  map[common.Address]bool // indexes params.UnslashableAddresses
+	logOverspendingMargin bool
+	rejectedSlashes       *rejectedSlashRecorder
+	slashMetrics          SlashMetrics
+	logger                log.FieldLogger
 }
 
-// NewSlashTxExecutor creates a new instance of SlashTxExecutor
+// NewSlashTxExecutor creates a new instance of SlashTxExecutor with the default slash parameters
 func NewSlashTxExecutor(consensus core.ConsensusEngine, valMgr core.ValidatorManager) *SlashTxExecutor {
-	return &SlashTxExecutor{
-		consensus: consensus,
-		valMgr:    valMgr,
+	return NewSlashTxExecutorWithParams(consensus, valMgr, DefaultSlashParams())
+}
+
+// NewSlashTxExecutorWithParams creates a new instance of SlashTxExecutor enforcing the given slash parameters
+func NewSlashTxExecutorWithParams(consensus core.ConsensusEngine, valMgr core.ValidatorManager, params SlashParams) *SlashTxExecutor {
+	exec := &SlashTxExecutor{
+		consensus:      consensus,
+		valMgr:         valMgr,
+		valSetProvider: NewValidatorSetProvider(consensus, valMgr),
+		logger:         logger,
+	}
+	exec.SetParams(params)
+
+	// Not fatal, a standalone validator set provider can be set, but the misconfiguration is reported
+	// here rather than as an obscure failure of the first SlashTx
+	if consensus == nil {
+		exec.logger.Warnf("SlashTxExecutor: consensus engine is not set, the proposers cannot be verified unless a validator set provider is set")
+	}
+	if valMgr == nil {
+		exec.logger.Warnf("SlashTxExecutor: validator manager is not set, the proposers cannot be verified unless a validator set provider is set")
+	}
+	return exec
+}
+
+// SetValidatorSetProvider sets the source of the validator set the slash proposers are checked against
+func (exec *SlashTxExecutor) SetValidatorSetProvider(valSetProvider ValidatorSetProvider) {
+	exec.valSetProvider = valSetProvider
+}
+
+// SetLogOverspendingMargin sets whether to log (at debug level) the fund intended to spend by the slash proofs
+// along with the initial fund of the reserved funds, so operators can see the overspending margin
+func (exec *SlashTxExecutor) SetLogOverspendingMargin(enabled bool) {
+	exec.logOverspendingMargin = enabled
+}
+
+// SetRejectedSlashSink sets the sink to record the rejected SlashTxs. Nil disables the recording. The
+// rejections still queued for the previous sink are recorded by it. It must not be called concurrently
+// with the sanity checks
+func (exec *SlashTxExecutor) SetRejectedSlashSink(sink RejectedSlashSink) {
+	if exec.rejectedSlashes != nil {
+		exec.rejectedSlashes.stop()
+		exec.rejectedSlashes = nil
+	}
+	if sink != nil {
+		exec.rejectedSlashes = newRejectedSlashRecorder(sink, exec.logger)
+	}
+}
+
+// DroppedRejectedSlashes returns the number of rejected SlashTxs dropped since the RejectedSlashSink was
+// set, because it could not keep up with the rejections
+func (exec *SlashTxExecutor) DroppedRejectedSlashes() uint64 {
+	if exec.rejectedSlashes == nil {
+		return 0
+	}
+	return exec.rejectedSlashes.droppedCount()
+}
+
+// SetSlashMetrics sets the receiver of the slashing metrics, see NewSlashCounters. Nil disables the metrics.
+func (exec *SlashTxExecutor) SetSlashMetrics(slashMetrics SlashMetrics) {
+	exec.slashMetrics = slashMetrics
+}
+
+// SetLogger sets the logger of the sanity check rejections, the slashes and the slash proof failures, e.g. to
+// capture them in tests. A nil logger restores the ledger logger
+func (exec *SlashTxExecutor) SetLogger(l log.FieldLogger) {
+	if l == nil {
+		l = logger
+	}
+	exec.logger = l
+}
+
+// ValidateWiring verifies the dependencies required by the executor are wired up
+func (exec *SlashTxExecutor) ValidateWiring() error {
+	if exec.consensus == nil {
+		return errors.New("SlashTxExecutor: consensus engine is not set")
+	}
+	if exec.valMgr == nil {
+		return errors.New("SlashTxExecutor: validator manager is not set")
+	}
+	if exec.logger == nil {
+		return errors.New("SlashTxExecutor: logger is not set")
+	}
+	if err := exec.params.Validate(); err != nil {
+		return errors.Wrap(err, "SlashTxExecutor")
+	}
+	return nil
+}
+
+var _ ErrorConditionEnumerator = (*SlashTxExecutor)(nil)
+
+// ErrorConditions returns the error conditions of the SlashTxs
+func (exec *SlashTxExecutor) ErrorConditions() []ErrorCondition {
+	return []ErrorCondition{
+		{result.CodeGenericError, "The transaction is malformed, or the proposer account cannot pay for it"},
+		{result.CodeSlashAccountNotFound, "The slashed account or the proposer account does not exist"},
+		{result.CodeSlashReservedFundNotFound, "The reserved fund does not exist"},
+		{result.CodeSlashNonValidatorProposer, "The proposer is not a validator"},
+		{result.CodeSlashInvalidProposerSignature, "The proposer signature does not verify"},
+		{result.CodeSlashInvalidProof, "The slash proof is malformed, or does not prove the offense"},
+		{result.CodeSlashNoEvidence, "The SlashTx carries no slash proof, and no slash evidence has been accumulated"},
+		{result.CodeInsufficientFund, "The proposer cannot afford the slash bond and fee"},
+		{result.CodeInsufficientStake, "The proposer does not have the minimum active stake"},
+		{result.CodeSlashReservedFundTooOld, "The reserved fund is too old to be slashed"},
+		{result.CodeSlashDuplicateReserveSequence, "Multiple reserved funds of the slashed account share the reserve sequence"},
+		{result.CodeSlashConflictedProposer, "The proposer is a payment target in the slash proof"},
+		{result.CodeSlashWrongReserveSequence, "A service payment in the slash proof is charged against another reserved fund"},
+		{result.CodeSlashProofTimestampOutOfSkew, "The slash proof is not timestamped, or its timestamp is too far from the block time"},
+		{result.CodeSlashProtectedAddress, "The slashed account is protected from being slashed"},
+		{result.CodeSlashReservedFundTooNew, "The reserved fund is still in the slash grace period"},
+		{result.CodeSlashInvalidSnapshot, "The state snapshot the slash proof is bound to is invalid"},
+		{result.CodeSlashPausedAddress, "Slashing the account is paused"},
+		{result.CodeSlashNotOverspent, "The slash proof does not show the reserved fund is overspent"},
+		{result.CodeSlashProofExpired, "The dispute window of the reserved fund is over"},
+		{result.CodeSlashZeroCollateral, "The reserved fund has no collateral to slash"},
+		{result.CodeSlashReserveTxHashMismatch, "The slash proof is not bound to the ReserveFundTx that created the reserved fund"},
+		{result.CodeSlashReasonMismatch, "The slash reason is not supported, or does not match the type of the slash proof"},
+		{result.CodeSlashAlreadySlashed, "The reserved fund has already been slashed"},
+		{result.CodeSlashUnsupportedSignatureScheme, "A service payment in the slash proof is signed with an unsupported signature scheme"},
+		{result.CodeSlashServiceTypeMismatch, "The reserved fund does not have the service type the slash is restricted to"},
+		{result.CodeSlashSelfSlash, "The proposer is the slashed account"},
+		{result.CodeSlashVerificationAborted, "The verification of the slash proof was aborted, the SlashTx can be retried later"},
+		{result.CodeValidatorSetUnavailable, "The validator set is temporarily unavailable, the SlashTx can be retried later"},
 	}
 }
 
 func (exec *SlashTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	return exec.sanityCheckWithContext(context.Background(), chainID, view, transaction)
+}
+
+// SanityCheckWithContext runs the sanity check of the SlashTx against a read-only view, and aborts the
+// verification of the slash proof once the context is done, with the retryable CodeSlashVerificationAborted
+// error. It lets a node bound the time spent screening a SlashTx with a large proof. It is not meant for the
+// delivery of the blocks, since a deadline local to the node would make the nodes disagree on the outcome
+func (exec *SlashTxExecutor) SanityCheckWithContext(ctx context.Context, chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	if _, ok := transaction.(*types.SlashTx); !ok {
+		return result.Error("Transaction is not a SlashTx")
+	}
+	return sanityCheckReadOnly(&slashTxExecutorWithContext{SlashTxExecutor: exec, ctx: ctx}, chainID, view, transaction)
+}
+
+// slashTxExecutorWithContext runs the sanity checks of the SlashTxExecutor with the given context
+type slashTxExecutorWithContext struct {
+	*SlashTxExecutor
+	ctx context.Context
+}
+
+func (exec *slashTxExecutorWithContext) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	return exec.sanityCheckWithContext(exec.ctx, chainID, view, transaction)
+}
+
+func (exec *SlashTxExecutor) sanityCheckWithContext(ctx context.Context, chainID string, view *st.StoreView, transaction types.Tx) result.Result {
 	tx := transaction.(*types.SlashTx)
+	res := exec.checkSlashTx(ctx, chainID, view, tx)
+	if exec.slashMetrics != nil {
+		exec.slashMetrics.SlashAttempted()
+		if res.IsError() && !res.IsRetryable() {
+			exec.slashMetrics.SlashRejected(SlashRejectionReasonOf(res))
+		}
+	}
+	if res.IsError() && !res.IsRetryable() {
+		exec.logger.WithFields(log.Fields{
+			"txHash":          tx.ID(chainID).Hex(),
+			"slashedAddress":  tx.SlashedAddress.Hex(),
+			"proposer":        tx.Proposer.Address.Hex(),
+			"reserveSequence": tx.ReserveSequence,
+			"code":            res.Code,
+			"reason":          res.Message,
+		}).Info("Rejected the SlashTx")
+		exec.recordRejectedSlash(tx, res)
+	}
+	return res
+}
+
+func (exec *SlashTxExecutor) recordRejectedSlash(tx *types.SlashTx, reason result.Result) {
+	if exec.rejectedSlashes == nil {
+		return
+	}
+	exec.rejectedSlashes.enqueue(tx, reason)
+}
+
+// BatchSanityCheck runs the sanity check of each of the SlashTxs against a read-only view, and returns the
+// results in the same order, e.g. for a block proposer to drop the invalid slashes from its mempool before
+// processing any of them. A reserved fund can only be slashed once, so the SlashTx of a reserved fund that an
+// earlier SlashTx of the batch already passes the check for is rejected as conflicting. Likewise, the slash
+// proofs must fit in the verification budget the earlier SlashTxs of the batch leave in the block
+func (exec *SlashTxExecutor) BatchSanityCheck(chainID string, view *st.StoreView, txs []types.Tx) []result.Result {
+	results := make([]result.Result, len(txs))
+	checked := make(map[slashedReservedFundKey]int)
+	batchCost := uint64(0)
+	for idx, transaction := range txs {
+		tx, ok := transaction.(*types.SlashTx)
+		if !ok {
+			results[idx] = result.Error("Transaction %v of the batch is not a SlashTx", idx)
+			continue
+		}
 
-	validatorAddresses := getValidatorAddresses(exec.consensus, exec.valMgr)
+		key := slashedReservedFundKey{address: tx.SlashedAddress, reserveSequence: tx.ReserveSequence}
+		if prevIdx, conflicting := checked[key]; conflicting {
+			results[idx] = result.Error("Reserved fund %v of %v is already slashed by transaction %v of the batch",
+				tx.ReserveSequence, tx.SlashedAddress, prevIdx).WithErrorCode(result.CodeSlashDuplicateReserveSequence)
+			continue
+		}
+
+		cost := exec.proofVerificationCost(view, tx)
+		res := exec.checkProofVerificationBudget(view, batchCost+cost)
+		if res.IsOK() {
+			res = sanityCheckReadOnly(exec, chainID, view, tx)
+		}
+		results[idx] = res
+		if res.IsError() {
+			continue
+		}
+		checked[key] = idx
+		batchCost += cost
+	}
+	return results
+}
+
+// slashedReservedFundKey identifies a reserved fund by its account and reserve sequence
+type slashedReservedFundKey struct {
+	address         common.Address
+	reserveSequence uint64
+}
+
+func (exec *SlashTxExecutor) checkSlashTx(ctx context.Context, chainID string, view *st.StoreView, tx *types.SlashTx) result.Result {
+	res := exec.checkProposer(view, tx.Proposer, tx.SignBytes(chainID))
+	if res.IsError() {
+		return res
+	}
+	return exec.checkSlash(ctx, chainID, view, tx)
+}
+
+// checkProposer verifies the proposer of a slash is one of the validators, and has signed the transaction.
+// The proposer is checked against the validator set effective at the processing height, not at the height
+// the slash proof was observed: the proof is evidence against the slashed account, whereas the proposer is
+// rewarded as a validator of the block including the slash, so a validator rotated out in between cannot
+// claim the reward, and one rotated in can
+func (exec *SlashTxExecutor) checkProposer(view *st.StoreView, proposer types.TxInput, signBytes []byte) result.Result {
+	blockHeight := view.Height()
+
+	// A misconfigured executor must not let the proposer check pass silently
+	validatorAddresses, res := getValidatorAddresses(exec.valSetProvider, view)
+	if res.IsError() {
+		return result.Error("Cannot verify the proposer %v: %v", proposer.Address.Hex(), res.Message).WithErrorCode(res.Code)
+	}
 
 	// Validate proposer, basic
-	res := tx.Proposer.ValidateBasic()
+	res = proposer.ValidateBasic()
 	if res.IsError() {
 		return res
 	}
 
 	// verify the proposer is one of the validators
-	res = isAValidator(tx.Proposer.Address, validatorAddresses)
+	res = isAValidator(proposer.Address, validatorAddresses, blockHeight)
 	if res.IsError() {
-		return res
+		return withSlashRejection(res.WithErrorCode(result.CodeSlashNonValidatorProposer), SlashRejectionNonValidatorProposer)
+	}
+
+	if exec.params.MinProposerStake != nil && exec.params.MinProposerStake.Sign() > 0 {
+		stake := getActiveStake(view, proposer.Address)
+		if stake.Cmp(exec.params.MinProposerStake) < 0 {
+			return result.Error("Proposer %v has an active stake of %v, less than the minimum of %v",
+				proposer.Address.Hex(), stake, exec.params.MinProposerStake).WithErrorCode(result.CodeInsufficientStake)
+		}
 	}
 
-	proposerAccount, res := getInput(view, tx.Proposer)
+	proposerAccount, res := getInput(view, proposer)
 	if res.IsError() {
 		return res
 	}
 
 	// verify the proposer's signature
-	signBytes := tx.SignBytes(chainID)
-	if !tx.Proposer.Signature.Verify(signBytes, proposerAccount.Address) {
-		return result.Error("SignBytes: %X", signBytes)
+	if !proposer.Signature.Verify(signBytes, proposerAccount.Address) {
+		return withSlashRejection(result.Error("SignBytes: %X", signBytes).
+			WithErrorCode(result.CodeSlashInvalidProposerSignature), SlashRejectionBadSignature)
+	}
+
+	return result.OK
+}
+
+// getActiveStake returns the stake deposited to the stake holder and not withdrawn
+func getActiveStake(view *st.StoreView, holder common.Address) *big.Int {
+	vcp := view.GetValidatorCandidatePool()
+	if vcp == nil {
+		return big.NewInt(0)
 	}
+	for _, candidate := range vcp.SortedCandidates {
+		if candidate.Holder == holder {
+			return candidate.TotalStake()
+		}
+	}
+	return big.NewInt(0)
+}
 
+// checkSlash verifies the slash itself, i.e. the slashed reserved fund and the slash proof. The proposer
+// is verified separately by checkProposer. The verification of an overspending proof is aborted once the
+// context is done.
+func (exec *SlashTxExecutor) checkSlash(ctx context.Context, chainID string, view *st.StoreView, tx *types.SlashTx) result.Result {
 	slashedAddress := tx.SlashedAddress
-	slashedAccount := view.GetAccount(slashedAddress)
+	// A validator slashing its own reserved fund would get the collateral back as the reward
+	if tx.Proposer.Address == slashedAddress {
+		return result.Error("Proposer %v cannot slash its own reserved fund", slashedAddress).
+			WithErrorCode(result.CodeSlashSelfSlash)
+	}
+	if exec.unslashableAddresses[slashedAddress] {
+		return result.Error("Account %v is protected from being slashed", slashedAddress).
+			WithErrorCode(result.CodeSlashProtectedAddress)
+	}
+	if view.IsSlashPaused(slashedAddress) {
+		endBlockHeight, _ := view.GetSlashPause(slashedAddress)
+		return result.Error("Slashing account %v is paused until block height %v", slashedAddress, endBlockHeight).
+			WithErrorCode(result.CodeSlashPausedAddress)
+	}
+	if proofHash, slashed := view.GetSlashedProofHash(slashedAddress, tx.ReserveSequence); slashed {
+		return result.Error("Reserved fund %v of %v has already been slashed with proof %v",
+			tx.ReserveSequence, slashedAddress, proofHash.Hex()).WithErrorCode(result.CodeSlashAlreadySlashed)
+	}
+
+	validatorAddress := tx.Proposer.Address
+	accounts := view.GetAccounts([]common.Address{slashedAddress, validatorAddress})
+	slashedAccount := accounts[slashedAddress]
 	if slashedAccount == nil {
-		return result.Error("Account %v does not exist!", slashedAddress)
+		return withSlashRejection(result.Error("Account %v does not exist!", slashedAddress).
+			WithErrorCode(result.CodeSlashAccountNotFound), SlashRejectionFundNotFound)
 	}
 
-	reservedFundFound := false
-	for _, reservedFund := range slashedAccount.ReservedFunds {
-		if reservedFund.ReserveSequence == tx.ReserveSequence {
-			reservedFundFound = true
-			break
+	reservedFund, _, res := findReservedFund(slashedAccount, tx.ReserveSequence)
+	if res.IsError() {
+		if res.Code == result.CodeSlashReservedFundNotFound {
+			res = withSlashRejection(res, SlashRejectionFundNotFound)
 		}
+		return res
 	}
 
-	if !reservedFundFound {
-		return result.Error("Reserved fund not found for %v", tx.ReserveSequence)
+	// A watchtower specialized in a type of service restricts its slashes to the reserved funds of that type
+	if !reservedFund.MatchesServiceType(tx.ServiceType) {
+		return result.Error("Reserved fund %v has service type %q, but the slash is restricted to service type %q",
+			tx.ReserveSequence, reservedFund.ServiceType, tx.ServiceType).WithErrorCode(result.CodeSlashServiceTypeMismatch)
 	}
 
-	validatorAddress := tx.Proposer.Address
-	validatorAccount := view.GetAccount(validatorAddress)
+	if exec.params.RejectZeroCollateral && reservedFund.Collateral.IsZero() {
+		return result.Error("Reserved fund %v has no collateral to slash", tx.ReserveSequence).
+			WithErrorCode(result.CodeSlashZeroCollateral)
+	}
+
+	// The age of a reserved fund is unknown if it was reserved before its start block height was recorded. It
+	// is then not held against the slash, so that the legacy reserved funds cannot escape the slashes
+	if exec.params.MaxReservedFundAge > 0 && reservedFund.StartBlockHeight > 0 {
+		currentBlockHeight := view.Height()
+		if currentBlockHeight > reservedFund.StartBlockHeight &&
+			currentBlockHeight-reservedFund.StartBlockHeight > exec.params.MaxReservedFundAge {
+			return result.Error("Reserved fund %v was created at block height %v, too old to be slashed",
+				tx.ReserveSequence, reservedFund.StartBlockHeight).WithErrorCode(result.CodeSlashReservedFundTooOld)
+		}
+	}
+
+	if exec.params.GracePeriod > 0 {
+		slashableBlockHeight := reservedFund.StartBlockHeight + exec.params.GracePeriod
+		if view.Height() < slashableBlockHeight {
+			return result.Error("Reserved fund %v cannot be slashed until block height %v",
+				tx.ReserveSequence, slashableBlockHeight).WithErrorCode(result.CodeSlashReservedFundTooNew)
+		}
+	}
+
+	if exec.params.DisputeWindow > 0 {
+		expiryBlockHeight := reservedFund.MinimumReleaseBlockHeight() + exec.params.DisputeWindow
+		if view.Height() > expiryBlockHeight {
+			return result.Error("Slash proofs against reserved fund %v expired at block height %v",
+				tx.ReserveSequence, expiryBlockHeight).WithErrorCode(result.CodeSlashProofExpired)
+		}
+	}
+
+	validatorAccount := accounts[validatorAddress]
 	if validatorAccount == nil {
-		return result.Error("Validator %v does not exist!", validatorAddress)
+		return result.Error("Validator %v does not exist!", validatorAddress).WithErrorCode(result.CodeSlashAccountNotFound)
+	}
+
+	if !exec.params.Bond.IsZero() && !validatorAccount.Balance.IsGTE(exec.params.Bond) {
+		return result.Error("Insufficient fund: validator balance is %v, but the slash bond is %v",
+			validatorAccount.Balance, exec.params.Bond).WithErrorCode(result.CodeInsufficientFund)
 	}
 
-	overspendingProofBytes := tx.SlashProof
-	slashProofVerified := exec.verifySlashProof(chainID, slashedAccount, overspendingProofBytes)
-	if !slashProofVerified {
-		return result.Error("Invalid slash proof: %v", overspendingProofBytes)
+	fee := exec.getFee(tx)
+	if !fee.IsZero() && !validatorAccount.Balance.IsGTE(exec.params.Bond.Plus(fee)) {
+		return result.Error("Insufficient fund: validator balance is %v, but the slash bond and fee are %v",
+			validatorAccount.Balance, exec.params.Bond.Plus(fee)).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	slashProofBytes, res := getSlashProof(view, tx)
+	if res.IsError() {
+		return withSlashRejection(res, SlashRejectionInvalidProof)
+	}
+	res = checkSlashReason(tx.Reason, slashProofBytes)
+	if res.IsError() {
+		return res
+	}
+
+	// The proof is verified against the snapshot it is bound to, if any, so that the slashed account
+	// cannot dodge the slash by mutating its state after the proof was submitted
+	proofAccount, res := getProofAccount(view, tx)
+	if res.IsError() {
+		return res
+	}
+
+	if tx.Reason == types.SlashReasonChannelRevocation {
+		if !exec.verifyChannelRevocationProof(chainID, proofAccount, tx.ReserveSequence, slashProofBytes) {
+			return withSlashRejection(result.Error("Invalid slash proof: %v", slashProofBytes).
+				WithErrorCode(result.CodeSlashInvalidProof), SlashRejectionInvalidProof)
+		}
+	} else {
+		res = exec.checkReserveTxHash(view, tx.SlashedAddress, tx.ReserveSequence, slashProofBytes)
+		if res.IsError() {
+			return res
+		}
+		res = exec.verifySlashProof(ctx, chainID, view, proofAccount, tx.ReserveSequence, slashProofBytes)
+		if res.Code == result.CodeSlashVerificationAborted {
+			return res
+		}
+		if exec.params.AttestUnderspend && res.Code == result.CodeSlashNotOverspent && view.Height() <= reservedFund.EndBlockHeight {
+			return withSlashRejection(result.Error("Reserved fund %v can settle payments until block height %v, it cannot be "+
+				"attested not to be overspent yet", tx.ReserveSequence, reservedFund.EndBlockHeight).
+				WithErrorCode(result.CodeSlashNotOverspent), SlashRejectionInvalidProof)
+		}
+		if res.IsError() && !(exec.params.AttestUnderspend && res.Code == result.CodeSlashNotOverspent) {
+			return withSlashRejection(res, SlashRejectionInvalidProof)
+		}
+	}
+
+	if exec.params.RejectConflictedProposer && isSlashProofPaymentTarget(slashProofBytes, tx.Proposer.Address) {
+		return result.Error("Proposer %v is a payment target in the slash proof", tx.Proposer.Address).
+			WithErrorCode(result.CodeSlashConflictedProposer)
 	}
 
 	return result.OK
@@ -91,99 +476,314 @@ func (exec *SlashTxExecutor) sanityCheck(chainID string, view *st.StoreView, tra
 func (exec *SlashTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
 	tx := transaction.(*types.SlashTx)
 
+	// All the mutations of an account are applied to a single copy, which is written once. The batch fetch
+	// yields a single copy in case the treasury is the slashed account or the proposer, otherwise writing the
+	// copies separately would make the latter write discard the changes of the former
 	slashedAddress := tx.SlashedAddress
-	slashedAccount := view.GetAccount(slashedAddress)
+	proposerAddress := tx.Proposer.Address
+	treasuryAddress := exec.params.Treasury
+	addrs := []common.Address{slashedAddress, proposerAddress}
+	if exec.params.Split.TreasuryWeight > 0 || exec.params.MaxNativeProposerReward != nil {
+		addrs = append(addrs, treasuryAddress)
+	}
+	accounts := view.GetAccounts(addrs)
+	slashedAccount := accounts[slashedAddress]
+	if slashedAccount == nil {
+		return common.Hash{}, result.Error("Account %v does not exist!", slashedAddress)
+	}
 
-	var reservedFundIdx int
-	var reservedFund types.ReservedFund
-	reservedFundFound := false
-	for reservedFundIdx, reservedFund = range slashedAccount.ReservedFunds {
-		if reservedFund.ReserveSequence == tx.ReserveSequence {
-			reservedFundFound = true
-			break
-		}
+	reservedFund, _, res := findReservedFund(slashedAccount, tx.ReserveSequence)
+	if res.IsError() {
+		return common.Hash{}, res
 	}
+	removedReservedFund := reservedFund.Copy()
 
-	if !reservedFundFound {
-		return common.Hash{}, result.Error("Reserved fund not found for %v", tx.ReserveSequence)
+	slashProofBytes, res := getSlashProof(view, tx)
+	if res.IsError() {
+		return common.Hash{}, res
 	}
 
-	proposerAddress := tx.Proposer.Address
-	proposerAccount := view.GetAccount(proposerAddress)
+	proofAccount, res := getProofAccount(view, tx)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+	proofReservedFund, _, res := findReservedFund(proofAccount, tx.ReserveSequence)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+
+	proposerAccount := accounts[proposerAddress]
 	if proposerAccount == nil {
 		return common.Hash{}, result.Error("Proposer %v does not exist!", proposerAddress)
 	}
+	fee := exec.getFee(tx)
+	if !chargeFee(proposerAccount, fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee")
+	}
 
-	// TODO: We should transfer the collateral to a special address, e.g. 0x0 instead of
-	//       transfering to the proposer, so the proposer gain no extra benefit if it colludes with
-	//       the address that overspent
-
-	// Slash: transfer the collateral and remainding deposit to the validator that identified the overspending
-	remainingFund := reservedFund.InitialFund.Minus(reservedFund.UsedFund)
-	if !remainingFund.IsNonnegative() {
-		remainingFund = types.NewCoins(0, 0) // Should NOT happen, just to be on the safe side
+	if reservedFund.Collateral.IsZero() {
+		exec.logger.Warnf("Reserved fund %v of %v has no collateral, only the remaining fund is slashed",
+			tx.ReserveSequence, slashedAddress.Hex())
 	}
-	slashedAmount := reservedFund.Collateral.Plus(remainingFund)
 
-	proposerAccount.Balance = proposerAccount.Balance.Plus(slashedAmount)
-	slashedAccount.ReservedFunds = append(slashedAccount.ReservedFunds[:reservedFundIdx],
-		slashedAccount.ReservedFunds[reservedFundIdx+1:]...)
+	// Slash: split the collateral and remainding deposit among the burn, the treasury, and the validator that
+	// identified the overspending
+	distribution, overspent, res := exec.calcSlashDistribution(slashedAddress, tx.Reason, reservedFund, proofReservedFund, slashProofBytes)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+	if !overspent {
+		// Only passes the sanity check with the underspend attestation enabled
+		view.SetReservedFundValidation(slashedAddress, tx.ReserveSequence, view.Height())
+		view.SetAccount(proposerAddress, proposerAccount)
+		if !fee.IsZero() {
+			view.AddSlashBurntSupply(fee)
+		}
+		return tx.ID(chainID), result.OKWith(result.Info{"validated": true})
+	}
+	slashedAmount, returnedAmount := distribution.SlashedAmount, distribution.ReturnedAmount
+	burntAmount, treasuryAmount, proposerAmount := distribution.BurntAmount, distribution.TreasuryAmount, distribution.ProposerAmount
+	burntRemainder := distribution.BurntRemainder
+	if !treasuryAmount.IsZero() && accounts[treasuryAddress] == nil && exec.params.BurnIfTreasuryMissing {
+		burntAmount = burntAmount.Plus(treasuryAmount)
+		treasuryAmount = types.NewCoins(0, 0)
+	}
 
-	view.SetAccount(proposerAddress, proposerAccount)
-	view.SetAccount(slashedAddress, slashedAccount)
+	var treasuryAccount *types.Account
+	if !treasuryAmount.IsZero() {
+		treasuryAccount = accounts[treasuryAddress]
+		if treasuryAccount == nil {
+			treasuryAccount = getOrMakeAccount(view, treasuryAddress)
+		}
+		treasuryAccount.Balance = treasuryAccount.Balance.Plus(treasuryAmount)
+	}
 
-	txHash := types.TxID(chainID, tx)
-	return txHash, result.OK
-}
+	// The proposer only gets its share of the reward shared among the validators, the others are credited
+	// once the accounts involved in the slash are saved
+	var validatorRewards []ValidatorReward
+	if exec.params.ShareRewardWithValidators && !proposerAmount.IsZero() {
+		validatorRewards, res = exec.shareSlashReward(view, proposerAmount)
+		if res.IsError() {
+			return common.Hash{}, res
+		}
+		proposerAmount = types.NewCoins(0, 0)
+		for _, reward := range validatorRewards {
+			if reward.Address == proposerAddress {
+				proposerAmount = reward.Amount
+			}
+		}
+	}
 
-func (exec *SlashTxExecutor) verifySlashProof(chainID string, slashedAccount *types.Account, overspendingProofBytes []byte) bool {
-	var overspendingProof types.OverspendingProof
-	err := types.FromBytes(overspendingProofBytes, &overspendingProof)
-	if err != nil {
-		// TODO: need proper logging and error handling here.
-		//panic(fmt.Sprintf("Failed to parse overspending proof: %v\n", err))
-		logger.Errorf("Failed to parse overspending proof: %v", err)
-		return false
+	txHash := tx.ID(chainID)
+	if exec.params.RewardVestingDuration > 0 {
+		currentBlockHeight := view.Height()
+		view.SetSlashRewardVesting(txHash, &types.SlashRewardVesting{
+			Beneficiary:      proposerAddress,
+			Amount:           proposerAmount,
+			Released:         types.NewCoins(0, 0),
+			StartBlockHeight: currentBlockHeight,
+			EndBlockHeight:   currentBlockHeight + exec.params.RewardVestingDuration,
+		})
+	} else if exec.params.BatchRewardPayout {
+		if !proposerAmount.IsZero() {
+			view.AddPendingSlashReward(proposerAddress, proposerAmount)
+		}
+	} else {
+		proposerAccount.Balance = proposerAccount.Balance.Plus(proposerAmount)
+	}
+	if !exec.params.Bond.IsZero() {
+		proposerAccount.Balance = proposerAccount.Balance.Plus(exec.params.Bond.Negate())
+		view.SetSlashBond(txHash, &types.SlashBond{
+			Proposer:          proposerAddress,
+			Amount:            exec.params.Bond,
+			ReturnBlockHeight: view.Height() + exec.params.BondLockPeriod,
+		})
 	}
+	slashedAccount.Balance = slashedAccount.Balance.Plus(returnedAmount)
 
-	slashedAddress := slashedAccount.Address
-	reserveSequence := overspendingProof.ReserveSequence
-	for _, reservedFund := range slashedAccount.ReservedFunds {
-		if reservedFund.ReserveSequence != reserveSequence {
+	view.SetAccount(slashedAddress, slashedAccount)
+	view.SetAccount(proposerAddress, proposerAccount)
+	if treasuryAccount != nil && treasuryAddress != slashedAddress && treasuryAddress != proposerAddress {
+		view.SetAccount(treasuryAddress, treasuryAccount)
+	}
+	for _, reward := range validatorRewards {
+		if reward.Address == proposerAddress || reward.Amount.IsZero() {
 			continue
 		}
+		if exec.params.BatchRewardPayout {
+			view.AddPendingSlashReward(reward.Address, reward.Amount)
+			continue
+		}
+		validatorAccount := getOrMakeAccount(view, reward.Address)
+		validatorAccount.Balance = validatorAccount.Balance.Plus(reward.Amount)
+		view.SetAccount(reward.Address, validatorAccount)
+	}
+	if res := view.RemoveReservedFund(slashedAddress, tx.ReserveSequence); res.IsError() {
+		return common.Hash{}, res
+	}
+	view.SetSlashedProofHash(slashedAddress, tx.ReserveSequence, crypto.Keccak256Hash(slashProofBytes))
+	if totalBurnt := burntAmount.Plus(burntRemainder).Plus(fee); !totalBurnt.IsZero() {
+		view.AddSlashBurntSupply(totalBurnt)
+	}
 
-		settledPaymentLookup := make(map[string]bool)
-		fundIntendedToSpend := types.NewCoins(0, 0)
-		for _, servicePaymentTx := range overspendingProof.ServicePayments {
-			if slashedAddress != servicePaymentTx.Source.Address {
-				return false // servicePaymentTx does not come from the slashed account
-			}
+	if exec.params.FlagStatus != types.AccountSlashStatusNone {
+		flag := &types.AccountSlashFlag{Status: exec.params.FlagStatus, Reason: tx.Reason}
+		if exec.params.FlagDuration > 0 {
+			flag.ClearBlockHeight = view.Height() + exec.params.FlagDuration
+		}
+		view.SetAccountSlashFlag(slashedAddress, flag)
+	}
 
-			if servicePaymentTx.ReserveSequence != overspendingProof.ReserveSequence {
-				return false // servicePaymentTx does not belong to claimed reserved fund
-			}
+	// The reserved fund is gone, so is the evidence accumulated against it
+	view.DeleteSlashEvidence(slashedAddress, tx.ReserveSequence)
+	view.DeleteReservedFundValidation(slashedAddress, tx.ReserveSequence)
+	view.DeleteReserveFundTxHash(slashedAddress, tx.ReserveSequence)
+	view.DeleteExpiredSlashEvidences(view.Height())
 
-			sourceSignedBytes := servicePaymentTx.SourceSignBytes(chainID)
-			if !servicePaymentTx.Source.Signature.Verify(sourceSignedBytes, slashedAccount.Address) {
-				return false // servicePaymentTx not signed by the slashed account
-			}
+	if exec.slashMetrics != nil {
+		exec.slashMetrics.SlashSucceeded(slashedAmount)
+	}
+	exec.logger.WithFields(log.Fields{
+		"txHash":          txHash.Hex(),
+		"slashedAddress":  slashedAddress.Hex(),
+		"proposer":        proposerAddress.Hex(),
+		"reserveSequence": tx.ReserveSequence,
+		"slashReason":     tx.Reason,
+		"slashedAmount":   slashedAmount.String(),
+		"returnedAmount":  returnedAmount.String(),
+		"burntAmount":     burntAmount.String(),
+		"treasuryAmount":  treasuryAmount.String(),
+		"proposerAmount":  proposerAmount.String(),
+	}).Info("Slashed the reserved fund")
+
+	view.AddEvent(&types.SlashEvent{
+		TxHash:          txHash,
+		SlashedAddress:  slashedAddress,
+		ProposerAddress: proposerAddress,
+		ReserveSequence: tx.ReserveSequence,
+		Reason:          tx.Reason,
+		SlashedAmount:   slashedAmount,
+		ReturnedAmount:  returnedAmount,
+		BurntAmount:     burntAmount,
+		TreasuryAmount:  treasuryAmount,
+		ProposerAmount:  proposerAmount,
+		Treasury:        treasuryAddress,
+		RewardVested:    exec.params.RewardVestingDuration > 0,
+	})
+
+	return txHash, result.OKWith(result.Info{
+		"slashed_amount":        slashedAmount,
+		"returned_amount":       returnedAmount,
+		"burnt_amount":          burntAmount,
+		"treasury_amount":       treasuryAmount,
+		"slash_reason":          tx.Reason,
+		"removed_reserved_fund": removedReservedFund,
+		"validator_rewards":     validatorRewards,
+	})
+}
 
-			paymentKey := string(servicePaymentTx.Target.Address[:]) + "." + string(servicePaymentTx.PaymentSequence)
-			_, targetExists := settledPaymentLookup[paymentKey]
-			if targetExists {
-				return false // to prevent using partial payments as proof
-			}
-			settledPaymentLookup[paymentKey] = true
+// RemovedReservedFund returns the snapshot of the reserved fund a SlashTx removed, as it was right before
+// the slash, from the result of its execution. It returns nil if the SlashTx did not remove any
+func RemovedReservedFund(res result.Result) *types.ReservedFund {
+	reservedFund, _ := res.Info["removed_reserved_fund"].(*types.ReservedFund)
+	return reservedFund
+}
 
-			fundIntendedToSpend = fundIntendedToSpend.Plus(servicePaymentTx.Source.Coins)
-		}
+// NewSlashDecision records the outcome of an executed SlashTx from the result of its execution
+func NewSlashDecision(tx *types.SlashTx, blockHeight uint64, res result.Result) (*types.SlashDecision, error) {
+	if res.IsError() {
+		return nil, errors.Errorf("The SlashTx failed: %v", res.Message)
+	}
+	slashedAmount, ok := res.Info["slashed_amount"].(types.Coins)
+	if !ok {
+		return nil, errors.New("The SlashTx did not slash the reserved fund")
+	}
+	returnedAmount, _ := res.Info["returned_amount"].(types.Coins)
+	return &types.SlashDecision{
+		SlashTx:        *tx,
+		BlockHeight:    blockHeight,
+		SlashedAmount:  slashedAmount.NoNil(),
+		ReturnedAmount: returnedAmount.NoNil(),
+	}, nil
+}
+
+// ReplaySlashDecision replays the SlashTx of the decision against the given view, which should reflect the
+// state the SlashTx was executed against, and verifies the replay reproduces the recorded outcome. The view
+// is mutated by the replay, callers would typically pass a copy
+func (exec *SlashTxExecutor) ReplaySlashDecision(chainID string, view *st.StoreView, decision *types.SlashDecision) result.Result {
+	if view.Height() != decision.BlockHeight {
+		return result.Error("The slash decision was made at block height %v, cannot replay at %v",
+			decision.BlockHeight, view.Height())
+	}
+
+	tx := &decision.SlashTx
+	res := exec.sanityCheck(chainID, view, tx)
+	if res.IsError() {
+		return res
+	}
+	_, res = exec.process(chainID, view, tx)
+	replayed, err := NewSlashDecision(tx, view.Height(), res)
+	if err != nil {
+		return result.Error("Failed to replay the slash decision: %v", err)
+	}
+
+	if !replayed.SlashedAmount.IsEqual(decision.SlashedAmount.NoNil()) ||
+		!replayed.ReturnedAmount.IsEqual(decision.ReturnedAmount.NoNil()) {
+		return result.Error("The replayed slash does not match the decision: slashed %v, returned %v, expected slashed %v, returned %v",
+			replayed.SlashedAmount, replayed.ReturnedAmount, decision.SlashedAmount, decision.ReturnedAmount)
+	}
+	return result.OK
+}
+
+// PayoutPendingSlashRewards credits the slash rewards accumulated during the block to the proposers, one
+// credit per proposer, and clears them. It is called at the end of the block, before the state root is
+// computed. The proposers are credited in the order of their addresses, so that all the nodes apply the
+// same writes
+func PayoutPendingSlashRewards(view *st.StoreView) map[common.Address]types.Coins {
+	rewards := view.GetPendingSlashRewards()
+	proposers := make([]common.Address, 0, len(rewards))
+	for proposer := range rewards {
+		proposers = append(proposers, proposer)
+	}
+	sort.Slice(proposers, func(i, j int) bool {
+		return bytes.Compare(proposers[i][:], proposers[j][:]) < 0
+	})
+
+	for _, proposer := range proposers {
+		proposerAccount := view.GetOrCreateAccount(proposer)
+		proposerAccount.Balance = proposerAccount.Balance.Plus(rewards[proposer])
+		view.SetAccount(proposer, proposerAccount)
+		view.DeletePendingSlashReward(proposer)
+	}
+	return rewards
+}
 
-		fundOverspent := !reservedFund.InitialFund.IsGTE(fundIntendedToSpend)
-		return fundOverspent
+// ForfeitSlashBond forfeits the bond locked by the proposer of the given SlashTx, for when the slash turns
+// out to be bad. The forfeited bond is burnt. It fails if the bond has already been returned.
+func ForfeitSlashBond(view *st.StoreView, slashTxHash common.Hash) (types.Coins, result.Result) {
+	bond := view.GetSlashBond(slashTxHash)
+	if bond == nil {
+		return types.Coins{}, result.Error("No bond locked for slash %v", slashTxHash.Hex())
 	}
+	view.DeleteSlashBond(slashTxHash)
+	view.AddSlashBurntSupply(bond.Amount)
+	return bond.Amount, result.OK
+}
 
-	return false
+// WouldAcceptProof tells whether the overspending proof against the slashed account would currently be
+// accepted, along with a human-readable reason, so tooling can validate a proof without assembling a SlashTx.
+// The reserved fund is the one the proof covers. Only the proof is verified, the conditions concerning the
+// SlashTx as a whole, e.g. its proposer and the slashable window of the reserved fund, are not checked
+func (exec *SlashTxExecutor) WouldAcceptProof(chainID string, view *st.StoreView, slashedAddress common.Address, proofBytes []byte) (bool, string) {
+	res := exec.VerifyProof(chainID, view, slashedAddress, proofBytes)
+	if res.IsError() {
+		return false, res.Message
+	}
+	overspendingProof, err := decodeOverspendingProof(proofBytes)
+	if err != nil {
+		return false, invalidSlashProofEncoding(err).Message
+	}
+	return true, fmt.Sprintf("Reserved fund %v of %v is overspent", overspendingProof.ReserveSequence, slashedAddress)
 }
 
 func (exec *SlashTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
@@ -195,6 +795,10 @@ func (exec *SlashTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
 	}
 }
 
+func (exec *SlashTxExecutor) getFee(transaction types.Tx) types.Coins {
+	return exec.params.Fee.NoNil()
+}
+
 func (exec *SlashTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	return new(big.Int).SetUint64(0)
 }
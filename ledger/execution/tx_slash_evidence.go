@@ -0,0 +1,168 @@
+package execution
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+var _ TxExecutor = (*SlashEvidenceTxExecutor)(nil)
+
+// ------------------------------- SlashEvidence Transaction -----------------------------------
+
+// SlashEvidenceTxExecutor implements the TxExecutor interface. It accumulates the service payments
+// submitted against a reserved fund, which can later be consumed by a SlashTx as the overspending proof
+type SlashEvidenceTxExecutor struct {
+	slashTxExec *SlashTxExecutor
+}
+
+// NewSlashEvidenceTxExecutor creates a new instance of SlashEvidenceTxExecutor
+func NewSlashEvidenceTxExecutor(slashTxExec *SlashTxExecutor) *SlashEvidenceTxExecutor {
+	return &SlashEvidenceTxExecutor{
+		slashTxExec: slashTxExec,
+	}
+}
+
+func (exec *SlashEvidenceTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.SlashEvidenceTx)
+
+	// Validate submitter, basic
+	res := tx.Submitter.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// Get input account
+	submitterAccount, res := getInput(view, tx.Submitter)
+	if res.IsError() {
+		return result.Error("Unknown address: %v", tx.Submitter.Address)
+	}
+
+	// Validate input, advanced
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(submitterAccount, signBytes, tx.Submitter)
+	if res.IsError() {
+		logger.Warnf(fmt.Sprintf("validateSourceAdvanced failed on %v: %v", tx.Submitter.Address.Hex(), res))
+		return res
+	}
+
+	if !sanityCheckForFee(tx.Fee) {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v TFuelWei",
+			types.MinimumTransactionFeeTFuelWei).WithErrorCode(result.CodeInvalidFee)
+	}
+
+	minimalBalance := tx.Fee
+	if !submitterAccount.Balance.IsGTE(minimalBalance) {
+		logger.Infof(fmt.Sprintf("Submitter did not have enough balance %v", tx.Submitter.Address.Hex()))
+		return result.Error("Submitter balance is %v, but required minimal balance is %v",
+			submitterAccount.Balance, minimalBalance).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	if len(tx.ServicePayments) == 0 {
+		return result.Error("No service payment submitted as slash evidence")
+	}
+
+	slashedAccount := view.GetAccount(tx.SlashedAddress)
+	if slashedAccount == nil {
+		return result.Error("Account %v does not exist!", tx.SlashedAddress)
+	}
+
+	_, _, res = findReservedFund(slashedAccount, tx.ReserveSequence)
+	if res.IsError() {
+		return res
+	}
+
+	submittedPaymentLookup := make(map[types.SettlementKey]bool)
+	evidence := getUnexpiredSlashEvidence(view, tx.SlashedAddress, tx.ReserveSequence)
+	if evidence != nil {
+		for _, servicePaymentTx := range evidence.ServicePayments {
+			submittedPaymentLookup[types.GetSettlementKey(&servicePaymentTx)] = true
+		}
+	}
+
+	for idx := range tx.ServicePayments {
+		servicePaymentTx := &tx.ServicePayments[idx]
+		res = verifySlashedServicePayment(chainID, tx.SlashedAddress, tx.ReserveSequence, servicePaymentTx)
+		if res.IsError() {
+			return res
+		}
+
+		paymentKey := types.GetSettlementKey(servicePaymentTx)
+		if submittedPaymentLookup[paymentKey] {
+			return result.Error("Service payment already submitted as slash evidence: %v", servicePaymentTx)
+		}
+		submittedPaymentLookup[paymentKey] = true
+	}
+
+	return result.OK
+}
+
+func (exec *SlashEvidenceTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.SlashEvidenceTx)
+
+	submitterAccount, res := getInput(view, tx.Submitter)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+
+	currentBlockHeight := view.Height()
+	view.DeleteExpiredSlashEvidences(currentBlockHeight)
+
+	evidence := view.GetSlashEvidence(tx.SlashedAddress, tx.ReserveSequence)
+	if evidence == nil {
+		evidence = &types.SlashEvidence{
+			Address:         tx.SlashedAddress,
+			ReserveSequence: tx.ReserveSequence,
+			EndBlockHeight:  currentBlockHeight + exec.slashTxExec.params.SlashEvidenceExpiryDuration,
+		}
+	}
+	evidence.ServicePayments = append(evidence.ServicePayments, tx.ServicePayments...)
+	view.SetSlashEvidence(evidence)
+
+	if !chargeFee(submitterAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee")
+	}
+
+	submitterAccount.Sequence++
+	view.SetAccount(tx.Submitter.Address, submitterAccount)
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+// getUnexpiredSlashEvidence returns the slash evidence accumulated against the given reserved fund, or nil
+// if there is none or it has expired
+func getUnexpiredSlashEvidence(view *st.StoreView, addr common.Address, reserveSequence uint64) *types.SlashEvidence {
+	evidence := view.GetSlashEvidence(addr, reserveSequence)
+	if evidence == nil || evidence.EndBlockHeight < view.Height() {
+		return nil
+	}
+	return evidence
+}
+
+func (exec *SlashEvidenceTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.SlashEvidenceTx)
+	return &core.TxInfo{
+		Address:           tx.Submitter.Address,
+		Sequence:          tx.Submitter.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *SlashEvidenceTxExecutor) getFee(transaction types.Tx) types.Coins {
+	tx := transaction.(*types.SlashEvidenceTx)
+	return tx.Fee
+}
+
+func (exec *SlashEvidenceTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.SlashEvidenceTx)
+	fee := tx.Fee
+	gas := new(big.Int).SetUint64(types.GasSlashEvidenceTx)
+	effectiveGasPrice := new(big.Int).Div(fee.TFuelWei, gas)
+	return effectiveGasPrice
+}
@@ -127,6 +127,18 @@ func (exec *SmartContractTxExecutor) getTxInfo(transaction types.Tx) *core.TxInf
 	}
 }
 
+// getFee returns the fee limit, the actual fee depends on the gas used
+func (exec *SmartContractTxExecutor) getFee(transaction types.Tx) types.Coins {
+	tx := transaction.(*types.SmartContractTx)
+	if tx.GasPrice == nil {
+		return types.NewCoins(0, 0)
+	}
+	return types.Coins{
+		ThetaWei: big.NewInt(0),
+		TFuelWei: new(big.Int).Mul(tx.GasPrice, new(big.Int).SetUint64(tx.GasLimit)),
+	}
+}
+
 func (exec *SmartContractTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	tx := transaction.(*types.SmartContractTx)
 	return tx.GasPrice
@@ -151,6 +151,11 @@ func (exec *SplitRuleTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
 	}
 }
 
+func (exec *SplitRuleTxExecutor) getFee(transaction types.Tx) types.Coins {
+	tx := transaction.(*types.SplitRuleTx)
+	return tx.Fee
+}
+
 func (exec *SplitRuleTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	tx := transaction.(*types.SplitRuleTx)
 	fee := tx.Fee
@@ -122,6 +122,11 @@ func (exec *WithdrawStakeExecutor) getTxInfo(transaction types.Tx) *core.TxInfo
 	}
 }
 
+func (exec *WithdrawStakeExecutor) getFee(transaction types.Tx) types.Coins {
+	tx := transaction.(*types.WithdrawStakeTx)
+	return tx.Fee
+}
+
 func (exec *WithdrawStakeExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	tx := transaction.(*types.WithdrawStakeTx)
 	fee := tx.Fee
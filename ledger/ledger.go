@@ -1,6 +1,7 @@
 package ledger
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"strconv"
@@ -43,10 +44,13 @@ type Ledger struct {
 	executor *exec.Executor
 }
 
-// NewLedger creates an instance of Ledger
-func NewLedger(chainID string, db database.Database, chain *blockchain.Chain, consensus core.ConsensusEngine, valMgr core.ValidatorManager, mempool *mp.Mempool) *Ledger {
+// NewLedger creates an instance of Ledger. It returns an error if the executor dependencies are not wired up
+func NewLedger(chainID string, db database.Database, chain *blockchain.Chain, consensus core.ConsensusEngine, valMgr core.ValidatorManager, mempool *mp.Mempool) (*Ledger, error) {
 	state := st.NewLedgerState(chainID, db)
 	executor := exec.NewExecutor(state, consensus, valMgr)
+	if err := executor.ValidateWiring(); err != nil {
+		return nil, err
+	}
 	ledger := &Ledger{
 		chain:     chain,
 		consensus: consensus,
@@ -56,7 +60,7 @@ func NewLedger(chainID string, db database.Database, chain *blockchain.Chain, co
 		state:     state,
 		executor:  executor,
 	}
-	return ledger
+	return ledger, nil
 }
 
 // State returns the state of the ledger
@@ -159,9 +163,23 @@ func (ledger *Ledger) ScreenTx(rawTx common.Bytes) (txInfo *core.TxInfo, res res
 	return txInfo, res
 }
 
+// SimulateTx predicts the outcome of executing the given transaction against the delivered state, without
+// mutating the state
+func (ledger *Ledger) SimulateTx(rawTx common.Bytes) (*exec.SimulationResult, error) {
+	tx, err := types.TxFromBytes(rawTx)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger.mu.RLock()
+	defer ledger.mu.RUnlock()
+
+	return ledger.executor.SimulateTx(ledger.state.Delivered(), tx)
+}
+
 // ProposeBlockTxs collects and executes a list of transactions, which will be used to assemble the next blockl
 // It also clears these transactions from the mempool.
-func (ledger *Ledger) ProposeBlockTxs() (stateRootHash common.Hash, blockRawTxs []common.Bytes, res result.Result) {
+func (ledger *Ledger) ProposeBlockTxs(block *core.Block) (stateRootHash common.Hash, blockRawTxs []common.Bytes, res result.Result) {
 	// Must always acquire locks in following order to avoid deadlock: mempool, ledger.
 	// Otherwise, could cause deadlock since mempool.InsertTransaction() also first acquires the mempool, and then the ledger lock
 	ledger.mempool.Lock()
@@ -171,10 +189,13 @@ func (ledger *Ledger) ProposeBlockTxs() (stateRootHash common.Hash, blockRawTxs
 	defer ledger.mu.Unlock()
 
 	view := ledger.state.Checked()
+	view.SetBlockTimestamp(block.Timestamp)
+	view.SetParentBlockHash(block.Parent)
 
 	// Add special transactions
 	rawTxCandidates := []common.Bytes{}
-	ledger.addSpecialTransactions(view, &rawTxCandidates)
+	ledger.addSpecialTransactions(block, view, &rawTxCandidates)
+	numSpecialTxs := len(rawTxCandidates)
 
 	// Add regular transactions submitted by the clients
 	regularRawTxs := ledger.mempool.ReapUnsafe(core.MaxNumRegularTxsPerBlock)
@@ -183,12 +204,21 @@ func (ledger *Ledger) ProposeBlockTxs() (stateRootHash common.Hash, blockRawTxs
 	}
 
 	blockRawTxs = []common.Bytes{}
-	for _, rawTxCandidate := range rawTxCandidates {
+	for idx, rawTxCandidate := range rawTxCandidates {
 		tx, err := types.TxFromBytes(rawTxCandidate)
 		if err != nil {
 			continue
 		}
 		_, res := ledger.executor.CheckTx(tx)
+		if res.IsRetryable() {
+			logger.Infof("Transaction deferred: errMsg = %v, tx = %v", res.Message, tx)
+			if idx < numSpecialTxs {
+				ledger.deferSpecialTransaction(view, tx)
+			} else {
+				ledger.requeueTransaction(tx, rawTxCandidate)
+			}
+			continue
+		}
 		if res.IsError() {
 			logger.Errorf("Transaction check failed: errMsg = %v, tx = %v", res.Message, tx)
 			continue
@@ -203,10 +233,43 @@ func (ledger *Ledger) ProposeBlockTxs() (stateRootHash common.Hash, blockRawTxs
 	return stateRootHash, blockRawTxs, result.OK
 }
 
-// ApplyBlockTxs applies the given block transactions. If any of the transactions failed, it returns
+// requeueTransaction puts a transaction reaped from the mempool which cannot be included in the current block
+// back into the mempool, so that it can be included in a later block
+func (ledger *Ledger) requeueTransaction(tx types.Tx, rawTx common.Bytes) {
+	if ledger.mempool == nil {
+		return
+	}
+	txInfo, res := ledger.executor.GetTxInfo(tx)
+	if res.IsError() {
+		logger.Warnf("Failed to requeue the transaction: errMsg = %v, tx = %v", res.Message, tx)
+		return
+	}
+	ledger.mempool.RequeueUnsafe(rawTx, txInfo)
+}
+
+// deferSpecialTransaction keeps a special transaction which cannot be included in the current block for a later
+// block. The special transactions are built by the proposer rather than reaped from the mempool, hence they are
+// not put into the mempool: the slash intent of a SlashTx is put back, so that the SlashTx is built again for the
+// next block, and a CoinbaseTx is dropped, since the next block comes with its own
+func (ledger *Ledger) deferSpecialTransaction(view *st.StoreView, tx types.Tx) {
+	slashTx, ok := tx.(*types.SlashTx)
+	if !ok {
+		return
+	}
+	view.AddSlashIntent(types.SlashIntent{
+		Address:         slashTx.SlashedAddress,
+		ReserveSequence: slashTx.ReserveSequence,
+		Proof:           slashTx.SlashProof,
+	})
+}
+
+// ApplyBlockTxs applies the transactions of the given block. If any of the transactions failed, it returns
 // an error immediately. If all the transactions execute successfully, it then validates the state
-// root hash. If the states root hash matches the expected value, it clears the transactions from the mempool
-func (ledger *Ledger) ApplyBlockTxs(blockRawTxs []common.Bytes, expectedStateRoot common.Hash) result.Result {
+// root hash against the one of the block. If the states root hash matches, it clears the transactions from the mempool
+func (ledger *Ledger) ApplyBlockTxs(block *core.Block) result.Result {
+	blockRawTxs := block.Txs
+	expectedStateRoot := block.StateHash
+
 	// Must always acquire locks in following order to avoid deadlock: mempool, ledger.
 	// Otherwise, could cause deadlock since mempool.InsertTransaction() also first acquires the mempool, and then the ledger lock
 	ledger.mempool.Lock()
@@ -219,6 +282,8 @@ func (ledger *Ledger) ApplyBlockTxs(blockRawTxs []common.Bytes, expectedStateRoo
 
 	currHeight := view.Height()
 	currStateRoot := view.Hash()
+	view.SetBlockTimestamp(block.Timestamp)
+	view.SetParentBlockHash(block.Parent)
 
 	hasValidatorUpdate := false
 	for _, rawTx := range blockRawTxs {
@@ -249,11 +314,15 @@ func (ledger *Ledger) ApplyBlockTxs(blockRawTxs []common.Bytes, expectedStateRoo
 			hex.EncodeToString(expectedStateRoot[:]))
 	}
 
+	// The events of the block are handed over to the caller, e.g. for the indexers
+	events := view.GetEvents()
+	view.ClearEvents()
+
 	ledger.state.Commit() // commit to persistent storage
 
 	ledger.mempool.UpdateUnsafe(blockRawTxs) // clear txs from the mempool
 
-	return result.OKWith(result.Info{"hasValidatorUpdate": hasValidatorUpdate})
+	return result.OKWith(result.Info{"hasValidatorUpdate": hasValidatorUpdate, "events": events})
 }
 
 // PruneState attempts to prune the state up to the targetEndHeight
@@ -404,14 +473,18 @@ func (ledger *Ledger) resetState(height uint64, rootHash common.Hash) result.Res
 }
 
 // CheckTx() should skip all the transactions that can only be initiated by the validators
-// i.e., if a regular user submits a coinbaseTx or slashTx, it should be skipped so it will not
-// get into the mempool
+// i.e., if a regular user submits a coinbaseTx, slashTx, batchSlashTx or multiFundSlashTx, it should be skipped so it
+// will not get into the mempool
 func (ledger *Ledger) shouldSkipCheckTx(tx types.Tx) bool {
 	switch tx.(type) {
 	case *types.CoinbaseTx:
 		return true
 	case *types.SlashTx:
 		return true
+	case *types.BatchSlashTx:
+		return true
+	case *types.MultiFundSlashTx:
+		return true
 	default:
 		return false
 	}
@@ -421,6 +494,9 @@ func (ledger *Ledger) shouldSkipCheckTx(tx types.Tx) bool {
 // is returned only after X blocks of its corresponding StakeWithdraw transaction
 func (ledger *Ledger) handleDelayedStateUpdates(view *st.StoreView) {
 	ledger.handleStakeReturn(view)
+	ledger.handleSlashRewardVesting(view)
+	ledger.handleSlashBondReturn(view)
+	exec.PayoutPendingSlashRewards(view)
 }
 
 func (ledger *Ledger) handleStakeReturn(view *st.StoreView) {
@@ -452,12 +528,63 @@ func (ledger *Ledger) handleStakeReturn(view *st.StoreView) {
 	view.UpdateValidatorCandidatePool(vcp)
 }
 
-// addSpecialTransactions adds special transactions (e.g. coinbase transaction, slash transaction) to the block
-func (ledger *Ledger) addSpecialTransactions(view *st.StoreView, rawTxs *[]common.Bytes) {
-	extBlk := ledger.consensus.GetLastFinalizedBlock()
-	epoch := ledger.consensus.GetEpoch()
-	proposer := ledger.valMgr.GetProposer(extBlk.Hash(), epoch)
-	validators := ledger.valMgr.GetValidatorSet(extBlk.Hash()).Validators()
+// handleSlashRewardVesting credits the vested part of the slash rewards to the beneficiaries, and
+// removes the fully vested records
+func (ledger *Ledger) handleSlashRewardVesting(view *st.StoreView) {
+	currentHeight := view.Height()
+	vestings := view.GetSlashRewardVestings()
+
+	for slashTxHash, vesting := range vestings {
+		vestedAmount := vesting.VestedAmount(currentHeight)
+		releasedAmount := vestedAmount.Minus(vesting.Released)
+		if !releasedAmount.IsZero() {
+			beneficiaryAccount := view.GetOrCreateAccount(vesting.Beneficiary)
+			beneficiaryAccount.Balance = beneficiaryAccount.Balance.Plus(releasedAmount)
+			view.SetAccount(vesting.Beneficiary, beneficiaryAccount)
+		}
+
+		if vesting.IsMatured(currentHeight) {
+			view.DeleteSlashRewardVesting(slashTxHash)
+		} else {
+			vesting.Released = vestedAmount
+			view.SetSlashRewardVesting(slashTxHash, vesting)
+		}
+	}
+}
+
+// handleSlashBondReturn returns the slash bonds that were not forfeited to the proposers once
+// their lock period is over
+func (ledger *Ledger) handleSlashBondReturn(view *st.StoreView) {
+	currentHeight := view.Height()
+	bonds := view.GetSlashBonds()
+
+	for slashTxHash, bond := range bonds {
+		if bond.ReturnBlockHeight > currentHeight {
+			continue
+		}
+		proposerAccount := view.GetOrCreateAccount(bond.Proposer)
+		proposerAccount.Balance = proposerAccount.Balance.Plus(bond.Amount)
+		view.SetAccount(bond.Proposer, proposerAccount)
+		view.DeleteSlashBond(slashTxHash)
+	}
+}
+
+// addSpecialTransactions adds special transactions (e.g. coinbase transaction, slash transaction) to the block.
+// The proposer and the validators are those the validator manager selects for a block on top of the parent block,
+// which the special transactions are checked against (see exec.NewValidatorSetProvider). A block without a parent
+// falls back to the last finalized block
+func (ledger *Ledger) addSpecialTransactions(block *core.Block, view *st.StoreView, rawTxs *[]common.Bytes) {
+	var proposer core.Validator
+	var validators []core.Validator
+	if !block.Parent.IsEmpty() {
+		proposer = ledger.valMgr.GetNextProposer(block.Parent, block.Epoch)
+		validators = ledger.valMgr.GetNextValidatorSet(block.Parent).Validators()
+	} else {
+		extBlk := ledger.consensus.GetLastFinalizedBlock()
+		epoch := ledger.consensus.GetEpoch()
+		proposer = ledger.valMgr.GetProposer(extBlk.Hash(), epoch)
+		validators = ledger.valMgr.GetValidatorSet(extBlk.Hash()).Validators()
+	}
 
 	ledger.addCoinbaseTx(view, &proposer, &validators, rawTxs)
 	ledger.addSlashTxs(view, &proposer, &validators, rawTxs)
@@ -509,20 +636,23 @@ func (ledger *Ledger) addCoinbaseTx(view *st.StoreView, proposer *core.Validator
 	logger.Debugf("Adding coinbase transction: tx: %v, bytes: %v", coinbaseTx, hex.EncodeToString(coinbaseTxBytes))
 }
 
-// addsSlashTx adds Slash transactions
+// addsSlashTx adds Slash transactions. At most one slash transaction per reserved fund, i.e. per
+// (address, reserve sequence), is added to a block, since only the first one could succeed. See
+// selectSlashIntents for how the slash intent is picked
 func (ledger *Ledger) addSlashTxs(view *st.StoreView, proposer *core.Validator, validators *[]core.Validator, rawTxs *[]common.Bytes) {
 	proposerAddress := proposer.Address
 	proposerTxIn := types.TxInput{
 		Address: proposerAddress,
 	}
 
-	slashIntents := view.GetSlashIntents()
+	slashIntents := selectSlashIntents(view.GetSlashIntents())
 	for _, slashIntent := range slashIntents {
 		slashTx := &types.SlashTx{
 			Proposer:        proposerTxIn,
 			SlashedAddress:  slashIntent.Address,
 			ReserveSequence: slashIntent.ReserveSequence,
 			SlashProof:      slashIntent.Proof,
+			Reason:          types.SlashReasonOverspending,
 		}
 
 		signature, err := ledger.signTransaction(slashTx)
@@ -543,6 +673,33 @@ func (ledger *Ledger) addSlashTxs(view *st.StoreView, proposer *core.Validator,
 	view.ClearSlashIntents()
 }
 
+// selectSlashIntents picks one slash intent per reserved fund. Among the intents against the same reserved
+// fund, the one with the lowest proof hash wins, so that nodes holding the same set of intents agree on the
+// winner regardless of the order they received them in, or which of them proposes the block. The selected
+// intents are kept in the order their reserved funds first appear
+func selectSlashIntents(slashIntents []types.SlashIntent) []types.SlashIntent {
+	selected := []types.SlashIntent{}
+	selectedIdx := make(map[string]int)
+	for _, slashIntent := range slashIntents {
+		slashKey := slashIntent.Address.Hex() + "/" + strconv.FormatUint(slashIntent.ReserveSequence, 10)
+		idx, ok := selectedIdx[slashKey]
+		if !ok {
+			selectedIdx[slashKey] = len(selected)
+			selected = append(selected, slashIntent)
+			continue
+		}
+
+		logger.Debugf("Multiple slash intents against reserved fund: address = %v, reserveSequence = %v",
+			slashIntent.Address.Hex(), slashIntent.ReserveSequence)
+		proofHash := crypto.Keccak256Hash(slashIntent.Proof)
+		selectedProofHash := crypto.Keccak256Hash(selected[idx].Proof)
+		if bytes.Compare(proofHash[:], selectedProofHash[:]) < 0 {
+			selected[idx] = slashIntent
+		}
+	}
+	return selected
+}
+
 // signTransaction signs the given transaction
 func (ledger *Ledger) signTransaction(tx types.Tx) (*crypto.Signature, error) {
 	chainID := ledger.state.GetChainID()
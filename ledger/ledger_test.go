@@ -3,6 +3,7 @@ package ledger
 import (
 	"fmt"
 	"math/big"
+	"math/rand"
 	"testing"
 	"time"
 
@@ -10,9 +11,12 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/blockchain"
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	exec "github.com/thetatoken/theta/ledger/execution"
 	"github.com/thetatoken/theta/ledger/types"
 	"github.com/thetatoken/theta/store/database/backend"
 )
@@ -25,6 +29,23 @@ func TestLedgerSetup(t *testing.T) {
 	assert.NotNil(mempool)
 }
 
+func TestLedgerSetupMissingDependencies(t *testing.T) {
+	assert := assert.New(t)
+
+	chainID := "test_chain_id"
+	chain := &blockchain.Chain{ChainID: chainID}
+	consensus := exec.NewTestConsensusEngine("proposer")
+	valMgr := newTesetValidatorManager(consensus)
+
+	ledger, err := NewLedger(chainID, backend.NewMemDatabase(), chain, nil, valMgr, nil)
+	assert.Nil(ledger)
+	assert.NotNil(err)
+
+	ledger, err = NewLedger(chainID, backend.NewMemDatabase(), chain, consensus, nil, nil)
+	assert.Nil(ledger)
+	assert.NotNil(err)
+}
+
 func TestLedgerScreenTx(t *testing.T) {
 	assert := assert.New(t)
 
@@ -63,7 +84,7 @@ func TestLedgerProposerBlockTxs(t *testing.T) {
 	startTime := time.Now()
 
 	// Propose block transactions
-	_, blockTxs, res := ledger.ProposeBlockTxs()
+	_, blockTxs, res := ledger.ProposeBlockTxs(core.NewBlock())
 
 	endTime := time.Now()
 	elapsed := endTime.Sub(startTime)
@@ -102,6 +123,83 @@ func TestLedgerProposerBlockTxs(t *testing.T) {
 	}
 }
 
+// rotatedValidatorManager selects another validator set for the child of a block than the one of the block
+type rotatedValidatorManager struct {
+	core.ValidatorManager
+	nextValSet *core.ValidatorSet
+}
+
+func (m *rotatedValidatorManager) GetNextValidatorSet(blockHash common.Hash) *core.ValidatorSet {
+	return m.nextValSet
+}
+
+func TestLedgerProposeBlockTxsValidatorRotation(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// The validator set rotates from val2 at the last finalized block to val3 at the child of the parent block
+	_, val2PubKey, err := crypto.TEST_GenerateKeyPairWithSeed("val2")
+	require.Nil(err)
+	_, val3PubKey, err := crypto.TEST_GenerateKeyPairWithSeed("val3")
+	require.Nil(err)
+	_, ledger, _ := newTestLedgerWithValidatorManager(func(consensus core.ConsensusEngine) core.ValidatorManager {
+		valMgr := newTesetValidatorManager(consensus)
+		nextValSet := core.NewValidatorSet()
+		nextValSet.AddValidator(valMgr.GetProposer(common.Hash{}, 0))
+		nextValSet.AddValidator(core.NewValidator(val3PubKey.Address().String(), new(big.Int).SetUint64(100)))
+		return &rotatedValidatorManager{ValidatorManager: valMgr, nextValSet: nextValSet}
+	})
+	prepareInitLedgerState(ledger, 1)
+
+	block := core.NewBlock()
+	block.Parent = common.BytesToHash([]byte("parent"))
+	_, blockTxs, res := ledger.ProposeBlockTxs(block)
+	require.True(res.IsOK(), res.Message)
+
+	// The coinbase rewards the validators of the parent block's child, so it passes the sanity check
+	require.Equal(1, len(blockTxs))
+	tx, err := types.TxFromBytes(blockTxs[0])
+	require.Nil(err)
+	coinbaseTx, ok := tx.(*types.CoinbaseTx)
+	require.True(ok)
+	rewarded := map[common.Address]bool{}
+	for _, output := range coinbaseTx.Outputs {
+		rewarded[output.Address] = true
+	}
+	assert.True(rewarded[val3PubKey.Address()])
+	assert.False(rewarded[val2PubKey.Address()])
+}
+
+type unavailableValidatorSetProvider struct{}
+
+func (p *unavailableValidatorSetProvider) GetValidatorSet(blockHeight uint64, parentBlockHash common.Hash) (*core.ValidatorSet, error) {
+	return nil, exec.ErrValidatorSetUnavailable
+}
+
+func TestLedgerProposeBlockTxsDeferredSpecialTxs(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	_, ledger, mempool := newTestLedger()
+	prepareInitLedgerState(ledger, 1)
+	slashIntent := types.SlashIntent{
+		Address:         common.HexToAddress("0x1234"),
+		ReserveSequence: 1,
+		Proof:           common.Bytes("proof"),
+	}
+	ledger.state.Checked().AddSlashIntent(slashIntent)
+
+	// The special txs are deferred while the validator set is unavailable
+	ledger.executor.SetValidatorSetProvider(&unavailableValidatorSetProvider{})
+	_, blockTxs, res := ledger.ProposeBlockTxs(core.NewBlock())
+	require.True(res.IsOK(), res.Message)
+	assert.Equal(0, len(blockTxs))
+
+	// The mempool does not receive them, the slash intent is kept for the next block instead
+	assert.Equal(0, mempool.Size())
+	assert.Equal([]types.SlashIntent{slashIntent}, ledger.state.Checked().GetSlashIntents())
+}
+
 func TestLedgerApplyBlockTxs(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -125,7 +223,10 @@ func TestLedgerApplyBlockTxs(t *testing.T) {
 	}
 	expectedStateRoot := common.HexToHash("0d7bff2377e3638b82b09c21b7d0636ed593d2225164cb9b67f7296432194c58")
 
-	res := ledger.ApplyBlockTxs(blockRawTxs, expectedStateRoot)
+	block := core.NewBlock()
+	block.AddTxs(blockRawTxs)
+	block.StateHash = expectedStateRoot
+	res := ledger.ApplyBlockTxs(block)
 	require.True(res.IsOK(), res.Message)
 
 	//
@@ -326,8 +427,10 @@ func TestValidatorStakeUpdate(t *testing.T) {
 	for h := uint64(0); h < heightDelta1; h++ {
 		es.state.Commit() // increment height
 	}
-	expectedStateHash, _, res := es.consensus.GetLedger().ProposeBlockTxs()
-	res = es.consensus.GetLedger().ApplyBlockTxs([]common.Bytes{}, expectedStateHash)
+	block := core.NewBlock()
+	expectedStateHash, _, res := es.consensus.GetLedger().ProposeBlockTxs(block)
+	block.StateHash = expectedStateHash
+	res = es.consensus.GetLedger().ApplyBlockTxs(block)
 	assert.True(res.IsOK())
 
 	srcAcc = es.state.Delivered().GetAccount(withdrawSourcePrivAcc.Address)
@@ -340,8 +443,10 @@ func TestValidatorStakeUpdate(t *testing.T) {
 	for h := uint64(0); h < heightDelta2; h++ {
 		es.state.Commit() // increment height
 	}
-	expectedStateHash, _, res = es.consensus.GetLedger().ProposeBlockTxs()
-	res = es.consensus.GetLedger().ApplyBlockTxs([]common.Bytes{}, expectedStateHash)
+	block = core.NewBlock()
+	expectedStateHash, _, res = es.consensus.GetLedger().ProposeBlockTxs(block)
+	block.StateHash = expectedStateHash
+	res = es.consensus.GetLedger().ApplyBlockTxs(block)
 	assert.True(res.IsOK())
 
 	srcAcc = es.state.Delivered().GetAccount(withdrawSourcePrivAcc.Address)
@@ -353,3 +458,165 @@ func TestValidatorStakeUpdate(t *testing.T) {
 	assert.True(returnedCoins.TFuelWei.Cmp(core.Zero) == 0)
 	log.Infof("Returned coins: %v", returnedCoins)
 }
+
+func TestSlashRewardVestingMaturation(t *testing.T) {
+	assert := assert.New(t)
+
+	_, ledger, _ := newTestLedger()
+	view := ledger.state.Delivered()
+
+	beneficiary := types.MakeAccWithInitBalance("beneficiary", types.NewCoins(0, 0))
+	view.SetAccount(beneficiary.Account.Address, &beneficiary.Account)
+
+	slashTxHash := common.BytesToHash([]byte("slash_tx"))
+	startHeight := view.Height()
+	view.SetSlashRewardVesting(slashTxHash, &types.SlashRewardVesting{
+		Beneficiary:      beneficiary.Account.Address,
+		Amount:           types.NewCoins(1000, 2000),
+		Released:         types.NewCoins(0, 0),
+		StartBlockHeight: startHeight,
+		EndBlockHeight:   startHeight + 4,
+	})
+
+	// The reward accrues to the beneficiary block by block
+	for i := 1; i <= 3; i++ {
+		view.IncrementHeight()
+		ledger.handleSlashRewardVesting(view)
+
+		balance := view.GetAccount(beneficiary.Account.Address).Balance
+		assert.True(types.NewCoins(int64(250*i), int64(500*i)).IsEqual(balance), balance.String())
+		vesting := view.GetSlashRewardVesting(slashTxHash)
+		assert.NotNil(vesting)
+		assert.True(balance.IsEqual(vesting.Released))
+	}
+
+	// The record is removed once the reward fully matures
+	view.IncrementHeight()
+	ledger.handleSlashRewardVesting(view)
+	balance := view.GetAccount(beneficiary.Account.Address).Balance
+	assert.True(types.NewCoins(1000, 2000).IsEqual(balance), balance.String())
+	assert.Nil(view.GetSlashRewardVesting(slashTxHash))
+	assert.Equal(0, len(view.GetSlashRewardVestings()))
+
+	view.IncrementHeight()
+	ledger.handleSlashRewardVesting(view)
+	balance = view.GetAccount(beneficiary.Account.Address).Balance
+	assert.True(types.NewCoins(1000, 2000).IsEqual(balance), balance.String())
+}
+
+func TestSlashBondReturn(t *testing.T) {
+	assert := assert.New(t)
+
+	_, ledger, _ := newTestLedger()
+	view := ledger.state.Delivered()
+
+	proposer := types.MakeAccWithInitBalance("proposer", types.NewCoins(0, 0))
+	view.SetAccount(proposer.Account.Address, &proposer.Account)
+
+	returnedSlashTxHash := common.BytesToHash([]byte("returned_slash_tx"))
+	forfeitedSlashTxHash := common.BytesToHash([]byte("forfeited_slash_tx"))
+	returnHeight := view.Height() + 2
+	for _, slashTxHash := range []common.Hash{returnedSlashTxHash, forfeitedSlashTxHash} {
+		view.SetSlashBond(slashTxHash, &types.SlashBond{
+			Proposer:          proposer.Account.Address,
+			Amount:            types.NewCoins(0, 1000),
+			ReturnBlockHeight: returnHeight,
+		})
+	}
+
+	// The bond is locked until the return height
+	view.IncrementHeight()
+	ledger.handleSlashBondReturn(view)
+	assert.True(view.GetAccount(proposer.Account.Address).Balance.IsZero())
+	assert.Equal(2, len(view.GetSlashBonds()))
+
+	_, res := exec.ForfeitSlashBond(view, forfeitedSlashTxHash)
+	assert.True(res.IsOK(), res.Message)
+
+	// Only the bond that was not forfeited is returned
+	view.IncrementHeight()
+	ledger.handleSlashBondReturn(view)
+	balance := view.GetAccount(proposer.Account.Address).Balance
+	assert.True(types.NewCoins(0, 1000).IsEqual(balance), balance.String())
+	assert.Equal(0, len(view.GetSlashBonds()))
+}
+
+func TestAddSlashTxsDedup(t *testing.T) {
+	assert := assert.New(t)
+
+	_, ledger, _ := newTestLedger()
+	view := ledger.state.Checked()
+
+	alice := types.MakeAccWithInitBalance("alice", types.NewCoins(0, 0))
+	bob := types.MakeAccWithInitBalance("bob", types.NewCoins(0, 0))
+	slashIntents := []types.SlashIntent{
+		{Address: alice.Address, ReserveSequence: 1, Proof: common.Bytes("proof1")},
+		{Address: alice.Address, ReserveSequence: 1, Proof: common.Bytes("proof2")},
+		{Address: alice.Address, ReserveSequence: 2, Proof: common.Bytes("proof3")},
+		{Address: bob.Address, ReserveSequence: 1, Proof: common.Bytes("proof4")},
+		{Address: bob.Address, ReserveSequence: 1, Proof: common.Bytes("proof5")},
+	}
+	for _, slashIntent := range slashIntents {
+		view.AddSlashIntent(slashIntent)
+	}
+
+	proposer := core.Validator{Address: ledger.consensus.PrivateKey().PublicKey().Address()}
+	validators := []core.Validator{proposer}
+	rawTxs := []common.Bytes{}
+	ledger.addSlashTxs(view, &proposer, &validators, &rawTxs)
+
+	// Only one slash against each reserved fund is admitted
+	assert.Equal(3, len(rawTxs))
+	expectedIntents := selectSlashIntents(slashIntents)
+	for idx, rawTx := range rawTxs {
+		tx, err := types.TxFromBytes(rawTx)
+		assert.Nil(err)
+		slashTx, ok := tx.(*types.SlashTx)
+		assert.True(ok)
+		assert.Equal(expectedIntents[idx].Address, slashTx.SlashedAddress)
+		assert.Equal(expectedIntents[idx].ReserveSequence, slashTx.ReserveSequence)
+		assert.Equal(expectedIntents[idx].Proof, slashTx.SlashProof)
+	}
+	assert.Equal(0, len(view.GetSlashIntents()))
+}
+
+func TestSelectSlashIntentsTieBreak(t *testing.T) {
+	assert := assert.New(t)
+
+	alice := types.MakeAccWithInitBalance("alice", types.NewCoins(0, 0))
+	bob := types.MakeAccWithInitBalance("bob", types.NewCoins(0, 0))
+	slashIntents := []types.SlashIntent{}
+	for idx := 0; idx < 5; idx++ {
+		proof := common.Bytes(fmt.Sprintf("proof%v", idx))
+		slashIntents = append(slashIntents,
+			types.SlashIntent{Address: alice.Address, ReserveSequence: 1, Proof: proof},
+			types.SlashIntent{Address: bob.Address, ReserveSequence: 1, Proof: proof})
+	}
+
+	lowestProofHash := func(addr common.Address) common.Bytes {
+		var winner common.Bytes
+		for _, slashIntent := range slashIntents {
+			if slashIntent.Address != addr {
+				continue
+			}
+			if winner == nil || crypto.Keccak256Hash(slashIntent.Proof).Hex() < crypto.Keccak256Hash(winner).Hex() {
+				winner = slashIntent.Proof
+			}
+		}
+		return winner
+	}
+
+	// Every node picks the same winner, whatever order it received the slash intents in
+	for round := 0; round < 10; round++ {
+		shuffled := make([]types.SlashIntent, len(slashIntents))
+		for i, j := range rand.Perm(len(slashIntents)) {
+			shuffled[j] = slashIntents[i]
+		}
+
+		selected := selectSlashIntents(shuffled)
+		assert.Equal(2, len(selected))
+		for _, slashIntent := range selected {
+			assert.Equal(lowestProofHash(slashIntent.Address), slashIntent.Proof)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package state
 
-import "github.com/thetatoken/theta/common"
+import (
+	"strconv"
+
+	"github.com/thetatoken/theta/common"
+)
 
 //
 // ------------------------- Ledger State Keys -------------------------
@@ -27,6 +31,81 @@ func SplitRuleKey(resourceID string) common.Bytes {
 	return append(SplitRuleKeyPrefix(), resourceIDBytes[:]...)
 }
 
+// SlashEvidenceKeyPrefix returns the prefix for the slash evidence key
+func SlashEvidenceKeyPrefix() common.Bytes {
+	return common.Bytes("ls/se/")
+}
+
+// SlashEvidenceKey constructs the state key for the slash evidence accumulated against the given reserved fund
+func SlashEvidenceKey(addr common.Address, reserveSequence uint64) common.Bytes {
+	key := append(SlashEvidenceKeyPrefix(), addr[:]...)
+	return append(key, common.Bytes("/"+strconv.FormatUint(reserveSequence, 10))...)
+}
+
+// ReservedFundValidationKey constructs the state key for the attestation that the given reserved fund is not overspent
+func ReservedFundValidationKey(addr common.Address, reserveSequence uint64) common.Bytes {
+	key := append(common.Bytes("ls/rfv/"), addr[:]...)
+	return append(key, common.Bytes("/"+strconv.FormatUint(reserveSequence, 10))...)
+}
+
+// ReserveFundTxHashKey constructs the state key for the hash of the ReserveFundTx that created the given reserved fund
+func ReserveFundTxHashKey(addr common.Address, reserveSequence uint64) common.Bytes {
+	key := append(common.Bytes("ls/rftx/"), addr[:]...)
+	return append(key, common.Bytes("/"+strconv.FormatUint(reserveSequence, 10))...)
+}
+
+// SlashedProofKey constructs the state key for the hash of the proof the given reserved fund was slashed with
+func SlashedProofKey(addr common.Address, reserveSequence uint64) common.Bytes {
+	key := append(common.Bytes("ls/spf/"), addr[:]...)
+	return append(key, common.Bytes("/"+strconv.FormatUint(reserveSequence, 10))...)
+}
+
+// SlashPauseKey constructs the state key for the pause of slashing against the given account
+func SlashPauseKey(addr common.Address) common.Bytes {
+	return append(common.Bytes("ls/sp/"), addr[:]...)
+}
+
+// AccountSlashFlagKey constructs the state key for the flag set on the given account after it has been slashed
+func AccountSlashFlagKey(addr common.Address) common.Bytes {
+	return append(common.Bytes("ls/asf/"), addr[:]...)
+}
+
+// SlashRewardVestingKeyPrefix returns the prefix for the slash reward vesting key
+func SlashRewardVestingKeyPrefix() common.Bytes {
+	return common.Bytes("ls/srv/")
+}
+
+// SlashRewardVestingKey constructs the state key for the vesting of the reward of the given SlashTx
+func SlashRewardVestingKey(slashTxHash common.Hash) common.Bytes {
+	return append(SlashRewardVestingKeyPrefix(), slashTxHash[:]...)
+}
+
+// SlashBondKeyPrefix returns the prefix for the slash bond key
+func SlashBondKeyPrefix() common.Bytes {
+	return common.Bytes("ls/sb/")
+}
+
+// SlashBondKey constructs the state key for the bond locked by the proposer of the given SlashTx
+func SlashBondKey(slashTxHash common.Hash) common.Bytes {
+	return append(SlashBondKeyPrefix(), slashTxHash[:]...)
+}
+
+// PendingSlashRewardKeyPrefix returns the prefix for the pending slash reward key
+func PendingSlashRewardKeyPrefix() common.Bytes {
+	return common.Bytes("ls/psr/")
+}
+
+// PendingSlashRewardKey constructs the state key for the slash rewards accumulated for the given proposer
+// during the current block
+func PendingSlashRewardKey(proposer common.Address) common.Bytes {
+	return append(PendingSlashRewardKeyPrefix(), proposer[:]...)
+}
+
+// SlashBurntSupplyKey returns the state key for the total amount burnt by slashing
+func SlashBurntSupplyKey() common.Bytes {
+	return common.Bytes("ls/sbs")
+}
+
 // CodeKey constructs the state key for the given code hash
 func CodeKey(codeHash common.Bytes) common.Bytes {
 	return append(common.Bytes("ls/ch/"), codeHash...)
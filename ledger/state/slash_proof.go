@@ -0,0 +1,72 @@
+package state
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/store/trie"
+)
+
+// SlashStateProof is the merkle proof of the state change made by a SlashTx, i.e. the resulting states
+// of the slashed and the proposer accounts. It allows a light client to verify the slash against the
+// state root of the block that includes the SlashTx, without access to the full state.
+type SlashStateProof struct {
+	SlashedAddress       common.Address
+	ReserveSequence      uint64
+	ProposerAddress      common.Address
+	SlashedAccountProof  core.VCPProof
+	ProposerAccountProof core.VCPProof
+}
+
+// GenerateSlashStateProof generates the proof of the state change made by the given SlashTx. The view
+// should be the committed view the SlashTx was applied to.
+func GenerateSlashStateProof(view *StoreView, tx *types.SlashTx) (*SlashStateProof, error) {
+	proof := &SlashStateProof{
+		SlashedAddress:  tx.SlashedAddress,
+		ReserveSequence: tx.ReserveSequence,
+		ProposerAddress: tx.Proposer.Address,
+	}
+	if err := view.store.Prove(AccountKey(proof.SlashedAddress), 0, &proof.SlashedAccountProof); err != nil {
+		return nil, errors.Wrapf(err, "failed to prove the slashed account %v", proof.SlashedAddress)
+	}
+	if err := view.store.Prove(AccountKey(proof.ProposerAddress), 0, &proof.ProposerAccountProof); err != nil {
+		return nil, errors.Wrapf(err, "failed to prove the proposer account %v", proof.ProposerAddress)
+	}
+	return proof, nil
+}
+
+// VerifySlashStateProof verifies the given proof against the state root, and returns the proven slashed
+// and proposer accounts. The proof is rejected if the slashed account still holds the slashed reserved fund.
+func VerifySlashStateProof(stateRoot common.Hash, proof *SlashStateProof) (slashedAccount, proposerAccount *types.Account, err error) {
+	slashedAccount, err = verifyAccountProof(stateRoot, proof.SlashedAddress, &proof.SlashedAccountProof)
+	if err != nil {
+		return nil, nil, err
+	}
+	if slashedAccount.HasReservedFund(proof.ReserveSequence) {
+		return nil, nil, errors.Errorf("reserved fund %v of account %v is not slashed",
+			proof.ReserveSequence, proof.SlashedAddress)
+	}
+
+	proposerAccount, err = verifyAccountProof(stateRoot, proof.ProposerAddress, &proof.ProposerAccountProof)
+	if err != nil {
+		return nil, nil, err
+	}
+	return slashedAccount, proposerAccount, nil
+}
+
+func verifyAccountProof(stateRoot common.Hash, addr common.Address, proof *core.VCPProof) (*types.Account, error) {
+	data, _, err := trie.VerifyProof(stateRoot, AccountKey(addr), proof)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid proof for account %v", addr)
+	}
+	if len(data) == 0 {
+		return nil, errors.Errorf("account %v does not exist", addr)
+	}
+	acc := &types.Account{}
+	if err := types.FromBytes(data, acc); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode account %v", addr)
+	}
+	return acc, nil
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
 	"github.com/thetatoken/theta/crypto"
 	"github.com/thetatoken/theta/ledger/types"
@@ -26,10 +28,19 @@ type StoreView struct {
 	store  *treestore.TreeStore
 
 	coinbaseTransactinProcessed bool
+	slashProofVerifications     uint64 // Signatures verified for the slash proofs of the current block
 	slashIntents                []types.SlashIntent
-	refund                      uint64 // Gas refund during smart contract execution
+	events                      []types.Event
+	refund                      uint64      // Gas refund during smart contract execution
+	blockTimestamp              *big.Int    // Timestamp of the block whose transactions are executed against the view
+	parentBlockHash             common.Hash // Parent of the block whose transactions are executed against the view
+
+	readOnly bool
 }
 
+// ErrWriteToReadOnlyView is the panic value raised when a read-only StoreView is written to
+var ErrWriteToReadOnlyView = errors.New("Attempted to write to a read-only StoreView")
+
 // NewStoreView creates an instance of the StoreView
 func NewStoreView(height uint64, root common.Hash, db database.Database) *StoreView {
 	store := treestore.NewTreeStore(root, db)
@@ -41,6 +52,7 @@ func NewStoreView(height uint64, root common.Hash, db database.Database) *StoreV
 		height:       height,
 		store:        store,
 		slashIntents: []types.SlashIntent{},
+		events:       []types.Event{},
 		refund:       0,
 	}
 	return sv
@@ -53,14 +65,45 @@ func (sv *StoreView) Copy() (*StoreView, error) {
 		return nil, err
 	}
 	copiedStoreView := &StoreView{
-		height:       sv.height,
-		store:        copiedStore,
-		slashIntents: []types.SlashIntent{},
-		refund:       0,
+		height:          sv.height,
+		store:           copiedStore,
+		slashIntents:    []types.SlashIntent{},
+		events:          []types.Event{},
+		refund:          0,
+		blockTimestamp:  sv.blockTimestamp,
+		parentBlockHash: sv.parentBlockHash,
 	}
 	return copiedStoreView, nil
 }
 
+// ReadOnlyView returns a view of the same state which panics with ErrWriteToReadOnlyView on any write,
+// e.g. for the sanity checks of the transactions, which must not mutate the state
+func (sv *StoreView) ReadOnlyView() *StoreView {
+	return &StoreView{
+		height:                      sv.height,
+		store:                       sv.store,
+		coinbaseTransactinProcessed: sv.coinbaseTransactinProcessed,
+		slashProofVerifications:     sv.slashProofVerifications,
+		slashIntents:                sv.slashIntents,
+		events:                      sv.events,
+		refund:                      sv.refund,
+		blockTimestamp:              sv.blockTimestamp,
+		parentBlockHash:             sv.parentBlockHash,
+		readOnly:                    true,
+	}
+}
+
+// IsReadOnly returns whether the view is read-only
+func (sv *StoreView) IsReadOnly() bool {
+	return sv.readOnly
+}
+
+func (sv *StoreView) checkWritable() {
+	if sv.readOnly {
+		panic(ErrWriteToReadOnlyView)
+	}
+}
+
 // GetDB returns the underlying database.
 func (sv *StoreView) GetDB() database.Database {
 	return sv.store.GetDB()
@@ -78,11 +121,38 @@ func (sv *StoreView) Height() uint64 {
 
 // IncrementHeight increments the block height by 1
 func (sv *StoreView) IncrementHeight() {
+	sv.checkWritable()
 	sv.height++
+	sv.slashProofVerifications = 0 // the verification budget is per block
+}
+
+// BlockTimestamp returns the timestamp of the block whose transactions are executed against the view, nil if
+// the view is not tied to a block, e.g. for the transaction screening
+func (sv *StoreView) BlockTimestamp() *big.Int {
+	return sv.blockTimestamp
+}
+
+// SetBlockTimestamp sets the timestamp of the block whose transactions are executed against the view
+func (sv *StoreView) SetBlockTimestamp(timestamp *big.Int) {
+	sv.checkWritable()
+	sv.blockTimestamp = timestamp
+}
+
+// ParentBlockHash returns the hash of the parent of the block whose transactions are executed against the
+// view, empty if the view is not tied to a block
+func (sv *StoreView) ParentBlockHash() common.Hash {
+	return sv.parentBlockHash
+}
+
+// SetParentBlockHash sets the hash of the parent of the block whose transactions are executed against the view
+func (sv *StoreView) SetParentBlockHash(parentBlockHash common.Hash) {
+	sv.checkWritable()
+	sv.parentBlockHash = parentBlockHash
 }
 
 // Save saves the StoreView to the persistent storage, and return the root hash
 func (sv *StoreView) Save() common.Hash {
+	sv.checkWritable()
 	rootHash, err := sv.store.Commit()
 
 	logger.Infof("Commit to data store, height: %v, rootHash: %v", sv.height+1, rootHash.Hex())
@@ -105,16 +175,19 @@ func (sv *StoreView) ProveVCP(vcpKey []byte, vp *core.VCPProof) error {
 
 // Delete removes the value corresponding to the key
 func (sv *StoreView) Delete(key common.Bytes) {
+	sv.checkWritable()
 	sv.store.Delete(key)
 }
 
 // Set returns the value corresponding to the key
 func (sv *StoreView) Set(key common.Bytes, value common.Bytes) {
+	sv.checkWritable()
 	sv.store.Set(key, value)
 }
 
 // AddSlashIntent adds slashIntent
 func (sv *StoreView) AddSlashIntent(slashIntent types.SlashIntent) {
+	sv.checkWritable()
 	sv.slashIntents = append(sv.slashIntents, slashIntent)
 }
 
@@ -125,9 +198,36 @@ func (sv *StoreView) GetSlashIntents() []types.SlashIntent {
 
 // ClearSlashIntents clears all the slashIntents
 func (sv *StoreView) ClearSlashIntents() {
+	sv.checkWritable()
 	sv.slashIntents = []types.SlashIntent{}
 }
 
+// AddEvent adds an event describing an effect of an executed transaction
+func (sv *StoreView) AddEvent(event types.Event) {
+	sv.checkWritable()
+	sv.events = append(sv.events, event)
+}
+
+// GetEvents retrieves all the events, in the order they were added
+func (sv *StoreView) GetEvents() []types.Event {
+	return sv.events
+}
+
+// ClearEvents clears all the events
+func (sv *StoreView) ClearEvents() {
+	sv.checkWritable()
+	sv.events = []types.Event{}
+}
+
+// RevertEvents drops the events added after the first numEvents ones, e.g. along with reverting the state
+// to a snapshot
+func (sv *StoreView) RevertEvents(numEvents int) {
+	sv.checkWritable()
+	if numEvents < len(sv.events) {
+		sv.events = sv.events[:numEvents]
+	}
+}
+
 // CoinbaseTransactinProcessed returns whether the coinbase transaction for the current block has been processed
 func (sv *StoreView) CoinbaseTransactinProcessed() bool {
 	return sv.coinbaseTransactinProcessed
@@ -135,9 +235,21 @@ func (sv *StoreView) CoinbaseTransactinProcessed() bool {
 
 // SetCoinbaseTransactionProcessed sets whether the coinbase transaction for the current block has been processed
 func (sv *StoreView) SetCoinbaseTransactionProcessed(processed bool) {
+	sv.checkWritable()
 	sv.coinbaseTransactinProcessed = processed
 }
 
+// SlashProofVerifications returns the number of signatures verified for the slash proofs of the current block
+func (sv *StoreView) SlashProofVerifications() uint64 {
+	return sv.slashProofVerifications
+}
+
+// AddSlashProofVerifications adds to the number of signatures verified for the slash proofs of the current block
+func (sv *StoreView) AddSlashProofVerifications(count uint64) {
+	sv.checkWritable()
+	sv.slashProofVerifications += count
+}
+
 // GetAccount returns an account.
 func (sv *StoreView) GetAccount(addr common.Address) *types.Account {
 	data := sv.Get(AccountKey(addr))
@@ -153,6 +265,19 @@ func (sv *StoreView) GetAccount(addr common.Address) *types.Account {
 	return acc
 }
 
+// GetAccounts gets the accounts of the given addresses in one batch. Each distinct address is read once, hence
+// duplicated addresses share the same account instance. The addresses without an account map to nil.
+func (sv *StoreView) GetAccounts(addrs []common.Address) map[common.Address]*types.Account {
+	accounts := make(map[common.Address]*types.Account, len(addrs))
+	for _, addr := range addrs {
+		if _, fetched := accounts[addr]; fetched {
+			continue
+		}
+		accounts[addr] = sv.GetAccount(addr)
+	}
+	return accounts
+}
+
 // SetAccount sets an account.
 func (sv *StoreView) SetAccount(addr common.Address, acc *types.Account) {
 	accBytes, err := types.ToBytes(acc)
@@ -163,6 +288,27 @@ func (sv *StoreView) SetAccount(addr common.Address, acc *types.Account) {
 	sv.Set(AccountKey(addr), accBytes)
 }
 
+// RemoveReservedFund removes the reserved fund with the given reserve sequence from the account, and writes
+// the account back. It errors out if the account does not hold exactly one such reserved fund.
+func (sv *StoreView) RemoveReservedFund(addr common.Address, reserveSequence uint64) result.Result {
+	acc := sv.GetAccount(addr)
+	if acc == nil {
+		return result.Error("Account %v does not exist!", addr.Hex())
+	}
+
+	_, reservedFundIdx, ok := acc.FindReservedFund(reserveSequence)
+	if !ok {
+		if acc.CountReservedFunds(reserveSequence) > 1 {
+			return result.Error("Multiple reserved funds found for %v", reserveSequence)
+		}
+		return result.Error("Reserved fund not found for %v", reserveSequence)
+	}
+
+	acc.ReservedFunds = append(acc.ReservedFunds[:reservedFundIdx], acc.ReservedFunds[reservedFundIdx+1:]...)
+	sv.SetAccount(addr, acc)
+	return result.OK
+}
+
 // DeleteAccount deletes an account.
 func (sv *StoreView) DeleteAccount(addr common.Address) {
 	sv.Delete(AccountKey(addr))
@@ -220,6 +366,7 @@ func (sv *StoreView) SetSplitRule(resourceID string, splitRule *types.SplitRule)
 
 // DeleteSplitRule deletes a split rule.
 func (sv *StoreView) DeleteSplitRule(resourceID string) bool {
+	sv.checkWritable()
 	key := SplitRuleKey(resourceID)
 	deleted := sv.store.Delete(key)
 	return deleted
@@ -227,6 +374,7 @@ func (sv *StoreView) DeleteSplitRule(resourceID string) bool {
 
 // DeleteExpiredSplitRules deletes a split rule.
 func (sv *StoreView) DeleteExpiredSplitRules(currentBlockHeight uint64) bool {
+	sv.checkWritable()
 	prefix := SplitRuleKeyPrefix()
 
 	expiredKeys := []common.Bytes{}
@@ -255,6 +403,394 @@ func (sv *StoreView) DeleteExpiredSplitRules(currentBlockHeight uint64) bool {
 	return true
 }
 
+// GetSlashEvidence gets the slash evidence accumulated against the given reserved fund.
+func (sv *StoreView) GetSlashEvidence(addr common.Address, reserveSequence uint64) *types.SlashEvidence {
+	data := sv.Get(SlashEvidenceKey(addr, reserveSequence))
+	if data == nil || len(data) == 0 {
+		return nil
+	}
+	evidence := &types.SlashEvidence{}
+	err := types.FromBytes(data, evidence)
+	if err != nil {
+		panic(fmt.Sprintf("Error reading slash evidence %X error: %v",
+			data, err.Error()))
+	}
+	return evidence
+}
+
+// SetSlashEvidence sets the slash evidence.
+func (sv *StoreView) SetSlashEvidence(evidence *types.SlashEvidence) {
+	evidenceBytes, err := types.ToBytes(evidence)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing slash evidence %v error: %v",
+			evidence, err.Error()))
+	}
+	sv.Set(SlashEvidenceKey(evidence.Address, evidence.ReserveSequence), evidenceBytes)
+}
+
+// DeleteSlashEvidence deletes the slash evidence accumulated against the given reserved fund.
+func (sv *StoreView) DeleteSlashEvidence(addr common.Address, reserveSequence uint64) bool {
+	sv.checkWritable()
+	key := SlashEvidenceKey(addr, reserveSequence)
+	deleted := sv.store.Delete(key)
+	return deleted
+}
+
+// DeleteExpiredSlashEvidences deletes the slash evidences that expired before the current block height.
+func (sv *StoreView) DeleteExpiredSlashEvidences(currentBlockHeight uint64) bool {
+	sv.checkWritable()
+	prefix := SlashEvidenceKeyPrefix()
+
+	expiredKeys := []common.Bytes{}
+	sv.store.Traverse(prefix, func(key, value common.Bytes) bool {
+		var evidence types.SlashEvidence
+		err := types.FromBytes(value, &evidence)
+		if err != nil {
+			panic(fmt.Sprintf("Error reading slash evidence %X error: %v", value, err.Error()))
+		}
+
+		expired := (evidence.EndBlockHeight < currentBlockHeight)
+		if expired {
+			expiredKeys = append(expiredKeys, key)
+		}
+		return true
+	})
+
+	for _, key := range expiredKeys {
+		deleted := sv.store.Delete(key)
+		if !deleted {
+			logger.Errorf("Failed to delete expired slash evidences")
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetReservedFundValidation gets the block height at which the given reserved fund was validated not to be
+// overspent. The returned boolean indicates whether the reserved fund has been validated.
+func (sv *StoreView) GetReservedFundValidation(addr common.Address, reserveSequence uint64) (blockHeight uint64, exists bool) {
+	data := sv.Get(ReservedFundValidationKey(addr, reserveSequence))
+	if data == nil || len(data) == 0 {
+		return 0, false
+	}
+	err := types.FromBytes(data, &blockHeight)
+	if err != nil {
+		panic(fmt.Sprintf("Error reading reserved fund validation %X error: %v",
+			data, err.Error()))
+	}
+	return blockHeight, true
+}
+
+// SetReservedFundValidation attests the given reserved fund was validated not to be overspent at the given block height.
+func (sv *StoreView) SetReservedFundValidation(addr common.Address, reserveSequence uint64, blockHeight uint64) {
+	validationBytes, err := types.ToBytes(blockHeight)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing reserved fund validation %v error: %v",
+			blockHeight, err.Error()))
+	}
+	sv.Set(ReservedFundValidationKey(addr, reserveSequence), validationBytes)
+}
+
+// DeleteReservedFundValidation deletes the attestation of the given reserved fund.
+func (sv *StoreView) DeleteReservedFundValidation(addr common.Address, reserveSequence uint64) bool {
+	sv.checkWritable()
+	deleted := sv.store.Delete(ReservedFundValidationKey(addr, reserveSequence))
+	return deleted
+}
+
+// GetReserveFundTxHash gets the hash of the ReserveFundTx that created the given reserved fund. The returned
+// boolean indicates whether the hash has been recorded.
+func (sv *StoreView) GetReserveFundTxHash(addr common.Address, reserveSequence uint64) (txHash common.Hash, exists bool) {
+	data := sv.Get(ReserveFundTxHashKey(addr, reserveSequence))
+	if data == nil || len(data) == 0 {
+		return common.Hash{}, false
+	}
+	return common.BytesToHash(data), true
+}
+
+// SetReserveFundTxHash records the hash of the ReserveFundTx that created the given reserved fund.
+func (sv *StoreView) SetReserveFundTxHash(addr common.Address, reserveSequence uint64, txHash common.Hash) {
+	sv.Set(ReserveFundTxHashKey(addr, reserveSequence), txHash[:])
+}
+
+// DeleteReserveFundTxHash deletes the hash of the ReserveFundTx that created the given reserved fund.
+func (sv *StoreView) DeleteReserveFundTxHash(addr common.Address, reserveSequence uint64) bool {
+	sv.checkWritable()
+	deleted := sv.store.Delete(ReserveFundTxHashKey(addr, reserveSequence))
+	return deleted
+}
+
+// GetSlashedProofHash gets the hash of the proof the given reserved fund was slashed with. The returned boolean
+// indicates whether the reserved fund has been slashed.
+func (sv *StoreView) GetSlashedProofHash(addr common.Address, reserveSequence uint64) (proofHash common.Hash, exists bool) {
+	data := sv.Get(SlashedProofKey(addr, reserveSequence))
+	if data == nil || len(data) == 0 {
+		return common.Hash{}, false
+	}
+	return common.BytesToHash(data), true
+}
+
+// SetSlashedProofHash records the hash of the proof the given reserved fund was slashed with. The record is
+// kept after the reserved fund is gone, so that replayed slashes can be told apart from malformed ones.
+func (sv *StoreView) SetSlashedProofHash(addr common.Address, reserveSequence uint64, proofHash common.Hash) {
+	sv.Set(SlashedProofKey(addr, reserveSequence), proofHash[:])
+}
+
+// GetSlashPause gets the block height until which slashing against the given account is paused, e.g. by
+// governance during a dispute. The returned boolean indicates whether a pause has been set.
+func (sv *StoreView) GetSlashPause(addr common.Address) (endBlockHeight uint64, exists bool) {
+	data := sv.Get(SlashPauseKey(addr))
+	if data == nil || len(data) == 0 {
+		return 0, false
+	}
+	err := types.FromBytes(data, &endBlockHeight)
+	if err != nil {
+		panic(fmt.Sprintf("Error reading slash pause %X error: %v",
+			data, err.Error()))
+	}
+	return endBlockHeight, true
+}
+
+// SetSlashPause pauses slashing against the given account until the given block height (exclusive).
+func (sv *StoreView) SetSlashPause(addr common.Address, endBlockHeight uint64) {
+	pauseBytes, err := types.ToBytes(endBlockHeight)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing slash pause %v error: %v",
+			endBlockHeight, err.Error()))
+	}
+	sv.Set(SlashPauseKey(addr), pauseBytes)
+}
+
+// DeleteSlashPause lifts the pause of slashing against the given account.
+func (sv *StoreView) DeleteSlashPause(addr common.Address) bool {
+	sv.checkWritable()
+	deleted := sv.store.Delete(SlashPauseKey(addr))
+	return deleted
+}
+
+// IsSlashPaused returns whether slashing against the given account is paused at the current height.
+func (sv *StoreView) IsSlashPaused(addr common.Address) bool {
+	endBlockHeight, exists := sv.GetSlashPause(addr)
+	return exists && sv.height < endBlockHeight
+}
+
+// GetAccountSlashFlag gets the flag set on the given account after it has been slashed.
+func (sv *StoreView) GetAccountSlashFlag(addr common.Address) *types.AccountSlashFlag {
+	data := sv.Get(AccountSlashFlagKey(addr))
+	if data == nil || len(data) == 0 {
+		return nil
+	}
+	flag := &types.AccountSlashFlag{}
+	err := types.FromBytes(data, flag)
+	if err != nil {
+		panic(fmt.Sprintf("Error reading account slash flag %X error: %v",
+			data, err.Error()))
+	}
+	return flag
+}
+
+// SetAccountSlashFlag sets the flag on the given account after it has been slashed.
+func (sv *StoreView) SetAccountSlashFlag(addr common.Address, flag *types.AccountSlashFlag) {
+	flagBytes, err := types.ToBytes(flag)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing account slash flag %v error: %v",
+			flag, err.Error()))
+	}
+	sv.Set(AccountSlashFlagKey(addr), flagBytes)
+}
+
+// DeleteAccountSlashFlag clears the flag set on the given account after it has been slashed.
+func (sv *StoreView) DeleteAccountSlashFlag(addr common.Address) bool {
+	sv.checkWritable()
+	deleted := sv.store.Delete(AccountSlashFlagKey(addr))
+	return deleted
+}
+
+// IsAccountSlashFlagged returns whether the given account is flagged after a slash at the current height.
+func (sv *StoreView) IsAccountSlashFlagged(addr common.Address) bool {
+	flag := sv.GetAccountSlashFlag(addr)
+	return flag != nil && flag.IsActive(sv.height)
+}
+
+// GetSlashRewardVesting gets the vesting of the reward of the given SlashTx.
+func (sv *StoreView) GetSlashRewardVesting(slashTxHash common.Hash) *types.SlashRewardVesting {
+	data := sv.Get(SlashRewardVestingKey(slashTxHash))
+	if data == nil || len(data) == 0 {
+		return nil
+	}
+	vesting := &types.SlashRewardVesting{}
+	err := types.FromBytes(data, vesting)
+	if err != nil {
+		panic(fmt.Sprintf("Error reading slash reward vesting %X error: %v",
+			data, err.Error()))
+	}
+	return vesting
+}
+
+// SetSlashRewardVesting sets the vesting of the reward of the given SlashTx.
+func (sv *StoreView) SetSlashRewardVesting(slashTxHash common.Hash, vesting *types.SlashRewardVesting) {
+	vestingBytes, err := types.ToBytes(vesting)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing slash reward vesting %v error: %v",
+			vesting, err.Error()))
+	}
+	sv.Set(SlashRewardVestingKey(slashTxHash), vestingBytes)
+}
+
+// DeleteSlashRewardVesting deletes the vesting of the reward of the given SlashTx.
+func (sv *StoreView) DeleteSlashRewardVesting(slashTxHash common.Hash) bool {
+	sv.checkWritable()
+	key := SlashRewardVestingKey(slashTxHash)
+	deleted := sv.store.Delete(key)
+	return deleted
+}
+
+// GetSlashRewardVestings gets all the slash reward vestings, keyed by the hash of the SlashTxs.
+func (sv *StoreView) GetSlashRewardVestings() map[common.Hash]*types.SlashRewardVesting {
+	prefix := SlashRewardVestingKeyPrefix()
+
+	vestings := make(map[common.Hash]*types.SlashRewardVesting)
+	sv.store.Traverse(prefix, func(key, value common.Bytes) bool {
+		vesting := &types.SlashRewardVesting{}
+		err := types.FromBytes(value, vesting)
+		if err != nil {
+			panic(fmt.Sprintf("Error reading slash reward vesting %X error: %v", value, err.Error()))
+		}
+		vestings[common.BytesToHash(key[len(prefix):])] = vesting
+		return true
+	})
+	return vestings
+}
+
+// GetSlashBond gets the bond locked by the proposer of the given SlashTx.
+func (sv *StoreView) GetSlashBond(slashTxHash common.Hash) *types.SlashBond {
+	data := sv.Get(SlashBondKey(slashTxHash))
+	if data == nil || len(data) == 0 {
+		return nil
+	}
+	bond := &types.SlashBond{}
+	err := types.FromBytes(data, bond)
+	if err != nil {
+		panic(fmt.Sprintf("Error reading slash bond %X error: %v",
+			data, err.Error()))
+	}
+	return bond
+}
+
+// SetSlashBond sets the bond locked by the proposer of the given SlashTx.
+func (sv *StoreView) SetSlashBond(slashTxHash common.Hash, bond *types.SlashBond) {
+	bondBytes, err := types.ToBytes(bond)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing slash bond %v error: %v",
+			bond, err.Error()))
+	}
+	sv.Set(SlashBondKey(slashTxHash), bondBytes)
+}
+
+// DeleteSlashBond deletes the bond locked by the proposer of the given SlashTx.
+func (sv *StoreView) DeleteSlashBond(slashTxHash common.Hash) bool {
+	sv.checkWritable()
+	key := SlashBondKey(slashTxHash)
+	deleted := sv.store.Delete(key)
+	return deleted
+}
+
+// GetSlashBonds gets all the slash bonds, keyed by the hash of the SlashTxs.
+func (sv *StoreView) GetSlashBonds() map[common.Hash]*types.SlashBond {
+	prefix := SlashBondKeyPrefix()
+
+	bonds := make(map[common.Hash]*types.SlashBond)
+	sv.store.Traverse(prefix, func(key, value common.Bytes) bool {
+		bond := &types.SlashBond{}
+		err := types.FromBytes(value, bond)
+		if err != nil {
+			panic(fmt.Sprintf("Error reading slash bond %X error: %v", value, err.Error()))
+		}
+		bonds[common.BytesToHash(key[len(prefix):])] = bond
+		return true
+	})
+	return bonds
+}
+
+// GetPendingSlashReward gets the slash rewards accumulated for the given proposer, and not paid out yet.
+func (sv *StoreView) GetPendingSlashReward(proposer common.Address) types.Coins {
+	data := sv.Get(PendingSlashRewardKey(proposer))
+	if data == nil || len(data) == 0 {
+		return types.NewCoins(0, 0)
+	}
+	reward := types.Coins{}
+	err := types.FromBytes(data, &reward)
+	if err != nil {
+		panic(fmt.Sprintf("Error reading pending slash reward %X error: %v",
+			data, err.Error()))
+	}
+	return reward.NoNil()
+}
+
+// AddPendingSlashReward adds the given amount to the slash rewards accumulated for the given proposer.
+func (sv *StoreView) AddPendingSlashReward(proposer common.Address, amount types.Coins) {
+	reward := sv.GetPendingSlashReward(proposer).Plus(amount)
+	rewardBytes, err := types.ToBytes(reward)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing pending slash reward %v error: %v",
+			reward, err.Error()))
+	}
+	sv.Set(PendingSlashRewardKey(proposer), rewardBytes)
+}
+
+// DeletePendingSlashReward deletes the slash rewards accumulated for the given proposer.
+func (sv *StoreView) DeletePendingSlashReward(proposer common.Address) bool {
+	sv.checkWritable()
+	key := PendingSlashRewardKey(proposer)
+	deleted := sv.store.Delete(key)
+	return deleted
+}
+
+// GetPendingSlashRewards gets all the accumulated slash rewards, keyed by the proposers.
+func (sv *StoreView) GetPendingSlashRewards() map[common.Address]types.Coins {
+	prefix := PendingSlashRewardKeyPrefix()
+
+	rewards := make(map[common.Address]types.Coins)
+	sv.store.Traverse(prefix, func(key, value common.Bytes) bool {
+		reward := types.Coins{}
+		err := types.FromBytes(value, &reward)
+		if err != nil {
+			panic(fmt.Sprintf("Error reading pending slash reward %X error: %v", value, err.Error()))
+		}
+		rewards[common.BytesToAddress(key[len(prefix):])] = reward.NoNil()
+		return true
+	})
+	return rewards
+}
+
+// GetSlashBurntSupply gets the total amount burnt by slashing, i.e. by which slashing has decreased the
+// circulating supply.
+func (sv *StoreView) GetSlashBurntSupply() types.Coins {
+	data := sv.Get(SlashBurntSupplyKey())
+	if data == nil || len(data) == 0 {
+		return types.NewCoins(0, 0)
+	}
+	burnt := types.Coins{}
+	err := types.FromBytes(data, &burnt)
+	if err != nil {
+		panic(fmt.Sprintf("Error reading slash burnt supply %X error: %v",
+			data, err.Error()))
+	}
+	return burnt.NoNil()
+}
+
+// AddSlashBurntSupply adds the given amount to the total amount burnt by slashing.
+func (sv *StoreView) AddSlashBurntSupply(amount types.Coins) {
+	burnt := sv.GetSlashBurntSupply().Plus(amount)
+	burntBytes, err := types.ToBytes(burnt)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing slash burnt supply %v error: %v",
+			burnt, err.Error()))
+	}
+	sv.Set(SlashBurntSupplyKey(), burntBytes)
+}
+
 // GetValidatorCandidatePool gets the validator candidate pool.
 func (sv *StoreView) GetValidatorCandidatePool() *core.ValidatorCandidatePool {
 	data := sv.Get(ValidatorCandidatePoolKey())
@@ -398,10 +934,12 @@ func (sv *StoreView) GetCodeSize(addr common.Address) int {
 }
 
 func (sv *StoreView) AddRefund(gas uint64) {
+	sv.checkWritable()
 	sv.refund += gas
 }
 
 func (sv *StoreView) SubRefund(gas uint64) {
+	sv.checkWritable()
 	if gas > sv.refund {
 		panic("Refund counter below zero")
 	}
@@ -413,6 +951,7 @@ func (sv *StoreView) GetRefund() uint64 {
 }
 
 func (sv *StoreView) ResetRefund() {
+	sv.checkWritable()
 	sv.refund = 0
 }
 
@@ -444,6 +983,7 @@ func (sv *StoreView) GetState(addr common.Address, key common.Hash) common.Hash
 }
 
 func (sv *StoreView) SetState(addr common.Address, key, val common.Hash) {
+	sv.checkWritable()
 	account := sv.GetAccount(addr)
 	if account == nil {
 		account = types.NewAccount(addr)
@@ -498,6 +1038,7 @@ func (sv *StoreView) Empty(addr common.Address) bool {
 }
 
 func (sv *StoreView) RevertToSnapshot(root common.Hash) {
+	sv.checkWritable()
 	var err error
 	sv.store, err = sv.store.Revert(root) // revert to one of the previous roots
 	if err != nil {
@@ -506,11 +1047,13 @@ func (sv *StoreView) RevertToSnapshot(root common.Hash) {
 }
 
 func (sv *StoreView) Snapshot() common.Hash {
+	sv.checkWritable()
 	sv.store.Trie.Commit(nil) // Needs to commit to the in-memory trie DB
 	return sv.store.Hash()
 }
 
 func (sv *StoreView) Prune() error {
+	sv.checkWritable()
 	err := sv.store.Prune(func(node []byte) bool {
 		account := &types.Account{}
 		err := types.FromBytes(node, account)
@@ -179,6 +179,197 @@ func TestStoreViewSplitRuleAccess(t *testing.T) {
 	assert.NotNil(sv.GetSplitRule(rid3))
 }
 
+func TestStoreViewSlashEvidenceAccess(t *testing.T) {
+	assert := assert.New(t)
+
+	db := backend.NewMemDatabase()
+	sv := NewStoreView(uint64(1), common.Hash{}, db)
+	_, pubKey, err := crypto.TEST_GenerateKeyPairWithSeed("slashed")
+	assert.Nil(err)
+	addr := pubKey.Address()
+
+	ev1 := &types.SlashEvidence{
+		Address:         addr,
+		ReserveSequence: 1,
+		ServicePayments: []types.ServicePaymentTx{types.ServicePaymentTx{PaymentSequence: 1, ReserveSequence: 1}},
+		EndBlockHeight:  100,
+	}
+	ev2 := &types.SlashEvidence{
+		Address:         addr,
+		ReserveSequence: 2,
+		EndBlockHeight:  17,
+	}
+
+	sv.SetSlashEvidence(ev1)
+	sv.SetSlashEvidence(ev2)
+	assert.Equal(ev1.String(), sv.GetSlashEvidence(addr, 1).String())
+	assert.Equal(ev2.String(), sv.GetSlashEvidence(addr, 2).String())
+	assert.Nil(sv.GetSlashEvidence(addr, 3))
+	assert.Equal(uint64(1), sv.GetSlashEvidence(addr, 1).ServicePayments[0].PaymentSequence)
+
+	sv.DeleteExpiredSlashEvidences(18)
+	assert.NotNil(sv.GetSlashEvidence(addr, 1))
+	assert.Nil(sv.GetSlashEvidence(addr, 2))
+
+	sv.DeleteSlashEvidence(addr, 1)
+	assert.Nil(sv.GetSlashEvidence(addr, 1))
+}
+
+func TestStoreViewSlashPause(t *testing.T) {
+	assert := assert.New(t)
+
+	db := backend.NewMemDatabase()
+	sv := NewStoreView(uint64(10), common.Hash{}, db)
+	_, pubKey, err := crypto.TEST_GenerateKeyPairWithSeed("paused")
+	assert.Nil(err)
+	addr := pubKey.Address()
+
+	_, exists := sv.GetSlashPause(addr)
+	assert.False(exists)
+	assert.False(sv.IsSlashPaused(addr))
+
+	sv.SetSlashPause(addr, 12)
+	endBlockHeight, exists := sv.GetSlashPause(addr)
+	assert.True(exists)
+	assert.Equal(uint64(12), endBlockHeight)
+	assert.True(sv.IsSlashPaused(addr))
+
+	sv.IncrementHeight()
+	assert.True(sv.IsSlashPaused(addr))
+	sv.IncrementHeight()
+	assert.False(sv.IsSlashPaused(addr))
+
+	sv.SetSlashPause(addr, 20)
+	assert.True(sv.IsSlashPaused(addr))
+	sv.DeleteSlashPause(addr)
+	assert.False(sv.IsSlashPaused(addr))
+}
+
+func TestStoreViewGetAccounts(t *testing.T) {
+	assert := assert.New(t)
+
+	db := backend.NewMemDatabase()
+	sv := NewStoreView(uint64(10), common.Hash{}, db)
+	addrs := []common.Address{}
+	for _, seed := range []string{"alice", "bob", "carol"} {
+		_, pubKey, err := crypto.TEST_GenerateKeyPairWithSeed(seed)
+		assert.Nil(err)
+		addrs = append(addrs, pubKey.Address())
+	}
+	alice, bob, carol := addrs[0], addrs[1], addrs[2]
+	sv.SetAccount(alice, &types.Account{Address: alice, Balance: types.NewCoins(0, 100)})
+	sv.SetAccount(bob, &types.Account{Address: bob, Balance: types.NewCoins(0, 200)})
+
+	accounts := sv.GetAccounts([]common.Address{alice, carol, bob, alice})
+	assert.Equal(3, len(accounts))
+	assert.True(types.NewCoins(0, 100).IsEqual(accounts[alice].Balance))
+	assert.True(types.NewCoins(0, 200).IsEqual(accounts[bob].Balance))
+
+	// Missing accounts map to nil
+	carolAccount, ok := accounts[carol]
+	assert.True(ok)
+	assert.Nil(carolAccount)
+
+	assert.Equal(0, len(sv.GetAccounts(nil)))
+}
+
+func TestStoreViewRemoveReservedFund(t *testing.T) {
+	assert := assert.New(t)
+
+	db := backend.NewMemDatabase()
+	sv := NewStoreView(uint64(10), common.Hash{}, db)
+	_, pubKey, err := crypto.TEST_GenerateKeyPairWithSeed("reserver")
+	assert.Nil(err)
+	addr := pubKey.Address()
+
+	res := sv.RemoveReservedFund(addr, 1)
+	assert.True(res.IsError())
+
+	acc := &types.Account{
+		Address: addr,
+		Balance: types.NewCoins(0, 1000),
+		ReservedFunds: []types.ReservedFund{
+			{ReserveSequence: 1, InitialFund: types.NewCoins(0, 10)},
+			{ReserveSequence: 2, InitialFund: types.NewCoins(0, 20)},
+			{ReserveSequence: 3, InitialFund: types.NewCoins(0, 30)},
+		},
+	}
+	sv.SetAccount(addr, acc)
+
+	res = sv.RemoveReservedFund(addr, 2)
+	assert.True(res.IsOK(), res.Message)
+	acc = sv.GetAccount(addr)
+	assert.Equal(2, len(acc.ReservedFunds))
+	assert.Equal(uint64(1), acc.ReservedFunds[0].ReserveSequence)
+	assert.Equal(uint64(3), acc.ReservedFunds[1].ReserveSequence)
+	assert.True(types.NewCoins(0, 1000).IsEqual(acc.Balance))
+
+	// Absent sequences leave the account untouched
+	res = sv.RemoveReservedFund(addr, 2)
+	assert.True(res.IsError())
+	assert.Contains(res.Message, "not found")
+	assert.Equal(2, len(sv.GetAccount(addr).ReservedFunds))
+}
+
+func TestStoreViewAccountSlashFlag(t *testing.T) {
+	assert := assert.New(t)
+
+	db := backend.NewMemDatabase()
+	sv := NewStoreView(uint64(10), common.Hash{}, db)
+	_, pubKey, err := crypto.TEST_GenerateKeyPairWithSeed("flagged")
+	assert.Nil(err)
+	addr := pubKey.Address()
+
+	assert.Nil(sv.GetAccountSlashFlag(addr))
+	assert.False(sv.IsAccountSlashFlagged(addr))
+
+	sv.SetAccountSlashFlag(addr, &types.AccountSlashFlag{Status: types.AccountSlashStatusUnderReview, ClearBlockHeight: 11})
+	flag := sv.GetAccountSlashFlag(addr)
+	assert.NotNil(flag)
+	assert.Equal(types.AccountSlashStatusUnderReview, flag.Status)
+	assert.Equal(uint64(11), flag.ClearBlockHeight)
+	assert.True(sv.IsAccountSlashFlagged(addr))
+
+	// The flag is cleared at the clear height
+	sv.IncrementHeight()
+	assert.False(sv.IsAccountSlashFlagged(addr))
+
+	// A flag without a clear height stays until deleted
+	sv.SetAccountSlashFlag(addr, &types.AccountSlashFlag{Status: types.AccountSlashStatusSlashed})
+	sv.IncrementHeight()
+	assert.True(sv.IsAccountSlashFlagged(addr))
+	sv.DeleteAccountSlashFlag(addr)
+	assert.Nil(sv.GetAccountSlashFlag(addr))
+	assert.False(sv.IsAccountSlashFlagged(addr))
+}
+
+func TestStoreViewReadOnly(t *testing.T) {
+	assert := assert.New(t)
+
+	db := backend.NewMemDatabase()
+	sv := NewStoreView(uint64(1), common.Hash{}, db)
+	_, pubKey, err := crypto.TEST_GenerateKeyPairWithSeed("account")
+	assert.Nil(err)
+	addr := pubKey.Address()
+	sv.SetAccount(addr, types.NewAccount(addr))
+
+	rv := sv.ReadOnlyView()
+	assert.True(rv.IsReadOnly())
+	assert.False(sv.IsReadOnly())
+	assert.NotNil(rv.GetAccount(addr))
+	assert.Equal(sv.Hash(), rv.Hash())
+
+	assert.PanicsWithValue(ErrWriteToReadOnlyView, func() { rv.SetAccount(addr, types.NewAccount(addr)) })
+	assert.PanicsWithValue(ErrWriteToReadOnlyView, func() { rv.DeleteAccount(addr) })
+	assert.PanicsWithValue(ErrWriteToReadOnlyView, func() { rv.AddSlashIntent(types.SlashIntent{}) })
+	assert.PanicsWithValue(ErrWriteToReadOnlyView, func() { rv.DeleteExpiredSplitRules(100) })
+	assert.PanicsWithValue(ErrWriteToReadOnlyView, func() { rv.Save() })
+
+	// The underlying view stays writable
+	sv.DeleteAccount(addr)
+	assert.Nil(rv.GetAccount(addr))
+}
+
 func TestRevertAndPruneStoreView(t *testing.T) {
 	assert := assert.New(t)
 
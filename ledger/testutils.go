@@ -186,6 +186,10 @@ func genSimSnapshot(chainID string, db database.Database) (snapshot mockSnapshot
 }
 
 func newTestLedger() (chainID string, ledger *Ledger, mempool *mp.Mempool) {
+	return newTestLedgerWithValidatorManager(newTesetValidatorManager)
+}
+
+func newTestLedgerWithValidatorManager(newValMgr func(consensus core.ConsensusEngine) core.ValidatorManager) (chainID string, ledger *Ledger, mempool *mp.Mempool) {
 	chainID = "test_chain_id"
 	peerID := "peer0"
 	proposerSeed := "proposer"
@@ -193,11 +197,14 @@ func newTestLedger() (chainID string, ledger *Ledger, mempool *mp.Mempool) {
 	db := backend.NewMemDatabase()
 	chain := &blockchain.Chain{ChainID: chainID}
 	consensus := exec.NewTestConsensusEngine(proposerSeed)
-	valMgr := newTesetValidatorManager(consensus)
+	valMgr := newValMgr(consensus)
 	p2psimnet := p2psim.NewSimnetWithHandler(nil)
 	messenger := p2psimnet.AddEndpoint(peerID)
 	mempool = newTestMempool(peerID, messenger)
-	ledger = NewLedger(chainID, db, chain, consensus, valMgr, mempool)
+	ledger, err := NewLedger(chainID, db, chain, consensus, valMgr, mempool)
+	if err != nil {
+		panic(err)
+	}
 	mempool.SetLedger(ledger)
 
 	ctx := context.Background()
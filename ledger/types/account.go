@@ -84,6 +84,17 @@ func (acc *Account) Copy() *Account {
 	return &accCopy
 }
 
+// Hash returns the hash of the canonical RLP encoding of the account, i.e. its contribution to the
+// state trie. Accounts with equal content hash the same regardless of how they were mutated, e.g. a
+// nil and an empty ReservedFunds slice are encoded identically.
+func (acc *Account) Hash() common.Hash {
+	raw, err := ToBytes(acc)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to encode account %v: %v", acc, err))
+	}
+	return crypto.Keccak256Hash(raw)
+}
+
 func (acc *Account) String() string {
 	if acc == nil {
 		return "nil-Account"
@@ -125,14 +136,15 @@ func (acc *Account) CheckReserveFund(collateral Coins, fund Coins, duration uint
 }
 
 // ReserveFund reserves the given amount of fund for subsequence service payments
-func (acc *Account) ReserveFund(collateral Coins, fund Coins, resourceIDs []string, endBlockHeight uint64, reserveSequence uint64) {
+func (acc *Account) ReserveFund(collateral Coins, fund Coins, resourceIDs []string, startBlockHeight uint64, endBlockHeight uint64, reserveSequence uint64) {
 	newReservedFund := ReservedFund{
-		Collateral:      collateral,
-		InitialFund:     fund,
-		UsedFund:        NewCoins(0, 0),
-		ResourceIDs:     resourceIDs,
-		EndBlockHeight:  endBlockHeight,
-		ReserveSequence: reserveSequence,
+		Collateral:       collateral,
+		InitialFund:      fund,
+		UsedFund:         NewCoins(0, 0),
+		ResourceIDs:      resourceIDs,
+		EndBlockHeight:   endBlockHeight,
+		ReserveSequence:  reserveSequence,
+		StartBlockHeight: startBlockHeight,
 	}
 	acc.ReservedFunds = append(acc.ReservedFunds, newReservedFund)
 	acc.Balance = acc.Balance.Minus(collateral).Minus(fund)
@@ -197,6 +209,39 @@ func (acc *Account) ReleaseFund(currentBlockHeight uint64, reserveSequence uint6
 	}
 }
 
+// FindReservedFund returns the reserved fund with the given reserve sequence, and its index in the reserved
+// funds of the account. Reserve sequences are strictly increasing, but to be on the safe side, ok is false if
+// more than one reserved fund matches, as it is if none does (see CountReservedFunds)
+func (acc *Account) FindReservedFund(reserveSequence uint64) (reservedFund *ReservedFund, idx int, ok bool) {
+	idx = -1
+	for i := range acc.ReservedFunds {
+		if acc.ReservedFunds[i].ReserveSequence != reserveSequence {
+			continue
+		}
+		if reservedFund != nil {
+			return nil, -1, false
+		}
+		reservedFund, idx = &acc.ReservedFunds[i], i
+	}
+	return reservedFund, idx, reservedFund != nil
+}
+
+// HasReservedFund tells whether the account holds any reserved fund with the given reserve sequence
+func (acc *Account) HasReservedFund(reserveSequence uint64) bool {
+	return acc.CountReservedFunds(reserveSequence) > 0
+}
+
+// CountReservedFunds returns the number of reserved funds of the account with the given reserve sequence
+func (acc *Account) CountReservedFunds(reserveSequence uint64) int {
+	count := 0
+	for i := range acc.ReservedFunds {
+		if acc.ReservedFunds[i].ReserveSequence == reserveSequence {
+			count++
+		}
+	}
+	return count
+}
+
 // CheckTransferReservedFund verifies inputs for SplitReservedFund
 func (acc *Account) CheckTransferReservedFund(tgtAcc *Account, transferAmount Coins, paymentSequence uint64, currentBlockHeight uint64, reserveSequence uint64) error {
 	for _, reservedFund := range acc.ReservedFunds {
@@ -321,6 +366,7 @@ func constructOverspendingProof(reservedFund *ReservedFund, currentServicePaymen
 		overspendingProof.ServicePayments = append(overspendingProof.ServicePayments, transferRecord.ServicePayment)
 	}
 	overspendingProof.ServicePayments = append(overspendingProof.ServicePayments, *currentServicePaymentTx)
+	CanonicalizeServicePayments(overspendingProof.ServicePayments)
 	overspendingProofBytes, _ := ToBytes(&overspendingProof)
 	return overspendingProofBytes
 }
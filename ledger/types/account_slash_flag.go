@@ -0,0 +1,72 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// AccountSlashStatus is the status an account is flagged with after it has been slashed
+type AccountSlashStatus uint8
+
+const (
+	AccountSlashStatusNone        AccountSlashStatus = iota // the account is not flagged
+	AccountSlashStatusSlashed                               // the account has been slashed
+	AccountSlashStatusUnderReview                           // the account is under review after a slash
+)
+
+func (s AccountSlashStatus) String() string {
+	switch s {
+	case AccountSlashStatusNone:
+		return "none"
+	case AccountSlashStatusSlashed:
+		return "slashed"
+	case AccountSlashStatusUnderReview:
+		return "under_review"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(s))
+	}
+}
+
+// AccountSlashFlag flags an account after it has been slashed, so that other modules can react, e.g. the
+// account cannot reserve new funds while flagged. The flag is cleared at ClearBlockHeight, or stays until
+// it is explicitly deleted if ClearBlockHeight is zero. Reason records why the account was slashed
+type AccountSlashFlag struct {
+	Status           AccountSlashStatus
+	ClearBlockHeight uint64
+	Reason           SlashReason
+}
+
+type AccountSlashFlagJSON struct {
+	Status           string            `json:"status"`
+	ClearBlockHeight common.JSONUint64 `json:"clear_block_height"`
+	Reason           string            `json:"reason"`
+}
+
+func NewAccountSlashFlagJSON(f AccountSlashFlag) AccountSlashFlagJSON {
+	return AccountSlashFlagJSON{
+		Status:           f.Status.String(),
+		ClearBlockHeight: common.JSONUint64(f.ClearBlockHeight),
+		Reason:           f.Reason.String(),
+	}
+}
+
+func (f AccountSlashFlag) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewAccountSlashFlagJSON(f))
+}
+
+// IsActive returns whether the flag is in effect at the given block height
+func (f *AccountSlashFlag) IsActive(height uint64) bool {
+	if f.Status == AccountSlashStatusNone {
+		return false
+	}
+	return f.ClearBlockHeight == 0 || height < f.ClearBlockHeight
+}
+
+func (f *AccountSlashFlag) String() string {
+	if f == nil {
+		return "nil-AccountSlashFlag"
+	}
+	return fmt.Sprintf("AccountSlashFlag{%v %v %v}", f.Status, f.ClearBlockHeight, f.Reason)
+}
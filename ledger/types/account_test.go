@@ -20,7 +20,7 @@ func makeAccount(secret string, balance Coins) Account {
 func makeAccountAndReserveFund(initialBalance Coins, collateral Coins, fund Coins, resourceID string, endBlockHeight uint64, reserveSequence uint64) Account {
 	acc := makeAccount("srcAcc", initialBalance)
 	resourceIDs := []string{resourceID}
-	acc.ReserveFund(collateral, fund, resourceIDs, endBlockHeight, reserveSequence)
+	acc.ReserveFund(collateral, fund, resourceIDs, 0, endBlockHeight, reserveSequence)
 
 	return acc
 }
@@ -63,6 +63,29 @@ func TestAccountJSON(t *testing.T) {
 	assert.Equal(uint64(math.MaxUint64), acc1.Sequence)
 }
 
+func TestAccountHash(t *testing.T) {
+	assert := assert.New(t)
+
+	acc1 := makeAccount("foo", NewCoins(1000, 20000))
+	acc2 := acc1.Copy()
+	assert.Equal(acc1.Hash(), acc2.Hash())
+
+	// Reserving and then removing a fund restores the hash
+	acc2.ReserveFund(NewCoins(0, 101), NewCoins(0, 100), []string{"rid001"}, 0, 10, 1)
+	assert.NotEqual(acc1.Hash(), acc2.Hash())
+	acc2.ReservedFunds = acc2.ReservedFunds[:0]
+	acc2.Balance = acc2.Balance.Plus(NewCoins(0, 201))
+	assert.Equal(acc1.Hash(), acc2.Hash())
+
+	// Zero coins are encoded the same regardless of the representation
+	acc3 := &Account{Address: acc1.Address, Balance: Coins{}}
+	acc4 := &Account{Address: acc1.Address, Balance: NewCoins(0, 0)}
+	assert.Equal(acc3.Hash(), acc4.Hash())
+
+	acc4.Sequence++
+	assert.NotEqual(acc3.Hash(), acc4.Hash())
+}
+
 func TestNilAccount(t *testing.T) {
 
 	var acc Account
@@ -96,9 +119,9 @@ func TestReleaseExpiredFunds(t *testing.T) {
 	resourceIDs := []string{"rid001"}
 
 	acc := makeAccount("foo", initialBalance)
-	acc.ReserveFund(collateral, fund, resourceIDs, 10, 1)
-	acc.ReserveFund(collateral, fund, resourceIDs, 20, 2)
-	acc.ReserveFund(collateral, fund, resourceIDs, 30, 3)
+	acc.ReserveFund(collateral, fund, resourceIDs, 0, 10, 1)
+	acc.ReserveFund(collateral, fund, resourceIDs, 0, 20, 2)
+	acc.ReserveFund(collateral, fund, resourceIDs, 0, 30, 3)
 
 	acc.ReleaseExpiredFunds(20) // only the first ReservedFund can be released
 	assert.Equal(t, 2, len(acc.ReservedFunds))
@@ -136,6 +159,50 @@ func TestCheckReleaseFund(t *testing.T) {
 	assert.Equal(t, 0, len(acc.ReservedFunds))
 }
 
+func TestFindReservedFund(t *testing.T) {
+	assert := assert.New(t)
+	collateral := NewCoins(0, 101)
+	fund := NewCoins(0, 100)
+	resourceIDs := []string{"rid001"}
+
+	acc := makeAccount("foo", NewCoins(1000, 20000))
+	_, idx, ok := acc.FindReservedFund(1)
+	assert.False(ok)
+	assert.Equal(-1, idx)
+	assert.False(acc.HasReservedFund(1))
+
+	acc.ReserveFund(collateral, fund, resourceIDs, 0, 10, 1)
+	acc.ReserveFund(collateral, fund, resourceIDs, 0, 20, 2)
+	acc.ReserveFund(collateral, fund, resourceIDs, 0, 30, 3)
+
+	// Found, the returned reserved fund is the one held by the account
+	reservedFund, idx, ok := acc.FindReservedFund(2)
+	assert.True(ok)
+	assert.Equal(1, idx)
+	assert.Equal(uint64(20), reservedFund.EndBlockHeight)
+	reservedFund.UsedFund = NewCoins(0, 10)
+	assert.Equal(NewCoins(0, 10), acc.ReservedFunds[1].UsedFund)
+	assert.True(acc.HasReservedFund(2))
+	assert.Equal(1, acc.CountReservedFunds(2))
+
+	// Not found
+	reservedFund, idx, ok = acc.FindReservedFund(4)
+	assert.False(ok)
+	assert.Nil(reservedFund)
+	assert.Equal(-1, idx)
+	assert.False(acc.HasReservedFund(4))
+	assert.Equal(0, acc.CountReservedFunds(4))
+
+	// Multiple reserved funds share the reserve sequence
+	acc.ReservedFunds = append(acc.ReservedFunds, acc.ReservedFunds[2])
+	reservedFund, idx, ok = acc.FindReservedFund(3)
+	assert.False(ok)
+	assert.Nil(reservedFund)
+	assert.Equal(-1, idx)
+	assert.True(acc.HasReservedFund(3))
+	assert.Equal(2, acc.CountReservedFunds(3))
+}
+
 // Test 1: currentBlockHeight > endBlockHeight
 func TestTransferReservedFund1(t *testing.T) {
 	srcAcc, tgtAcc, splitAcc1, _, servicePaymentTx, reserveSequence := prepareForTransferReservedFund()
@@ -125,7 +125,8 @@ func (coinsA Coins) Plus(coinsB Coins) Coins {
 	}
 }
 
-func (coins Coins) Negative() Coins {
+// Negate returns the coins with every denomination negated, e.g. to express a debit as Plus(coins.Negate())
+func (coins Coins) Negate() Coins {
 	c := coins.NoNil()
 
 	theta := new(big.Int)
@@ -140,8 +141,13 @@ func (coins Coins) Negative() Coins {
 	}
 }
 
+// Negative is the same as Negate
+func (coins Coins) Negative() Coins {
+	return coins.Negate()
+}
+
 func (coinsA Coins) Minus(coinsB Coins) Coins {
-	return coinsA.Plus(coinsB.Negative())
+	return coinsA.Plus(coinsB.Negate())
 }
 
 func (coinsA Coins) IsGTE(coinsB Coins) bool {
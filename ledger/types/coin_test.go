@@ -33,6 +33,27 @@ func TestCoins(t *testing.T) {
 	assert.True(NewCoins(8, 25).IsEqual(a.Plus(b)))
 }
 
+func TestCoinsNegate(t *testing.T) {
+	assert := assert.New(t)
+
+	coins := NewCoins(123, -456)
+	neg := coins.Negate()
+	assert.True(NewCoins(-123, 456).IsEqual(neg))
+	assert.True(coins.IsEqual(neg.Negate()))
+	assert.True(coins.Plus(neg).IsZero())
+	assert.True(NewCoins(100, 200).Plus(NewCoins(30, 50).Negate()).IsEqual(NewCoins(70, 150)))
+	assert.True(NewCoins(100, 200).Minus(NewCoins(30, 50)).IsEqual(NewCoins(100, 200).Plus(NewCoins(30, 50).Negate())))
+	assert.True(neg.IsEqual(coins.Negative()))
+
+	// Result should be a copy
+	neg.ThetaWei.SetInt64(789)
+	assert.True(NewCoins(123, -456).IsEqual(coins))
+
+	// Single denomination
+	assert.True(NewCoins(0, -1).IsEqual(NewCoins(0, 1).Negate()))
+	assert.True(NewCoins(-1, 0).IsEqual(Coins{ThetaWei: big.NewInt(1)}.Negate()))
+}
+
 //Test operations on invalid coins
 func TestInvalidCoin(t *testing.T) {
 	assert := assert.New(t)
@@ -67,4 +67,11 @@ const (
 
 	// ReservedFundFreezePeriodDuration indicates the freeze duration (in terms of number of blocks) of the reserved fund
 	ReservedFundFreezePeriodDuration uint64 = 5
+
+	// SlashEvidenceExpiryDuration indicates the duration (in terms of number of blocks) the accumulated slash evidence
+	// stays on-chain after its first submission, if it is not consumed by a SlashTx
+	SlashEvidenceExpiryDuration uint64 = 300
+
+	// MaximumBatchSlashTxEntries indicates the maximum number of slashes a BatchSlashTx can contain
+	MaximumBatchSlashTxEntries int = 32
 )
@@ -0,0 +1,149 @@
+package types
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/thetatoken/theta/common"
+)
+
+// Event describes an effect of an executed transaction, so that block explorers and subscribers can
+// consume it without re-deriving the state diffs. The executors append the events to the StoreView
+// the transaction is executed against. The events are exported in the standard encoding of EncodeEventJSON
+type Event interface {
+	EventType() string
+}
+
+const (
+	EventTypeSlash = "slash"
+)
+
+var _ Event = (*SlashEvent)(nil)
+
+// SlashEvent describes a reserved fund slashed by a SlashTx, and where the slashed amount went
+type SlashEvent struct {
+	TxHash          common.Hash
+	SlashedAddress  common.Address
+	ProposerAddress common.Address
+	ReserveSequence uint64
+	Reason          SlashReason
+	SlashedAmount   Coins          // seized from the reserved fund
+	ReturnedAmount  Coins          // returned to the slashed account
+	BurntAmount     Coins          // share of the slashed amount burnt
+	TreasuryAmount  Coins          // share of the slashed amount credited to the treasury
+	ProposerAmount  Coins          // share of the slashed amount rewarded to the proposer
+	Treasury        common.Address // meaningful only if TreasuryAmount is not zero
+	RewardVested    bool           // whether the proposer reward vests instead of being credited
+}
+
+// EventType implements the Event interface
+func (e *SlashEvent) EventType() string {
+	return EventTypeSlash
+}
+
+// SlashEventJSON is the JSON layout of a SlashEvent. The field names are stable, the indexers rely on them
+type SlashEventJSON struct {
+	TxHash          common.Hash       `json:"tx_hash"`
+	SlashedAddress  common.Address    `json:"slashed_address"`
+	ProposerAddress common.Address    `json:"proposer_address"`
+	ReserveSequence common.JSONUint64 `json:"reserve_sequence"`
+	Reason          SlashReason       `json:"reason"`
+	SlashedAmount   Coins             `json:"slashed_amount"`
+	ReturnedAmount  Coins             `json:"returned_amount"`
+	BurntAmount     Coins             `json:"burnt_amount"`
+	TreasuryAmount  Coins             `json:"treasury_amount"`
+	ProposerAmount  Coins             `json:"proposer_amount"`
+	Treasury        common.Address    `json:"treasury"`
+	RewardVested    bool              `json:"reward_vested"`
+}
+
+func NewSlashEventJSON(e SlashEvent) SlashEventJSON {
+	return SlashEventJSON{
+		TxHash:          e.TxHash,
+		SlashedAddress:  e.SlashedAddress,
+		ProposerAddress: e.ProposerAddress,
+		ReserveSequence: common.JSONUint64(e.ReserveSequence),
+		Reason:          e.Reason,
+		SlashedAmount:   e.SlashedAmount.NoNil(),
+		ReturnedAmount:  e.ReturnedAmount.NoNil(),
+		BurntAmount:     e.BurntAmount.NoNil(),
+		TreasuryAmount:  e.TreasuryAmount.NoNil(),
+		ProposerAmount:  e.ProposerAmount.NoNil(),
+		Treasury:        e.Treasury,
+		RewardVested:    e.RewardVested,
+	}
+}
+
+func (e SlashEventJSON) SlashEvent() SlashEvent {
+	return SlashEvent{
+		TxHash:          e.TxHash,
+		SlashedAddress:  e.SlashedAddress,
+		ProposerAddress: e.ProposerAddress,
+		ReserveSequence: uint64(e.ReserveSequence),
+		Reason:          e.Reason,
+		SlashedAmount:   e.SlashedAmount,
+		ReturnedAmount:  e.ReturnedAmount,
+		BurntAmount:     e.BurntAmount,
+		TreasuryAmount:  e.TreasuryAmount,
+		ProposerAmount:  e.ProposerAmount,
+		Treasury:        e.Treasury,
+		RewardVested:    e.RewardVested,
+	}
+}
+
+func (e SlashEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewSlashEventJSON(e))
+}
+
+func (e *SlashEvent) UnmarshalJSON(data []byte) error {
+	var a SlashEventJSON
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	*e = a.SlashEvent()
+	return nil
+}
+
+// eventEnvelopeJSON is the standard encoding of an event, the same for all the event types:
+//
+//	{"type": "<event type>", "event": {<event fields>}}
+type eventEnvelopeJSON struct {
+	Type  string          `json:"type"`
+	Event json.RawMessage `json:"event"`
+}
+
+// EncodeEventJSON encodes the event in the standard encoding, so that the indexers consume all the event
+// types uniformly, dispatching on the type
+func EncodeEventJSON(event Event) ([]byte, error) {
+	if event == nil {
+		return nil, errors.New("Event is nil")
+	}
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(eventEnvelopeJSON{
+		Type:  event.EventType(),
+		Event: eventBytes,
+	})
+}
+
+// DecodeEventJSON decodes an event in the standard encoding
+func DecodeEventJSON(data []byte) (Event, error) {
+	var envelope eventEnvelopeJSON
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+
+	var event Event
+	switch envelope.Type {
+	case EventTypeSlash:
+		event = &SlashEvent{}
+	default:
+		return nil, errors.Errorf("Unknown event type %v", envelope.Type)
+	}
+	if err := json.Unmarshal(envelope.Event, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
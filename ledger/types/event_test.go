@@ -0,0 +1,66 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+)
+
+func TestSlashEventJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	event := &SlashEvent{
+		TxHash:          common.HexToHash("0x1234"),
+		SlashedAddress:  common.HexToAddress("0x2e833968e5bb786ae419c4d13189fb081cc43bab"),
+		ProposerAddress: common.HexToAddress("0x36bc7b8e7a3c6e5a1d2a0a2de4e2bb18b0ab5efb"),
+		ReserveSequence: 18446744073709551615,
+		Reason:          SlashReasonChannelRevocation,
+		SlashedAmount:   NewCoins(0, 1000),
+		ReturnedAmount:  NewCoins(0, 1),
+		BurntAmount:     NewCoins(0, 500),
+		TreasuryAmount:  NewCoins(0, 400),
+		ProposerAmount:  NewCoins(0, 100),
+		Treasury:        common.HexToAddress("0x9f1233798e905e173560071255140b4a8abd3ec6"),
+		RewardVested:    true,
+	}
+
+	eventBytes, err := EncodeEventJSON(event)
+	assert.Nil(err)
+	expected := `{"type":"slash","event":{` +
+		`"tx_hash":"0x0000000000000000000000000000000000000000000000000000000000001234",` +
+		`"slashed_address":"0x2e833968e5bb786ae419c4d13189fb081cc43bab",` +
+		`"proposer_address":"0x36bc7b8e7a3c6e5a1d2a0a2de4e2bb18b0ab5efb",` +
+		`"reserve_sequence":"18446744073709551615",` +
+		`"reason":"channel_revocation",` +
+		`"slashed_amount":{"thetawei":"0","tfuelwei":"1000"},` +
+		`"returned_amount":{"thetawei":"0","tfuelwei":"1"},` +
+		`"burnt_amount":{"thetawei":"0","tfuelwei":"500"},` +
+		`"treasury_amount":{"thetawei":"0","tfuelwei":"400"},` +
+		`"proposer_amount":{"thetawei":"0","tfuelwei":"100"},` +
+		`"treasury":"0x9f1233798e905e173560071255140b4a8abd3ec6",` +
+		`"reward_vested":true}}`
+	assert.Equal(expected, string(eventBytes))
+
+	decoded, err := DecodeEventJSON(eventBytes)
+	assert.Nil(err)
+	decodedEvent, ok := decoded.(*SlashEvent)
+	if assert.True(ok) {
+		decodedBytes, err := json.Marshal(decodedEvent)
+		assert.Nil(err)
+		eventBytes, err := json.Marshal(event)
+		assert.Nil(err)
+		assert.Equal(eventBytes, decodedBytes)
+	}
+
+	// The missing amounts are encoded as zero
+	eventBytes, err = json.Marshal(&SlashEvent{Reason: SlashReasonOverspending})
+	assert.Nil(err)
+	assert.Contains(string(eventBytes), `"burnt_amount":{"thetawei":"0","tfuelwei":"0"}`)
+
+	_, err = DecodeEventJSON([]byte(`{"type":"unknown","event":{}}`))
+	assert.NotNil(err)
+	_, err = EncodeEventJSON(nil)
+	assert.NotNil(err)
+}
@@ -0,0 +1,208 @@
+package types
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/thetatoken/theta/common"
+)
+
+// WrongReserveSequenceError indicates a service payment is charged against another reserved fund than the
+// one it is verified against
+type WrongReserveSequenceError struct {
+	ReserveSequence         uint64
+	ExpectedReserveSequence uint64
+}
+
+func (e *WrongReserveSequenceError) Error() string {
+	return fmt.Sprintf("Service payment references wrong reserve sequence %v, expected %v",
+		e.ReserveSequence, e.ExpectedReserveSequence)
+}
+
+// UnsupportedSignatureSchemeError indicates a service payment is signed with a signature scheme that is not
+// supported, hence its signer cannot be verified at all
+type UnsupportedSignatureSchemeError struct {
+	SignatureLength int
+}
+
+func (e *UnsupportedSignatureSchemeError) Error() string {
+	return fmt.Sprintf("Service payment uses an unsupported signature scheme, %v-byte signature", e.SignatureLength)
+}
+
+// VerifySlashedServicePayment verifies the service payment comes from the slashed account, is charged against
+// the reserved fund with the given reserve sequence, and is signed by the slashed account
+func VerifySlashedServicePayment(chainID string, slashedAddress common.Address, reserveSequence uint64, servicePaymentTx *ServicePaymentTx) error {
+	if slashedAddress != servicePaymentTx.Source.Address {
+		return errors.Errorf("Service payment does not come from the slashed account %v", slashedAddress)
+	}
+
+	if servicePaymentTx.ReserveSequence != reserveSequence {
+		return &WrongReserveSequenceError{
+			ReserveSequence:         servicePaymentTx.ReserveSequence,
+			ExpectedReserveSequence: reserveSequence,
+		}
+	}
+
+	// Reject the unknown signature schemes before attempting the signer recovery, so that they are told
+	// apart from the payments signed by another account
+	signature := servicePaymentTx.Source.Signature
+	if signature != nil && !signature.IsEmpty() && !signature.HasSupportedScheme() {
+		return &UnsupportedSignatureSchemeError{SignatureLength: len(signature.ToBytes())}
+	}
+
+	// The sign bytes start with the chain ID, so the payments signed for another chain are rejected here,
+	// the chain ID does not need to be carried by the proof
+	sourceSignedBytes := servicePaymentTx.SourceSignBytes(chainID)
+	if !servicePaymentTx.Source.Signature.Verify(sourceSignedBytes, slashedAddress) {
+		return errors.Errorf("Service payment not signed by the slashed account %v for chain %v", slashedAddress, chainID)
+	}
+
+	return nil
+}
+
+// SettlementKey identifies a settlement of a service payment, i.e. a service payment settles at most once
+// per target and payment sequence
+type SettlementKey struct {
+	target          common.Address
+	paymentSequence uint64
+}
+
+// GetSettlementKey returns the key of the settlement of the service payment
+func GetSettlementKey(servicePaymentTx *ServicePaymentTx) SettlementKey {
+	return SettlementKey{target: servicePaymentTx.Target.Address, paymentSequence: servicePaymentTx.PaymentSequence}
+}
+
+// compare orders the settlements by target address, then by payment sequence
+func (k SettlementKey) compare(other SettlementKey) int {
+	if c := bytes.Compare(k.target[:], other.target[:]); c != 0 {
+		return c
+	}
+	switch {
+	case k.paymentSequence < other.paymentSequence:
+		return -1
+	case k.paymentSequence > other.paymentSequence:
+		return 1
+	}
+	return 0
+}
+
+// CanonicalizeServicePayments sorts the service payments of an overspending proof in the canonical order,
+// i.e. by target address, then by payment sequence, in place. The sort is stable, the duplicates are kept
+// next to each other, so that they are still rejected by VerifyOverspendingProof
+func CanonicalizeServicePayments(servicePayments []ServicePaymentTx) {
+	sort.SliceStable(servicePayments, func(i, j int) bool {
+		return GetSettlementKey(&servicePayments[i]).compare(GetSettlementKey(&servicePayments[j])) < 0
+	})
+}
+
+// VerifyOverspendingProof verifies the overspending proof against the account, and tells whether it shows the
+// reserved fund it covers is overspent. An error is returned if the proof is invalid, i.e. the account has no
+// such reserved fund, or a service payment in the proof does not come from the account, is charged against
+// another reserved fund, is not signed by the account, is for a resource the reserved fund does not cover, or
+// is settled more than once, or if the payments are not in the canonical order (see
+// CanonicalizeServicePayments). It lets wallets and watchers validate a proof before broadcasting a SlashTx.
+// The conditions concerning the SlashTx itself, e.g. its proposer, are not checked.
+func VerifyOverspendingProof(chainID string, account *Account, proof OverspendingProof) (bool, error) {
+	return VerifyOverspendingProofWithContext(context.Background(), chainID, account, proof)
+}
+
+// VerifyOverspendingProofWithContext is VerifyOverspendingProof, aborted once the context is done. The
+// context is checked before the signature of each service payment is verified, and the error it reports
+// is the cause of the error returned on abort, e.g. context.DeadlineExceeded
+func VerifyOverspendingProofWithContext(ctx context.Context, chainID string, account *Account, proof OverspendingProof) (bool, error) {
+	if account == nil {
+		return false, errors.New("Account is nil")
+	}
+
+	reservedFund, _, ok := account.FindReservedFund(proof.ReserveSequence)
+	if !ok {
+		if account.CountReservedFunds(proof.ReserveSequence) > 1 {
+			return false, errors.Errorf("Multiple reserved funds found for %v", proof.ReserveSequence)
+		}
+		return false, errors.Errorf("Reserved fund not found for %v", proof.ReserveSequence)
+	}
+
+	var prevKey SettlementKey
+	for idx := range proof.ServicePayments {
+		if err := ctx.Err(); err != nil {
+			return false, errors.Wrapf(err, "Verification aborted at service payment #%v", idx)
+		}
+		servicePaymentTx := &proof.ServicePayments[idx]
+		if err := VerifySlashedServicePayment(chainID, account.Address, proof.ReserveSequence, servicePaymentTx); err != nil {
+			return false, err
+		}
+
+		// Payments for resources the reserved fund does not cover cannot be charged against it, and
+		// hence must not pad the overspending either
+		if len(reservedFund.ResourceIDs) > 0 && !reservedFund.HasResourceID(servicePaymentTx.ResourceID) {
+			return false, errors.Errorf("Service payment #%v is for resource %v which the reserved fund %v does not cover",
+				idx, servicePaymentTx.ResourceID, proof.ReserveSequence)
+		}
+
+		// The payments must be in the canonical order, so that the proof is reproducible byte-for-byte and
+		// a duplicate (a partial payment used as proof) is next to the payment it duplicates
+		key := GetSettlementKey(servicePaymentTx)
+		if idx > 0 {
+			switch key.compare(prevKey) {
+			case 0:
+				return false, errors.Errorf("Service payment #%v is settled more than once", idx)
+			case -1:
+				return false, errors.Errorf("Service payment #%v is out of the canonical order, the payments must be "+
+					"sorted by target address, then by payment sequence", idx)
+			}
+		}
+		prevKey = key
+	}
+
+	thetaOverspent, tfuelOverspent := reservedFund.OverspentDenoms(proof.ServicePayments)
+	return thetaOverspent || tfuelOverspent, nil
+}
+
+// NewOverspendingProof builds an overspending proof of the reserved fund with the given reserve sequence
+// and initial fund. An error is returned if there is no service payment, if a service payment is charged
+// against another reserved fund, carries no source signature, or is settled more than once, or if the
+// payments together do not exceed the initial fund in any denomination. The payments are copied and put in
+// the canonical order (see CanonicalizeServicePayments). The signers are not verified, since the slashed
+// account is not known here, VerifyOverspendingProof checks them against the account
+func NewOverspendingProof(reserveSequence uint64, servicePayments []ServicePaymentTx, initialFund Coins) (*OverspendingProof, error) {
+	if len(servicePayments) == 0 {
+		return nil, errors.New("No service payment to prove the overspending with")
+	}
+
+	payments := make([]ServicePaymentTx, len(servicePayments))
+	copy(payments, servicePayments)
+	CanonicalizeServicePayments(payments)
+
+	for idx := range payments {
+		servicePaymentTx := &payments[idx]
+		if servicePaymentTx.ReserveSequence != reserveSequence {
+			return nil, &WrongReserveSequenceError{
+				ReserveSequence:         servicePaymentTx.ReserveSequence,
+				ExpectedReserveSequence: reserveSequence,
+			}
+		}
+		if servicePaymentTx.Source.Signature == nil || servicePaymentTx.Source.Signature.IsEmpty() {
+			return nil, errors.Errorf("Service payment to %v with payment sequence %v has no source signature",
+				servicePaymentTx.Target.Address, servicePaymentTx.PaymentSequence)
+		}
+		if idx > 0 && GetSettlementKey(servicePaymentTx).compare(GetSettlementKey(&payments[idx-1])) == 0 {
+			return nil, errors.Errorf("Service payment to %v with payment sequence %v is settled more than once",
+				servicePaymentTx.Target.Address, servicePaymentTx.PaymentSequence)
+		}
+	}
+
+	totalSpent := sumServicePayments(payments)
+	thetaOverspent, tfuelOverspent := IsOverspent(initialFund, totalSpent)
+	if !thetaOverspent && !tfuelOverspent {
+		return nil, errors.Errorf("Service payments total %v, which does not exceed the initial fund %v",
+			totalSpent, initialFund.NoNil())
+	}
+
+	return &OverspendingProof{
+		ReserveSequence: reserveSequence,
+		ServicePayments: payments,
+	}, nil
+}
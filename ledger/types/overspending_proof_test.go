@@ -0,0 +1,359 @@
+package types
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/crypto"
+)
+
+func signedServicePayment(chainID string, source, target *PrivAccount, signer *PrivAccount, amount int64, paymentSeq, reserveSeq uint64, resourceID string) ServicePaymentTx {
+	tx := ServicePaymentTx{
+		Fee:             NewCoins(0, 0),
+		Source:          TxInput{Address: source.Account.Address, Coins: NewCoins(0, amount)},
+		Target:          TxInput{Address: target.Account.Address},
+		PaymentSequence: paymentSeq,
+		ReserveSequence: reserveSeq,
+		ResourceID:      resourceID,
+	}
+	tx.Source.Signature = signer.Sign(tx.SourceSignBytes(chainID))
+	return tx
+}
+
+func TestVerifyOverspendingProof(t *testing.T) {
+	assert := assert.New(t)
+
+	chainID := "test_chain_id"
+	alice := MakeAcc("User Alice")
+	bob := MakeAcc("User Bob")
+	carol := MakeAcc("User Carol")
+
+	account := alice.Account
+	account.ReservedFunds = []ReservedFund{{
+		Collateral:      NewCoins(0, 1001),
+		InitialFund:     NewCoins(0, 1000),
+		UsedFund:        NewCoins(0, 0),
+		ResourceIDs:     []string{"rid001"},
+		ReserveSequence: 1,
+	}}
+
+	payment := func(target *PrivAccount, amount int64, paymentSeq uint64) ServicePaymentTx {
+		return signedServicePayment(chainID, &alice, target, &alice, amount, paymentSeq, 1, "rid001")
+	}
+
+	// A 96-byte signature, e.g. of an aggregatable scheme the ledger does not support
+	unsupportedSchemePayment := payment(&carol, 600, 1)
+	unsupportedSchemePayment.Source.Signature, _ = crypto.SignatureFromBytes(make([]byte, 96))
+
+	testCases := []struct {
+		name      string
+		proof     OverspendingProof
+		overspent bool
+		err       string
+	}{
+		{
+			name:      "overspent",
+			proof:     OverspendingProof{ReserveSequence: 1, ServicePayments: []ServicePaymentTx{payment(&bob, 600, 1), payment(&carol, 600, 1)}},
+			overspent: true,
+		},
+		{
+			name:      "not overspent",
+			proof:     OverspendingProof{ReserveSequence: 1, ServicePayments: []ServicePaymentTx{payment(&bob, 600, 1)}},
+			overspent: false,
+		},
+		{
+			name: "foreign source",
+			proof: OverspendingProof{ReserveSequence: 1, ServicePayments: []ServicePaymentTx{
+				payment(&bob, 600, 1), signedServicePayment(chainID, &carol, &bob, &carol, 600, 2, 1, "rid001")}},
+			err: "does not come from the slashed account",
+		},
+		{
+			name: "mismatched reserve sequence",
+			proof: OverspendingProof{ReserveSequence: 1, ServicePayments: []ServicePaymentTx{
+				payment(&bob, 600, 1), signedServicePayment(chainID, &alice, &carol, &alice, 600, 1, 2, "rid001")}},
+			err: "wrong reserve sequence",
+		},
+		{
+			name: "bad signature",
+			proof: OverspendingProof{ReserveSequence: 1, ServicePayments: []ServicePaymentTx{
+				payment(&bob, 600, 1), signedServicePayment(chainID, &alice, &carol, &bob, 600, 1, 1, "rid001")}},
+			err: "not signed by the slashed account",
+		},
+		{
+			name: "foreign chain",
+			proof: OverspendingProof{ReserveSequence: 1, ServicePayments: []ServicePaymentTx{
+				payment(&bob, 600, 1), signedServicePayment("other_chain_id", &alice, &carol, &alice, 600, 1, 1, "rid001")}},
+			err: "not signed by the slashed account " + alice.Address.String() + " for chain " + chainID,
+		},
+		{
+			name: "unsupported signature scheme",
+			proof: OverspendingProof{ReserveSequence: 1, ServicePayments: []ServicePaymentTx{
+				payment(&bob, 600, 1), unsupportedSchemePayment}},
+			err: "unsupported signature scheme",
+		},
+		{
+			name:  "duplicate payment",
+			proof: OverspendingProof{ReserveSequence: 1, ServicePayments: []ServicePaymentTx{payment(&bob, 600, 1), payment(&bob, 600, 1)}},
+			err:   "settled more than once",
+		},
+		{
+			name: "uncovered resource",
+			proof: OverspendingProof{ReserveSequence: 1, ServicePayments: []ServicePaymentTx{
+				payment(&bob, 600, 1), signedServicePayment(chainID, &alice, &carol, &alice, 600, 1, 1, "rid002")}},
+			err: "does not cover",
+		},
+		{
+			name:  "unknown reserved fund",
+			proof: OverspendingProof{ReserveSequence: 2},
+			err:   "Reserved fund not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		overspent, err := VerifyOverspendingProof(chainID, &account, tc.proof)
+		if tc.err == "" {
+			assert.Nil(err, tc.name)
+			assert.Equal(tc.overspent, overspent, tc.name)
+			continue
+		}
+		if assert.NotNil(err, tc.name) {
+			assert.Contains(err.Error(), tc.err, tc.name)
+		}
+		assert.False(overspent, tc.name)
+	}
+
+	_, err := VerifyOverspendingProof(chainID, nil, OverspendingProof{ReserveSequence: 1})
+	assert.NotNil(err)
+}
+
+// countdownContext is cancelled once its Err method has been called the given number of times
+type countdownContext struct {
+	context.Context
+	remaining int
+}
+
+func (ctx *countdownContext) Err() error {
+	if ctx.remaining <= 0 {
+		return context.Canceled
+	}
+	ctx.remaining--
+	return nil
+}
+
+func TestVerifyOverspendingProofWithContext(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	chainID := "test_chain_id"
+	alice := MakeAcc("User Alice")
+	bob := MakeAcc("User Bob")
+
+	account := alice.Account
+	account.ReservedFunds = []ReservedFund{{
+		Collateral:      NewCoins(0, 1001),
+		InitialFund:     NewCoins(0, 1000),
+		UsedFund:        NewCoins(0, 0),
+		ResourceIDs:     []string{"rid001"},
+		ReserveSequence: 1,
+	}}
+	proof := OverspendingProof{ReserveSequence: 1}
+	for paymentSeq := uint64(1); paymentSeq <= 50; paymentSeq++ {
+		proof.ServicePayments = append(proof.ServicePayments,
+			signedServicePayment(chainID, &alice, &bob, &alice, 100, paymentSeq, 1, "rid001"))
+	}
+
+	overspent, err := VerifyOverspendingProofWithContext(context.Background(), chainID, &account, proof)
+	require.Nil(err)
+	assert.True(overspent)
+
+	// The verification stops at the payment it is cancelled at
+	ctx := &countdownContext{Context: context.Background(), remaining: 10}
+	overspent, err = VerifyOverspendingProofWithContext(ctx, chainID, &account, proof)
+	require.NotNil(err)
+	assert.False(overspent)
+	assert.Equal(context.Canceled, errors.Cause(err))
+	assert.Contains(err.Error(), "service payment #10")
+
+	expiredCtx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	_, err = VerifyOverspendingProofWithContext(expiredCtx, chainID, &account, proof)
+	assert.Equal(context.DeadlineExceeded, errors.Cause(err))
+}
+
+func TestOverspendingProofCanonicalOrder(t *testing.T) {
+	assert := assert.New(t)
+
+	chainID := "test_chain_id"
+	alice := MakeAcc("User Alice")
+	bob := MakeAcc("User Bob")
+	carol := MakeAcc("User Carol")
+
+	account := alice.Account
+	account.ReservedFunds = []ReservedFund{{
+		Collateral:      NewCoins(0, 1001),
+		InitialFund:     NewCoins(0, 1000),
+		UsedFund:        NewCoins(0, 0),
+		ResourceIDs:     []string{"rid001"},
+		ReserveSequence: 1,
+	}}
+
+	payments := []ServicePaymentTx{
+		signedServicePayment(chainID, &alice, &bob, &alice, 400, 1, 1, "rid001"),
+		signedServicePayment(chainID, &alice, &bob, &alice, 400, 2, 1, "rid001"),
+		signedServicePayment(chainID, &alice, &carol, &alice, 400, 1, 1, "rid001"),
+	}
+	CanonicalizeServicePayments(payments)
+	for i := 1; i < len(payments); i++ {
+		assert.True(GetSettlementKey(&payments[i-1]).compare(GetSettlementKey(&payments[i])) < 0)
+	}
+	canonicalProof := OverspendingProof{ReserveSequence: 1, ServicePayments: payments}
+	overspent, err := VerifyOverspendingProof(chainID, &account, canonicalProof)
+	assert.Nil(err)
+	assert.True(overspent)
+	canonicalBytes, err := ToBytes(&canonicalProof)
+	assert.Nil(err)
+
+	// Every reordering of the payments is rejected, and canonicalizes to the same proof
+	for _, order := range [][]int{{0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}} {
+		shuffled := make([]ServicePaymentTx, len(payments))
+		for i, idx := range order {
+			shuffled[i] = payments[idx]
+		}
+		shuffledProof := OverspendingProof{ReserveSequence: 1, ServicePayments: shuffled}
+		overspent, err := VerifyOverspendingProof(chainID, &account, shuffledProof)
+		if assert.NotNil(err, "%v", order) {
+			assert.Contains(err.Error(), "canonical order")
+		}
+		assert.False(overspent)
+
+		CanonicalizeServicePayments(shuffledProof.ServicePayments)
+		shuffledBytes, err := ToBytes(&shuffledProof)
+		assert.Nil(err)
+		assert.Equal(canonicalBytes, shuffledBytes, "%v", order)
+	}
+
+	// Duplicates stay next to each other once canonicalized, and are rejected as such
+	duplicated := []ServicePaymentTx{payments[0], payments[2], payments[0]}
+	CanonicalizeServicePayments(duplicated)
+	_, err = VerifyOverspendingProof(chainID, &account, OverspendingProof{ReserveSequence: 1, ServicePayments: duplicated})
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "settled more than once")
+	}
+}
+
+func TestNewOverspendingProof(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	chainID := "test_chain_id"
+	alice := MakeAcc("User Alice")
+	bob := MakeAcc("User Bob")
+	carol := MakeAcc("User Carol")
+
+	account := alice.Account
+	account.ReservedFunds = []ReservedFund{{
+		Collateral:      NewCoins(0, 1001),
+		InitialFund:     NewCoins(0, 1000),
+		UsedFund:        NewCoins(0, 0),
+		ResourceIDs:     []string{"rid001"},
+		ReserveSequence: 1,
+	}}
+	initialFund := account.ReservedFunds[0].InitialFund
+
+	payment := func(target *PrivAccount, amount int64, paymentSeq uint64) ServicePaymentTx {
+		return signedServicePayment(chainID, &alice, target, &alice, amount, paymentSeq, 1, "rid001")
+	}
+
+	// Well-formed, the payments are put in the canonical order and the caller's slice is left untouched
+	payments := []ServicePaymentTx{payment(&carol, 600, 1), payment(&bob, 600, 1)}
+	proof, err := NewOverspendingProof(1, payments, initialFund)
+	require.Nil(err)
+	assert.Equal(uint64(1), proof.ReserveSequence)
+	assert.Nil(proof.GetTimestamp())
+	require.Equal(2, len(proof.ServicePayments))
+	assert.True(GetSettlementKey(&proof.ServicePayments[0]).compare(GetSettlementKey(&proof.ServicePayments[1])) < 0)
+	assert.Equal(carol.Account.Address, payments[0].Target.Address)
+
+	overspent, err := VerifyOverspendingProof(chainID, &account, *proof)
+	assert.Nil(err)
+	assert.True(overspent)
+
+	unsignedPayment := payment(&carol, 600, 2)
+	unsignedPayment.Source.Signature = nil
+
+	testCases := []struct {
+		name     string
+		payments []ServicePaymentTx
+		err      string
+	}{
+		{
+			name: "no payment",
+			err:  "No service payment",
+		},
+		{
+			name: "mismatched reserve sequence",
+			payments: []ServicePaymentTx{
+				payment(&bob, 600, 1), signedServicePayment(chainID, &alice, &carol, &alice, 600, 1, 2, "rid001")},
+			err: "wrong reserve sequence",
+		},
+		{
+			name:     "missing signature",
+			payments: []ServicePaymentTx{payment(&bob, 600, 1), unsignedPayment},
+			err:      "has no source signature",
+		},
+		{
+			name:     "duplicated payment",
+			payments: []ServicePaymentTx{payment(&bob, 600, 1), payment(&bob, 600, 1)},
+			err:      "settled more than once",
+		},
+		{
+			name:     "not exceeding the initial fund",
+			payments: []ServicePaymentTx{payment(&bob, 500, 1), payment(&carol, 500, 1)},
+			err:      "does not exceed the initial fund",
+		},
+	}
+
+	for _, tc := range testCases {
+		proof, err := NewOverspendingProof(1, tc.payments, initialFund)
+		assert.Nil(proof, tc.name)
+		if assert.NotNil(err, tc.name) {
+			assert.Contains(err.Error(), tc.err, tc.name)
+		}
+	}
+}
+
+func TestNewOverspendingProofRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	chainID := "test_chain_id"
+	alice := MakeAcc("User Alice")
+	bob := MakeAcc("User Bob")
+	carol := MakeAcc("User Carol")
+
+	proof, err := NewOverspendingProof(1, []ServicePaymentTx{
+		signedServicePayment(chainID, &alice, &bob, &alice, 600, 1, 1, "rid001"),
+		signedServicePayment(chainID, &alice, &carol, &alice, 600, 1, 1, "rid001"),
+	}, NewCoins(0, 1000))
+	require.Nil(err)
+
+	proofBytes, err := ToBytes(proof)
+	require.Nil(err)
+	decoded := &OverspendingProof{}
+	require.Nil(FromBytes(proofBytes, decoded))
+
+	assert.Equal(proof.ReserveSequence, decoded.ReserveSequence)
+	require.Equal(len(proof.ServicePayments), len(decoded.ServicePayments))
+	for idx := range proof.ServicePayments {
+		assert.Equal(proof.ServicePayments[idx].Target.Address, decoded.ServicePayments[idx].Target.Address)
+		assert.Equal(proof.ServicePayments[idx].PaymentSequence, decoded.ServicePayments[idx].PaymentSequence)
+		assert.Equal(proof.ServicePayments[idx].Source.Signature.ToBytes(), decoded.ServicePayments[idx].Source.Signature.ToBytes())
+	}
+
+	decodedBytes, err := ToBytes(decoded)
+	require.Nil(err)
+	assert.Equal(proofBytes, decodedBytes)
+}
@@ -2,57 +2,173 @@ package types
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
 	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/rlp"
 )
 
 type TransferRecord struct {
 	ServicePayment ServicePaymentTx `json:"service_payment"`
 }
 
+// ResourceCap caps the amount of the reserved fund that can be spent on a resource
+type ResourceCap struct {
+	ResourceID string `json:"resource_id"`
+	Cap        Coins  `json:"cap"`
+}
+
 type ReservedFund struct {
+	Collateral       Coins
+	InitialFund      Coins
+	UsedFund         Coins
+	ResourceIDs      []string // List of resource ID
+	EndBlockHeight   uint64
+	ReserveSequence  uint64           // sequence number of the corresponding ReserveFundTx transaction
+	TransferRecords  []TransferRecord // signed ServerPaymentTransactions
+	StartBlockHeight uint64           // block height at which the fund was reserved, zero if it was reserved before the height was recorded
+	ResourceCaps     []ResourceCap    // optional per-resource spending caps, a trailing field
+	ServiceType      string           // optional tag of the type of service the fund is reserved for, encoded after the caps
+}
+
+// reservedFundRLP is the RLP layout of a ReservedFund. The reserved funds stored before StartBlockHeight was
+// recorded have the first seven fields only, hence the StartBlockHeight is an optional trailing field: it
+// leads the tail, and is omitted along with the rest of the tail if it is zero and the tail is empty, so
+// that the encoding of these reserved funds is unchanged
+type reservedFundRLP struct {
 	Collateral      Coins
 	InitialFund     Coins
 	UsedFund        Coins
-	ResourceIDs     []string // List of resource ID
+	ResourceIDs     []string
 	EndBlockHeight  uint64
-	ReserveSequence uint64           // sequence number of the corresponding ReserveFundTx transaction
-	TransferRecords []TransferRecord // signed ServerPaymentTransactions
-}
-
-type ReservedFundJSON struct {
-	Collateral      Coins             `json:"collateral"`
-	InitialFund     Coins             `json:"initial_fund"`
-	UsedFund        Coins             `json:"used_fund"`
-	ResourceIDs     []string          `json:"resource_ids"` // List of resource ID
-	EndBlockHeight  common.JSONUint64 `json:"end_block_height"`
-	ReserveSequence common.JSONUint64 `json:"reserve_sequence"` // sequence number of the corresponding ReserveFundTx transaction
-	TransferRecords []TransferRecord  `json:"transfer_records"` // signed ServerPaymentTransactions
+	ReserveSequence uint64
+	TransferRecords []TransferRecord
+	Tail            []rlp.RawValue `rlp:"tail"` // the start block height, then the resource caps and the service type
 }
 
-func NewReservedFundJSON(resv ReservedFund) ReservedFundJSON {
-	return ReservedFundJSON{
+// EncodeRLP implements rlp.Encoder.
+func (resv ReservedFund) EncodeRLP(w io.Writer) error {
+	var elems []interface{}
+	for _, resourceCap := range resv.ResourceCaps {
+		elems = append(elems, resourceCap)
+	}
+	tail, err := encodeTaggedTail(elems, resv.ServiceType)
+	if err != nil {
+		return err
+	}
+	if resv.StartBlockHeight != 0 || len(tail) > 0 {
+		raw, err := rlp.EncodeToBytes(resv.StartBlockHeight)
+		if err != nil {
+			return err
+		}
+		tail = append([]rlp.RawValue{raw}, tail...)
+	}
+	return rlp.Encode(w, reservedFundRLP{
 		Collateral:      resv.Collateral,
 		InitialFund:     resv.InitialFund,
 		UsedFund:        resv.UsedFund,
 		ResourceIDs:     resv.ResourceIDs,
-		EndBlockHeight:  common.JSONUint64(resv.EndBlockHeight),
-		ReserveSequence: common.JSONUint64(resv.ReserveSequence),
+		EndBlockHeight:  resv.EndBlockHeight,
+		ReserveSequence: resv.ReserveSequence,
 		TransferRecords: resv.TransferRecords,
+		Tail:            tail,
+	})
+}
+
+// DecodeRLP implements rlp.Decoder.
+func (resv *ReservedFund) DecodeRLP(s *rlp.Stream) error {
+	var dec reservedFundRLP
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+	startBlockHeight := uint64(0)
+	tail := dec.Tail
+	if len(tail) > 0 {
+		if err := rlp.DecodeBytes(tail[0], &startBlockHeight); err != nil {
+			return errors.Wrap(err, "rlp: invalid start block height of the reserved fund")
+		}
+		tail = tail[1:]
+	}
+	resourceCaps, serviceType, err := decodeResourceCapsTail(tail)
+	if err != nil {
+		return err
+	}
+	*resv = ReservedFund{
+		Collateral:       dec.Collateral,
+		InitialFund:      dec.InitialFund,
+		UsedFund:         dec.UsedFund,
+		ResourceIDs:      dec.ResourceIDs,
+		EndBlockHeight:   dec.EndBlockHeight,
+		ReserveSequence:  dec.ReserveSequence,
+		TransferRecords:  dec.TransferRecords,
+		StartBlockHeight: startBlockHeight,
+		ResourceCaps:     resourceCaps,
+		ServiceType:      serviceType,
+	}
+	return nil
+}
+
+// decodeResourceCapsTail decodes the resource caps and the service type trailing a ReservedFund or a ReserveFundTx
+func decodeResourceCapsTail(tail []rlp.RawValue) ([]ResourceCap, string, error) {
+	resourceCaps := make([]ResourceCap, 0, len(tail))
+	serviceType, err := decodeTaggedTail(tail, func(raw rlp.RawValue) error {
+		var resourceCap ResourceCap
+		if err := rlp.DecodeBytes(raw, &resourceCap); err != nil {
+			return err
+		}
+		resourceCaps = append(resourceCaps, resourceCap)
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return resourceCaps, serviceType, nil
+}
+
+type ReservedFundJSON struct {
+	Collateral       Coins             `json:"collateral"`
+	InitialFund      Coins             `json:"initial_fund"`
+	UsedFund         Coins             `json:"used_fund"`
+	ResourceIDs      []string          `json:"resource_ids"` // List of resource ID
+	EndBlockHeight   common.JSONUint64 `json:"end_block_height"`
+	ReserveSequence  common.JSONUint64 `json:"reserve_sequence"`   // sequence number of the corresponding ReserveFundTx transaction
+	TransferRecords  []TransferRecord  `json:"transfer_records"`   // signed ServerPaymentTransactions
+	StartBlockHeight common.JSONUint64 `json:"start_block_height"` // block height at which the fund was reserved
+	ResourceCaps     []ResourceCap     `json:"resource_caps,omitempty"`
+	ServiceType      string            `json:"service_type,omitempty"`
+}
+
+func NewReservedFundJSON(resv ReservedFund) ReservedFundJSON {
+	return ReservedFundJSON{
+		Collateral:       resv.Collateral,
+		InitialFund:      resv.InitialFund,
+		UsedFund:         resv.UsedFund,
+		ResourceIDs:      resv.ResourceIDs,
+		EndBlockHeight:   common.JSONUint64(resv.EndBlockHeight),
+		ReserveSequence:  common.JSONUint64(resv.ReserveSequence),
+		TransferRecords:  resv.TransferRecords,
+		StartBlockHeight: common.JSONUint64(resv.StartBlockHeight),
+		ResourceCaps:     resv.ResourceCaps,
+		ServiceType:      resv.ServiceType,
 	}
 }
 
 func (resv ReservedFundJSON) ReservedFund() ReservedFund {
 	return ReservedFund{
-		Collateral:      resv.Collateral,
-		InitialFund:     resv.InitialFund,
-		UsedFund:        resv.UsedFund,
-		ResourceIDs:     resv.ResourceIDs,
-		EndBlockHeight:  uint64(resv.EndBlockHeight),
-		ReserveSequence: uint64(resv.ReserveSequence),
-		TransferRecords: resv.TransferRecords,
+		Collateral:       resv.Collateral,
+		InitialFund:      resv.InitialFund,
+		UsedFund:         resv.UsedFund,
+		ResourceIDs:      resv.ResourceIDs,
+		EndBlockHeight:   uint64(resv.EndBlockHeight),
+		ReserveSequence:  uint64(resv.ReserveSequence),
+		TransferRecords:  resv.TransferRecords,
+		StartBlockHeight: uint64(resv.StartBlockHeight),
+		ResourceCaps:     resv.ResourceCaps,
+		ServiceType:      resv.ServiceType,
 	}
 }
 
@@ -69,6 +185,24 @@ func (resv *ReservedFund) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Copy returns a deep copy of the reserved fund, which shares nothing with the original one, e.g. to keep
+// a snapshot of it while the account holding it is mutated. The copy goes through the RLP encoding, so the
+// nil values come back as the state would decode them, e.g. as zero coins
+func (reservedFund *ReservedFund) Copy() *ReservedFund {
+	if reservedFund == nil {
+		return nil
+	}
+	raw, err := ToBytes(reservedFund)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to encode reserved fund %v: %v", reservedFund.ReserveSequence, err))
+	}
+	reservedFundCopy := &ReservedFund{}
+	if err := FromBytes(raw, reservedFundCopy); err != nil {
+		panic(fmt.Sprintf("Failed to decode reserved fund %v: %v", reservedFund.ReserveSequence, err))
+	}
+	return reservedFundCopy
+}
+
 // TODO: this implementation is not very efficient
 func (reservedFund *ReservedFund) VerifyPaymentSequence(targetAddress common.Address, paymentSequence uint64) error {
 	currentPaymentSequence := uint64(0)
@@ -95,6 +229,11 @@ func (reservedFund *ReservedFund) RecordTransfer(serverPaymentTx *ServicePayment
 	reservedFund.TransferRecords = append(reservedFund.TransferRecords, transferRecord)
 }
 
+// MinimumReleaseBlockHeight returns the block height from which the reserved fund can be released
+func (reservedFund *ReservedFund) MinimumReleaseBlockHeight() uint64 {
+	return calcMinimumReleaseBlockHeight(reservedFund)
+}
+
 func (reservedFund *ReservedFund) HasResourceID(resourceID string) bool {
 	for _, rid := range reservedFund.ResourceIDs {
 		if strings.Compare(rid, resourceID) == 0 {
@@ -103,3 +242,183 @@ func (reservedFund *ReservedFund) HasResourceID(resourceID string) bool {
 	}
 	return false
 }
+
+// GetResourceCap returns the spending cap of the given resource, if any
+func (reservedFund *ReservedFund) GetResourceCap(resourceID string) (Coins, bool) {
+	for _, resourceCap := range reservedFund.ResourceCaps {
+		if resourceCap.ResourceID == resourceID {
+			return resourceCap.Cap, true
+		}
+	}
+	return Coins{}, false
+}
+
+// CheckResourceCaps verifies the spending caps of the resources a fund is reserved for
+func CheckResourceCaps(resourceIDs []string, resourceCaps []ResourceCap) error {
+	cappedResources := make(map[string]bool)
+	for _, resourceCap := range resourceCaps {
+		found := false
+		for _, rid := range resourceIDs {
+			if rid == resourceCap.ResourceID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("Resource %v is capped but not reserved for", resourceCap.ResourceID)
+		}
+		if cappedResources[resourceCap.ResourceID] {
+			return errors.Errorf("Resource %v is capped more than once", resourceCap.ResourceID)
+		}
+		cappedResources[resourceCap.ResourceID] = true
+
+		if !resourceCap.Cap.IsValid() || !resourceCap.Cap.IsNonnegative() {
+			return errors.Errorf("Invalid cap for resource %v", resourceCap.ResourceID)
+		}
+	}
+	return nil
+}
+
+// MaxServiceTypeLength is the maximum length of the service type tag of a reserved fund
+const MaxServiceTypeLength = 64
+
+// CheckServiceType verifies the service type tag a fund is reserved with. The tag is optional
+func CheckServiceType(serviceType string) error {
+	if len(serviceType) > MaxServiceTypeLength {
+		return errors.Errorf("Service type is %v bytes long, longer than the maximum of %v",
+			len(serviceType), MaxServiceTypeLength)
+	}
+	return nil
+}
+
+// MatchesServiceType tells whether the reserved fund is tagged with the service type. Any reserved fund
+// matches an empty service type
+func (reservedFund *ReservedFund) MatchesServiceType(serviceType string) bool {
+	return serviceType == "" || reservedFund.ServiceType == serviceType
+}
+
+// IsSettled checks whether the service payment has already been settled against the reserved fund
+func (reservedFund *ReservedFund) IsSettled(servicePaymentTx *ServicePaymentTx) bool {
+	for _, transferRecord := range reservedFund.TransferRecords {
+		settledPayment := transferRecord.ServicePayment
+		if settledPayment.Target.Address == servicePaymentTx.Target.Address &&
+			settledPayment.PaymentSequence == servicePaymentTx.PaymentSequence {
+			return true
+		}
+	}
+	return false
+}
+
+// unsettledServicePayments returns the service payments not yet settled against the reserved fund
+func (reservedFund *ReservedFund) unsettledServicePayments(servicePayments []ServicePaymentTx) []ServicePaymentTx {
+	unsettled := []ServicePaymentTx{}
+	for idx := range servicePayments {
+		if !reservedFund.IsSettled(&servicePayments[idx]) {
+			unsettled = append(unsettled, servicePayments[idx])
+		}
+	}
+	return unsettled
+}
+
+// FundIntendedToSpend sums up the fund already used by the settled payments and the fund claimed by the
+// unsettled service payments. The settled payments included in the proof are only counted once, as used fund.
+func (reservedFund *ReservedFund) FundIntendedToSpend(servicePayments []ServicePaymentTx) Coins {
+	usedFund := reservedFund.UsedFund.NoNil()
+	return usedFund.Plus(sumServicePayments(reservedFund.unsettledServicePayments(servicePayments)))
+}
+
+// fundIntendedToSpendPerResource is the per resource ID counterpart of FundIntendedToSpend
+func (reservedFund *ReservedFund) fundIntendedToSpendPerResource(servicePayments []ServicePaymentTx) map[string]Coins {
+	payments := reservedFund.unsettledServicePayments(servicePayments)
+	for _, transferRecord := range reservedFund.TransferRecords {
+		payments = append(payments, transferRecord.ServicePayment)
+	}
+	return sumServicePaymentsPerResource(payments)
+}
+
+// OverspentDenoms determines in which denominations the service payments overspend the reserved fund,
+// either in total, or for a resource whose spending is capped by the reserved fund. Both the payments
+// already settled against the reserved fund and the unsettled ones are taken into account.
+func (reservedFund *ReservedFund) OverspentDenoms(servicePayments []ServicePaymentTx) (thetaOverspent, tfuelOverspent bool) {
+	thetaOverspent, tfuelOverspent = IsOverspent(reservedFund.InitialFund, reservedFund.FundIntendedToSpend(servicePayments))
+	if len(reservedFund.ResourceCaps) == 0 {
+		return thetaOverspent, tfuelOverspent
+	}
+
+	for resourceID, fundIntendedToSpend := range reservedFund.fundIntendedToSpendPerResource(servicePayments) {
+		resourceCap, capped := reservedFund.GetResourceCap(resourceID)
+		if !capped {
+			continue
+		}
+		thetaOverspentOnResource, tfuelOverspentOnResource := IsOverspent(resourceCap, fundIntendedToSpend)
+		thetaOverspent = thetaOverspent || thetaOverspentOnResource
+		tfuelOverspent = tfuelOverspent || tfuelOverspentOnResource
+	}
+	return thetaOverspent, tfuelOverspent
+}
+
+// OverspentAmount is the amount by which the service payments overspend the reserved fund in each
+// denomination, i.e. the largest excess of the fund intended to spend over either the initial fund or the
+// cap of a resource. It is zero in the denominations the reserved fund is not overspent in, see
+// OverspentDenoms.
+func (reservedFund *ReservedFund) OverspentAmount(servicePayments []ServicePaymentTx) Coins {
+	overspentAmount := excessOver(reservedFund.InitialFund, reservedFund.FundIntendedToSpend(servicePayments))
+	if len(reservedFund.ResourceCaps) == 0 {
+		return overspentAmount
+	}
+
+	for resourceID, fundIntendedToSpend := range reservedFund.fundIntendedToSpendPerResource(servicePayments) {
+		resourceCap, capped := reservedFund.GetResourceCap(resourceID)
+		if !capped {
+			continue
+		}
+		excess := excessOver(resourceCap, fundIntendedToSpend)
+		if excess.ThetaWei.Cmp(overspentAmount.ThetaWei) > 0 {
+			overspentAmount.ThetaWei = excess.ThetaWei
+		}
+		if excess.TFuelWei.Cmp(overspentAmount.TFuelWei) > 0 {
+			overspentAmount.TFuelWei = excess.TFuelWei
+		}
+	}
+	return overspentAmount
+}
+
+// excessOver is the amount by which the fund intended to spend exceeds the limit, per denomination
+func excessOver(limit, fundIntendedToSpend Coins) Coins {
+	excess := fundIntendedToSpend.NoNil().Minus(limit.NoNil())
+	if excess.ThetaWei.Sign() < 0 {
+		excess.ThetaWei = big.NewInt(0)
+	}
+	if excess.TFuelWei.Sign() < 0 {
+		excess.TFuelWei = big.NewInt(0)
+	}
+	return excess
+}
+
+// IsOverspent tells in which denominations the fund intended to spend exceeds the limit
+func IsOverspent(limit, fundIntendedToSpend Coins) (thetaOverspent, tfuelOverspent bool) {
+	limit = limit.NoNil()
+	intended := fundIntendedToSpend.NoNil()
+	return intended.ThetaWei.Cmp(limit.ThetaWei) > 0, intended.TFuelWei.Cmp(limit.TFuelWei) > 0
+}
+
+func sumServicePayments(servicePayments []ServicePaymentTx) Coins {
+	total := NewCoins(0, 0)
+	for _, servicePaymentTx := range servicePayments {
+		total = total.Plus(servicePaymentTx.Source.Coins)
+	}
+	return total
+}
+
+// sumServicePaymentsPerResource sums up the service payments per resource ID
+func sumServicePaymentsPerResource(servicePayments []ServicePaymentTx) map[string]Coins {
+	totals := make(map[string]Coins)
+	for _, servicePaymentTx := range servicePayments {
+		total, ok := totals[servicePaymentTx.ResourceID]
+		if !ok {
+			total = NewCoins(0, 0)
+		}
+		totals[servicePaymentTx.ResourceID] = total.Plus(servicePaymentTx.Source.Coins)
+	}
+	return totals
+}
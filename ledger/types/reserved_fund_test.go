@@ -3,10 +3,12 @@ package types
 import (
 	"encoding/json"
 	"math"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/rlp"
 )
 
 func TestHasResourceID(t *testing.T) {
@@ -42,6 +44,45 @@ func TestRecordTransfer(t *testing.T) {
 	assert.Equal(len(rf.TransferRecords), 3)
 }
 
+func TestReservedFundCopy(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	rf := &ReservedFund{
+		Collateral:       NewCoins(0, 1001),
+		InitialFund:      NewCoins(0, 1000),
+		UsedFund:         NewCoins(0, 200),
+		ResourceIDs:      []string{"rid001", "rid002"},
+		EndBlockHeight:   199,
+		ReserveSequence:  3,
+		StartBlockHeight: 99,
+		ResourceCaps:     []ResourceCap{{ResourceID: "rid001", Cap: NewCoins(0, 500)}},
+		ServiceType:      "video",
+	}
+	rf.RecordTransfer(&ServicePaymentTx{Fee: NewCoins(0, 0), PaymentSequence: 1, ReserveSequence: 3})
+
+	rfCopy := rf.Copy()
+	rfBytes, err := ToBytes(rf)
+	require.Nil(err)
+	rfCopyBytes, err := ToBytes(rfCopy)
+	require.Nil(err)
+	assert.Equal(rfBytes, rfCopyBytes)
+	assert.Equal(rf.ServiceType, rfCopy.ServiceType)
+
+	// The copy shares nothing with the original reserved fund
+	rfCopy.UsedFund.TFuelWei.SetInt64(1000)
+	rfCopy.ResourceIDs[0] = "rid003"
+	rfCopy.ResourceCaps[0].Cap.TFuelWei.SetInt64(0)
+	rfCopy.TransferRecords[0].ServicePayment.PaymentSequence = 2
+	assert.Equal(NewCoins(0, 200), rf.UsedFund)
+	assert.Equal("rid001", rf.ResourceIDs[0])
+	assert.Equal(NewCoins(0, 500), rf.ResourceCaps[0].Cap)
+	assert.Equal(uint64(1), rf.TransferRecords[0].ServicePayment.PaymentSequence)
+
+	var nilReservedFund *ReservedFund
+	assert.Nil(nilReservedFund.Copy())
+}
+
 func TestReserveFundJSON(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -58,3 +99,216 @@ func TestReserveFundJSON(t *testing.T) {
 	require.Nil(err)
 	assert.Equal(uint64(math.MaxUint64), d.EndBlockHeight)
 }
+
+func TestReservedFundResourceCaps(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	resv := ReservedFund{
+		Collateral:      NewCoins(0, 1001),
+		InitialFund:     NewCoins(0, 1000),
+		UsedFund:        NewCoins(0, 0),
+		ResourceIDs:     []string{"rid001", "rid002"},
+		ReserveSequence: 1,
+	}
+
+	// Reserved funds without caps encode as before
+	raw, err := ToBytes(&resv)
+	require.Nil(err)
+	var decoded ReservedFund
+	require.Nil(FromBytes(raw, &decoded))
+	assert.Equal(0, len(decoded.ResourceCaps))
+	_, capped := decoded.GetResourceCap("rid001")
+	assert.False(capped)
+
+	resv.ResourceCaps = []ResourceCap{{ResourceID: "rid001", Cap: NewCoins(0, 300)}}
+	raw, err = ToBytes(&resv)
+	require.Nil(err)
+	require.Nil(FromBytes(raw, &decoded))
+	resourceCap, capped := decoded.GetResourceCap("rid001")
+	assert.True(capped)
+	assert.True(NewCoins(0, 300).IsEqual(resourceCap))
+	_, capped = decoded.GetResourceCap("rid002")
+	assert.False(capped)
+
+	s, err := json.Marshal(resv)
+	require.Nil(err)
+	var resv1 ReservedFund
+	require.Nil(json.Unmarshal(s, &resv1))
+	assert.Equal(resv.ResourceCaps[0].ResourceID, resv1.ResourceCaps[0].ResourceID)
+	assert.True(resv.ResourceCaps[0].Cap.IsEqual(resv1.ResourceCaps[0].Cap))
+}
+
+func TestReservedFundLegacyLayout(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	resv := ReservedFund{
+		Collateral:      NewCoins(0, 1001),
+		InitialFund:     NewCoins(0, 1000),
+		UsedFund:        NewCoins(0, 0),
+		ResourceIDs:     []string{"rid001"},
+		EndBlockHeight:  120,
+		ReserveSequence: 1,
+	}
+
+	// The reserved funds stored before the start block height was recorded keep their encoding
+	legacyRaw, err := rlp.EncodeToBytes(struct {
+		Collateral      Coins
+		InitialFund     Coins
+		UsedFund        Coins
+		ResourceIDs     []string
+		EndBlockHeight  uint64
+		ReserveSequence uint64
+		TransferRecords []TransferRecord
+	}{resv.Collateral, resv.InitialFund, resv.UsedFund, resv.ResourceIDs, resv.EndBlockHeight,
+		resv.ReserveSequence, resv.TransferRecords})
+	require.Nil(err)
+	raw, err := ToBytes(&resv)
+	require.Nil(err)
+	assert.Equal(legacyRaw, raw)
+
+	var decoded ReservedFund
+	require.Nil(FromBytes(legacyRaw, &decoded))
+	assert.Equal(uint64(0), decoded.StartBlockHeight)
+	assert.Equal(uint64(120), decoded.EndBlockHeight)
+	assert.Equal(uint64(1), decoded.ReserveSequence)
+
+	// The start block height trails the legacy fields
+	resv.StartBlockHeight = 99
+	raw, err = ToBytes(&resv)
+	require.Nil(err)
+	assert.NotEqual(legacyRaw, raw)
+	require.Nil(FromBytes(raw, &decoded))
+	assert.Equal(uint64(99), decoded.StartBlockHeight)
+	assert.Equal(0, len(decoded.ResourceCaps))
+	assert.Equal("", decoded.ServiceType)
+
+	// An account holding a legacy reserved fund decodes
+	alice := MakeAcc("User Alice")
+	account := alice.Account
+	resv.StartBlockHeight = 0
+	account.ReservedFunds = []ReservedFund{resv}
+	raw, err = ToBytes(&account)
+	require.Nil(err)
+	var decodedAccount Account
+	require.Nil(FromBytes(raw, &decodedAccount))
+	require.Equal(1, len(decodedAccount.ReservedFunds))
+	assert.Equal(uint64(0), decodedAccount.ReservedFunds[0].StartBlockHeight)
+}
+
+func TestReservedFundOverspentAmount(t *testing.T) {
+	assert := assert.New(t)
+
+	alice := MakeAcc("User Alice")
+	bob := MakeAcc("User Bob")
+	resv := ReservedFund{
+		Collateral:      NewCoins(0, 1001),
+		InitialFund:     NewCoins(0, 1000),
+		UsedFund:        NewCoins(0, 200),
+		ResourceIDs:     []string{"rid001", "rid002"},
+		ReserveSequence: 1,
+	}
+	payment := func(amount int64, paymentSeq uint64, resourceID string) ServicePaymentTx {
+		return ServicePaymentTx{
+			Source:          TxInput{Address: alice.Address, Coins: NewCoins(0, amount)},
+			Target:          TxInput{Address: bob.Address},
+			PaymentSequence: paymentSeq,
+			ReserveSequence: 1,
+			ResourceID:      resourceID,
+		}
+	}
+
+	// The used fund counts towards the overspending
+	assert.True(NewCoins(0, 0).IsEqual(resv.OverspentAmount([]ServicePaymentTx{payment(800, 1, "rid001")})))
+	assert.True(NewCoins(0, 300).IsEqual(resv.OverspentAmount([]ServicePaymentTx{payment(1100, 1, "rid001")})))
+
+	// The largest excess over the initial fund or a resource cap is the overspent amount
+	resv.ResourceCaps = []ResourceCap{{ResourceID: "rid002", Cap: NewCoins(0, 100)}}
+	assert.True(NewCoins(0, 400).IsEqual(resv.OverspentAmount([]ServicePaymentTx{payment(500, 1, "rid002")})))
+	assert.True(NewCoins(0, 600).IsEqual(resv.OverspentAmount([]ServicePaymentTx{
+		payment(400, 1, "rid001"), payment(700, 2, "rid002")})))
+}
+
+func TestReservedFundServiceType(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	resv := ReservedFund{
+		Collateral:      NewCoins(0, 1001),
+		InitialFund:     NewCoins(0, 1000),
+		UsedFund:        NewCoins(0, 0),
+		ResourceIDs:     []string{"rid001"},
+		ReserveSequence: 1,
+		ResourceCaps:    []ResourceCap{{ResourceID: "rid001", Cap: NewCoins(0, 300)}},
+	}
+
+	// Untagged reserved funds encode as before the tag was introduced
+	legacyRaw, err := rlp.EncodeToBytes(struct {
+		Collateral       Coins
+		InitialFund      Coins
+		UsedFund         Coins
+		ResourceIDs      []string
+		EndBlockHeight   uint64
+		ReserveSequence  uint64
+		TransferRecords  []TransferRecord
+		StartBlockHeight uint64
+		ResourceCaps     []ResourceCap `rlp:"tail"`
+	}{resv.Collateral, resv.InitialFund, resv.UsedFund, resv.ResourceIDs, resv.EndBlockHeight,
+		resv.ReserveSequence, resv.TransferRecords, resv.StartBlockHeight, resv.ResourceCaps})
+	require.Nil(err)
+	raw, err := ToBytes(&resv)
+	require.Nil(err)
+	assert.Equal(legacyRaw, raw)
+
+	var decoded ReservedFund
+	require.Nil(FromBytes(raw, &decoded))
+	assert.Equal("", decoded.ServiceType)
+	assert.True(decoded.MatchesServiceType(""))
+	assert.False(decoded.MatchesServiceType("video"))
+
+	// The tag trails the resource caps
+	resv.ServiceType = "video"
+	raw, err = ToBytes(&resv)
+	require.Nil(err)
+	assert.NotEqual(legacyRaw, raw)
+	require.Nil(FromBytes(raw, &decoded))
+	assert.Equal("video", decoded.ServiceType)
+	assert.Equal(1, len(decoded.ResourceCaps))
+	assert.True(decoded.MatchesServiceType(""))
+	assert.True(decoded.MatchesServiceType("video"))
+	assert.False(decoded.MatchesServiceType("storage"))
+
+	s, err := json.Marshal(resv)
+	require.Nil(err)
+	var resv1 ReservedFund
+	require.Nil(json.Unmarshal(s, &resv1))
+	assert.Equal("video", resv1.ServiceType)
+
+	assert.Nil(CheckServiceType(""))
+	assert.Nil(CheckServiceType(strings.Repeat("v", MaxServiceTypeLength)))
+	assert.NotNil(CheckServiceType(strings.Repeat("v", MaxServiceTypeLength+1)))
+}
+
+func TestTaggedTail(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	resourceCap := ResourceCap{ResourceID: "rid001", Cap: NewCoins(0, 300)}
+	tail, err := encodeTaggedTail([]interface{}{resourceCap}, "video")
+	require.Nil(err)
+	require.Equal(2, len(tail))
+
+	resourceCaps, serviceType, err := decodeResourceCapsTail(tail)
+	require.Nil(err)
+	assert.Equal("video", serviceType)
+	assert.Equal(1, len(resourceCaps))
+
+	// The tag must be the last value, and must be omitted if empty
+	_, _, err = decodeResourceCapsTail([]rlp.RawValue{tail[1], tail[0]})
+	assert.NotNil(err)
+	emptyTag, err := rlp.EncodeToBytes("")
+	require.Nil(err)
+	_, _, err = decodeResourceCapsTail([]rlp.RawValue{tail[0], emptyTag})
+	assert.NotNil(err)
+}
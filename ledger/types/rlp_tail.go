@@ -0,0 +1,61 @@
+package types
+
+import (
+	"github.com/pkg/errors"
+	"github.com/thetatoken/theta/rlp"
+)
+
+// Some types carry an optional tag, e.g. the service type, in addition to their optional list of trailing
+// elements (the "tail"). Since only one field can be the tail, the tag is encoded right after the tail
+// elements as an RLP string, and only if it is not empty. The tail elements are RLP lists, so the tag is
+// told apart by its kind, and the values without a tag keep their encoding.
+
+// encodeTaggedTail encodes the tail elements followed by the tag, if any
+func encodeTaggedTail(elems []interface{}, tag string) ([]rlp.RawValue, error) {
+	tail := make([]rlp.RawValue, 0, len(elems)+1)
+	for _, elem := range elems {
+		raw, err := rlp.EncodeToBytes(elem)
+		if err != nil {
+			return nil, err
+		}
+		tail = append(tail, raw)
+	}
+	if len(tag) > 0 {
+		raw, err := rlp.EncodeToBytes(tag)
+		if err != nil {
+			return nil, err
+		}
+		tail = append(tail, raw)
+	}
+	return tail, nil
+}
+
+// decodeTaggedTail decodes the tail elements with decodeElem, and returns the tag, if any. The tag must be
+// the last value and must not be empty, so that each value has a single encoding
+func decodeTaggedTail(tail []rlp.RawValue, decodeElem func(raw rlp.RawValue) error) (string, error) {
+	for idx, raw := range tail {
+		kind, _, _, err := rlp.Split(raw)
+		if err != nil {
+			return "", err
+		}
+		if kind == rlp.List {
+			if err := decodeElem(raw); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		if idx != len(tail)-1 {
+			return "", errors.New("rlp: the tag must be the last value of the tail")
+		}
+		var tag string
+		if err := rlp.DecodeBytes(raw, &tag); err != nil {
+			return "", err
+		}
+		if len(tag) == 0 {
+			return "", errors.New("rlp: empty tag, it must be omitted instead")
+		}
+		return tag, nil
+	}
+	return "", nil
+}
@@ -33,6 +33,9 @@ const (
 	TxSmartContract
 	TxDepositStake
 	TxWithdrawStake
+	TxSlashEvidence
+	TxBatchSlash
+	TxMultiFundSlash
 )
 
 func TxFromBytes(raw []byte) (Tx, error) {
@@ -82,6 +85,18 @@ func TxFromBytes(raw []byte) (Tx, error) {
 		data := &WithdrawStakeTx{}
 		err = rlp.Decode(buff, data)
 		return data, err
+	} else if txType == TxSlashEvidence {
+		data := &SlashEvidenceTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxBatchSlash {
+		data := &BatchSlashTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxMultiFundSlash {
+		data := &MultiFundSlashTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
 	} else {
 		return nil, fmt.Errorf("Unknown TX type: %v", txType)
 	}
@@ -111,6 +126,12 @@ func TxToBytes(t Tx) ([]byte, error) {
 		txType = TxDepositStake
 	case *WithdrawStakeTx:
 		txType = TxWithdrawStake
+	case *SlashEvidenceTx:
+		txType = TxSlashEvidence
+	case *BatchSlashTx:
+		txType = TxBatchSlash
+	case *MultiFundSlashTx:
+		txType = TxMultiFundSlash
 	default:
 		return nil, errors.New("Unsupported message type")
 	}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// SlashBond is the bond locked by the proposer of a slash, to deter frivolous slashes. The bond is
+// returned to the proposer at ReturnBlockHeight, unless it is forfeited because the slash turns out bad
+type SlashBond struct {
+	Proposer          common.Address
+	Amount            Coins
+	ReturnBlockHeight uint64
+}
+
+type SlashBondJSON struct {
+	Proposer          common.Address    `json:"proposer"`
+	Amount            Coins             `json:"amount"`
+	ReturnBlockHeight common.JSONUint64 `json:"return_block_height"`
+}
+
+func NewSlashBondJSON(b SlashBond) SlashBondJSON {
+	return SlashBondJSON{
+		Proposer:          b.Proposer,
+		Amount:            b.Amount,
+		ReturnBlockHeight: common.JSONUint64(b.ReturnBlockHeight),
+	}
+}
+
+func (b SlashBondJSON) SlashBond() SlashBond {
+	return SlashBond{
+		Proposer:          b.Proposer,
+		Amount:            b.Amount,
+		ReturnBlockHeight: uint64(b.ReturnBlockHeight),
+	}
+}
+
+func (b SlashBond) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewSlashBondJSON(b))
+}
+
+func (b *SlashBond) UnmarshalJSON(data []byte) error {
+	var a SlashBondJSON
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	*b = a.SlashBond()
+	return nil
+}
+
+func (b *SlashBond) String() string {
+	if b == nil {
+		return "nil-SlashBond"
+	}
+	return fmt.Sprintf("SlashBond{%v %v %v}", b.Proposer, b.Amount, b.ReturnBlockHeight)
+}
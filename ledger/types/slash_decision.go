@@ -0,0 +1,41 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// SlashDecision records the outcome of a SlashTx, e.g. for block producers to embed in the block metadata
+// for auditability. Replaying the SlashTx against the state it was executed against reproduces the decision
+type SlashDecision struct {
+	SlashTx        SlashTx
+	BlockHeight    uint64 // block height the SlashTx was executed at
+	SlashedAmount  Coins
+	ReturnedAmount Coins // amount returned to the slashed account
+}
+
+// Encode serializes the slash decision
+func (d *SlashDecision) Encode() (common.Bytes, error) {
+	return ToBytes(d)
+}
+
+// DecodeSlashDecision deserializes a slash decision
+func DecodeSlashDecision(data common.Bytes) (*SlashDecision, error) {
+	if len(data) == 0 {
+		return nil, errors.New("Empty slash decision")
+	}
+	decision := &SlashDecision{}
+	if err := FromBytes(data, decision); err != nil {
+		return nil, err
+	}
+	return decision, nil
+}
+
+func (d *SlashDecision) String() string {
+	if d == nil {
+		return "nil-SlashDecision"
+	}
+	return fmt.Sprintf("SlashDecision{%v %v %v %v}", d.SlashTx.String(), d.BlockHeight, d.SlashedAmount, d.ReturnedAmount)
+}
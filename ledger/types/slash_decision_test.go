@@ -0,0 +1,42 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+)
+
+func TestSlashDecisionEncoding(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	decision := &SlashDecision{
+		SlashTx: SlashTx{
+			Proposer:        TxInput{Address: common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")},
+			SlashedAddress:  common.HexToAddress("0x7d73424a8256C0b2BA245e5d5a3De8820E45F390"),
+			ReserveSequence: 3,
+			SlashProof:      common.Bytes("proof"),
+		},
+		BlockHeight:    100,
+		SlashedAmount:  NewCoins(0, 3000),
+		ReturnedAmount: NewCoins(1000, 0),
+	}
+
+	data, err := decision.Encode()
+	require.Nil(err)
+	decoded, err := DecodeSlashDecision(data)
+	require.Nil(err)
+	assert.Equal(decision.SlashTx.ID("test_chain_id"), decoded.SlashTx.ID("test_chain_id"))
+	assert.Equal(decision.SlashTx.SlashedAddress, decoded.SlashTx.SlashedAddress)
+	assert.Equal(decision.SlashTx.ReserveSequence, decoded.SlashTx.ReserveSequence)
+	assert.Equal(decision.BlockHeight, decoded.BlockHeight)
+	assert.True(decision.SlashedAmount.IsEqual(decoded.SlashedAmount))
+	assert.True(decision.ReturnedAmount.IsEqual(decoded.ReturnedAmount))
+
+	_, err = DecodeSlashDecision(nil)
+	assert.NotNil(err)
+	_, err = DecodeSlashDecision(common.Bytes("garbage"))
+	assert.NotNil(err)
+}
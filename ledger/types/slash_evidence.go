@@ -0,0 +1,76 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// SlashEvidence accumulates the service payments submitted incrementally (e.g. by watchtowers) against
+// a reserved fund. A SlashTx can consume the accumulated evidence as its overspending proof. The evidence
+// expires after EndBlockHeight if it is not consumed, which frees the state
+type SlashEvidence struct {
+	Address         common.Address
+	ReserveSequence uint64
+	ServicePayments []ServicePaymentTx
+	EndBlockHeight  uint64
+}
+
+type SlashEvidenceJSON struct {
+	Address         common.Address     `json:"address"`
+	ReserveSequence common.JSONUint64  `json:"reserve_sequence"`
+	ServicePayments []ServicePaymentTx `json:"service_payments"`
+	EndBlockHeight  common.JSONUint64  `json:"end_block_height"`
+}
+
+func NewSlashEvidenceJSON(s SlashEvidence) SlashEvidenceJSON {
+	return SlashEvidenceJSON{
+		Address:         s.Address,
+		ReserveSequence: common.JSONUint64(s.ReserveSequence),
+		ServicePayments: s.ServicePayments,
+		EndBlockHeight:  common.JSONUint64(s.EndBlockHeight),
+	}
+}
+
+func (s SlashEvidenceJSON) SlashEvidence() SlashEvidence {
+	return SlashEvidence{
+		Address:         s.Address,
+		ReserveSequence: uint64(s.ReserveSequence),
+		ServicePayments: s.ServicePayments,
+		EndBlockHeight:  uint64(s.EndBlockHeight),
+	}
+}
+
+func (s SlashEvidence) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewSlashEvidenceJSON(s))
+}
+
+func (s *SlashEvidence) UnmarshalJSON(data []byte) error {
+	var a SlashEvidenceJSON
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	*s = a.SlashEvidence()
+	return nil
+}
+
+// OverspendingProof returns the accumulated evidence as an overspending proof, with the service payments in
+// the canonical order
+func (s *SlashEvidence) OverspendingProof() *OverspendingProof {
+	servicePayments := make([]ServicePaymentTx, len(s.ServicePayments))
+	copy(servicePayments, s.ServicePayments)
+	CanonicalizeServicePayments(servicePayments)
+	return &OverspendingProof{
+		ReserveSequence: s.ReserveSequence,
+		ServicePayments: servicePayments,
+	}
+}
+
+func (s *SlashEvidence) String() string {
+	if s == nil {
+		return "nil-SlashEvidence"
+	}
+	return fmt.Sprintf("SlashEvidence{%v %v %v %v}",
+		s.Address, s.ReserveSequence, len(s.ServicePayments), s.EndBlockHeight)
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/big"
 
+	"github.com/pkg/errors"
 	"github.com/thetatoken/theta/common"
 )
 
@@ -63,25 +65,53 @@ func (si *SlashIntent) String() string {
 type OverspendingProof struct {
 	ReserveSequence uint64
 	ServicePayments []ServicePaymentTx
+
+	// Timestamp is optional, it has at most one element, the unix time the proof was produced. It is
+	// the trailing field, so that the proofs without a timestamp keep the same RLP encoding
+	Timestamp []*big.Int `rlp:"tail"`
 }
 
 type OverspendingProofJSON struct {
 	ReserveSequence common.JSONUint64
 	ServicePayments []ServicePaymentTx
+	Timestamp       []*common.JSONBig `json:"Timestamp,omitempty"`
 }
 
 func NewOverspendingProofJSON(a OverspendingProof) OverspendingProofJSON {
+	var timestamp []*common.JSONBig
+	for _, ts := range a.Timestamp {
+		timestamp = append(timestamp, (*common.JSONBig)(ts))
+	}
 	return OverspendingProofJSON{
 		ReserveSequence: common.JSONUint64(a.ReserveSequence),
 		ServicePayments: a.ServicePayments,
+		Timestamp:       timestamp,
 	}
 }
 
 func (a OverspendingProofJSON) OverspendingProof() OverspendingProof {
+	var timestamp []*big.Int
+	for _, ts := range a.Timestamp {
+		timestamp = append(timestamp, (*big.Int)(ts))
+	}
 	return OverspendingProof{
 		ReserveSequence: uint64(a.ReserveSequence),
 		ServicePayments: a.ServicePayments,
+		Timestamp:       timestamp,
+	}
+}
+
+// SetTimestamp sets the time the proof was produced
+func (a *OverspendingProof) SetTimestamp(timestamp *big.Int) {
+	a.Timestamp = []*big.Int{timestamp}
+}
+
+// GetTimestamp returns the time the proof was produced, or nil if the proof carries no timestamp
+func (a *OverspendingProof) GetTimestamp() *big.Int {
+	if len(a.Timestamp) == 0 {
+		return nil
 	}
+	return a.Timestamp[0]
 }
 
 func (a OverspendingProof) MarshalJSON() ([]byte, error) {
@@ -96,3 +126,64 @@ func (a *OverspendingProof) UnmarshalJSON(data []byte) error {
 	*a = b.OverspendingProof()
 	return nil
 }
+
+// OverspendingProofJSONEncodingMarker marks an OverspendingProof in the canonical JSON encoding. The native
+// encoding of the proof is RLP. To make it easier for non-Go clients to build proofs, the following canonical
+// JSON encoding is accepted as well:
+//
+//	0x01 || JSON({"ReserveSequence": "<decimal uint64>", "ServicePayments": [<ServicePaymentTx JSON>, ...]})
+//
+// A proof with a timestamp has the additional trailing field "Timestamp": ["<decimal unix time>"].
+// The fields appear in the above order without insignificant whitespace, and each ServicePaymentTx uses
+// the same JSON layout as the RPC APIs. An RLP encoded proof always starts with a list prefix (0xc0
+// or above), so it cannot be mistaken for the marker.
+const OverspendingProofJSONEncodingMarker byte = 0x01
+
+// EncodeOverspendingProofJSON encodes the proof in the canonical JSON encoding, prefixed by the encoding marker
+func EncodeOverspendingProofJSON(proof *OverspendingProof) ([]byte, error) {
+	// Normalize the proof (e.g. nil vs. zero amounts) through the native encoding, so that
+	// equivalent proofs always have the same JSON encoding
+	rlpBytes, err := ToBytes(proof)
+	if err != nil {
+		return nil, err
+	}
+	normalizedProof := &OverspendingProof{}
+	if err := FromBytes(rlpBytes, normalizedProof); err != nil {
+		return nil, err
+	}
+
+	jsonBytes, err := json.Marshal(normalizedProof)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{OverspendingProofJSONEncodingMarker}, jsonBytes...), nil
+}
+
+// DecodeOverspendingProofJSON decodes a proof in the canonical JSON encoding, prefixed by the encoding marker
+func DecodeOverspendingProofJSON(data []byte, proof *OverspendingProof) error {
+	if len(data) == 0 || data[0] != OverspendingProofJSONEncodingMarker {
+		return errors.New("Missing the JSON encoding marker")
+	}
+	return json.Unmarshal(data[1:], proof)
+}
+
+// IsOverspendingProofJSON tells whether the proof bytes are in the canonical JSON encoding
+func IsOverspendingProofJSON(data []byte) bool {
+	return len(data) > 0 && data[0] == OverspendingProofJSONEncodingMarker
+}
+
+// ReserveBoundOverspendingProof is an OverspendingProof bound to a specific instance of the reserved fund,
+// identified by the hash of the ReserveFundTx that created it rather than by the reserve sequence only. Its
+// RLP layout differs from both OverspendingProof and ChannelRevocationProof, so they cannot be mistaken for
+// each other
+type ReserveBoundOverspendingProof struct {
+	ReserveTxHash common.Hash
+	Proof         OverspendingProof
+}
+
+// ChannelRevocationProof contains the proof that the claimed state of a payment channel has been
+// revoked, i.e. the account also signed a newer state of the same channel contradicting the claimed one
+type ChannelRevocationProof struct {
+	ClaimedState ServicePaymentTx
+	NewerState   ServicePaymentTx
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"encoding/json"
 	"math"
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -42,3 +43,80 @@ func TestOverspendingProofJSON(t *testing.T) {
 	require.Nil(err)
 	assert.Equal(uint64(math.MaxUint64), d.ReserveSequence)
 }
+
+func TestOverspendingProofJSONEncoding(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p := OverspendingProof{
+		ReserveSequence: math.MaxUint64,
+		ServicePayments: []ServicePaymentTx{
+			ServicePaymentTx{
+				Fee:             NewCoins(0, 1),
+				Source:          TxInput{Coins: NewCoins(0, 100), Sequence: 1},
+				Target:          TxInput{Sequence: 2},
+				PaymentSequence: math.MaxUint64,
+				ReserveSequence: math.MaxUint64,
+				ResourceID:      "rid001",
+			},
+		},
+	}
+
+	jsonBytes, err := EncodeOverspendingProofJSON(&p)
+	require.Nil(err)
+	assert.True(IsOverspendingProofJSON(jsonBytes))
+	assert.Equal(`{"ReserveSequence":"18446744073709551615","ServicePayments":[`, string(jsonBytes[1:62]))
+
+	// The encoding is deterministic
+	jsonBytes2, err := EncodeOverspendingProofJSON(&p)
+	require.Nil(err)
+	assert.Equal(jsonBytes, jsonBytes2)
+
+	var d OverspendingProof
+	err = DecodeOverspendingProofJSON(jsonBytes, &d)
+	require.Nil(err)
+
+	// Cross-encoding round trip: JSON -> RLP -> JSON
+	rlpBytes, err := ToBytes(&d)
+	require.Nil(err)
+	assert.False(IsOverspendingProofJSON(rlpBytes))
+	expectedRLPBytes, err := ToBytes(&p)
+	require.Nil(err)
+	assert.Equal(expectedRLPBytes, rlpBytes)
+
+	var r OverspendingProof
+	err = FromBytes(rlpBytes, &r)
+	require.Nil(err)
+	jsonBytes3, err := EncodeOverspendingProofJSON(&r)
+	require.Nil(err)
+	assert.Equal(jsonBytes, jsonBytes3)
+
+	err = DecodeOverspendingProofJSON(jsonBytes[1:], &d)
+	assert.NotNil(err)
+}
+
+func TestOverspendingProofTimestamp(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p := OverspendingProof{ReserveSequence: 1}
+	untimestampedBytes, err := ToBytes(&p)
+	require.Nil(err)
+	assert.Nil(p.GetTimestamp())
+
+	p.SetTimestamp(big.NewInt(1234567))
+	timestampedBytes, err := ToBytes(&p)
+	require.Nil(err)
+
+	var d OverspendingProof
+	require.Nil(FromBytes(untimestampedBytes, &d))
+	assert.Nil(d.GetTimestamp())
+	require.Nil(FromBytes(timestampedBytes, &d))
+	assert.Equal(int64(1234567), d.GetTimestamp().Int64())
+
+	jsonBytes, err := EncodeOverspendingProofJSON(&p)
+	require.Nil(err)
+	var j OverspendingProof
+	require.Nil(DecodeOverspendingProofJSON(jsonBytes, &j))
+	assert.Equal(int64(1234567), j.GetTimestamp().Int64())
+}
@@ -0,0 +1,47 @@
+package types
+
+import (
+	"fmt"
+)
+
+// SlashReason is the misbehavior a SlashTx punishes. The reason determines the type of the slash proof
+// the SlashTx must carry
+type SlashReason uint8
+
+const (
+	SlashReasonOverspending      SlashReason = iota // the reserved fund is overspent, proven by an OverspendingProof
+	SlashReasonChannelRevocation                    // a revoked channel state is claimed, proven by a ChannelRevocationProof
+	SlashReasonDoubleSigning                        // the validator signed conflicting votes, no proof type is supported yet
+	SlashReasonDowntime                             // the validator missed too many blocks, no proof type is supported yet
+)
+
+var slashReasonNames = map[SlashReason]string{
+	SlashReasonOverspending:      "overspending",
+	SlashReasonChannelRevocation: "channel_revocation",
+	SlashReasonDoubleSigning:     "double_signing",
+	SlashReasonDowntime:          "downtime",
+}
+
+func (r SlashReason) String() string {
+	if name, ok := slashReasonNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(r))
+}
+
+func (r SlashReason) MarshalText() ([]byte, error) {
+	if _, ok := slashReasonNames[r]; !ok {
+		return nil, fmt.Errorf("Unknown slash reason %d", uint8(r))
+	}
+	return []byte(r.String()), nil
+}
+
+func (r *SlashReason) UnmarshalText(text []byte) error {
+	for reason, name := range slashReasonNames {
+		if name == string(text) {
+			*r = reason
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown slash reason %v", string(text))
+}
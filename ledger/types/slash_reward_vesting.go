@@ -0,0 +1,93 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// SlashRewardVesting records a slash reward that vests linearly to the beneficiary between
+// StartBlockHeight and EndBlockHeight, instead of being credited at once
+type SlashRewardVesting struct {
+	Beneficiary      common.Address
+	Amount           Coins // the total reward
+	Released         Coins // the part of the reward already credited to the beneficiary
+	StartBlockHeight uint64
+	EndBlockHeight   uint64
+}
+
+type SlashRewardVestingJSON struct {
+	Beneficiary      common.Address    `json:"beneficiary"`
+	Amount           Coins             `json:"amount"`
+	Released         Coins             `json:"released"`
+	StartBlockHeight common.JSONUint64 `json:"start_block_height"`
+	EndBlockHeight   common.JSONUint64 `json:"end_block_height"`
+}
+
+func NewSlashRewardVestingJSON(v SlashRewardVesting) SlashRewardVestingJSON {
+	return SlashRewardVestingJSON{
+		Beneficiary:      v.Beneficiary,
+		Amount:           v.Amount,
+		Released:         v.Released,
+		StartBlockHeight: common.JSONUint64(v.StartBlockHeight),
+		EndBlockHeight:   common.JSONUint64(v.EndBlockHeight),
+	}
+}
+
+func (v SlashRewardVestingJSON) SlashRewardVesting() SlashRewardVesting {
+	return SlashRewardVesting{
+		Beneficiary:      v.Beneficiary,
+		Amount:           v.Amount,
+		Released:         v.Released,
+		StartBlockHeight: uint64(v.StartBlockHeight),
+		EndBlockHeight:   uint64(v.EndBlockHeight),
+	}
+}
+
+func (v SlashRewardVesting) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewSlashRewardVestingJSON(v))
+}
+
+func (v *SlashRewardVesting) UnmarshalJSON(data []byte) error {
+	var a SlashRewardVestingJSON
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	*v = a.SlashRewardVesting()
+	return nil
+}
+
+// VestedAmount returns the part of the reward vested by the given block height
+func (v *SlashRewardVesting) VestedAmount(currentBlockHeight uint64) Coins {
+	if v.IsMatured(currentBlockHeight) {
+		return v.Amount.NoNil()
+	}
+	if currentBlockHeight <= v.StartBlockHeight {
+		return NewCoins(0, 0)
+	}
+
+	elapsed := new(big.Int).SetUint64(currentBlockHeight - v.StartBlockHeight)
+	duration := new(big.Int).SetUint64(v.EndBlockHeight - v.StartBlockHeight)
+	amount := v.Amount.NoNil()
+	theta := new(big.Int).Mul(amount.ThetaWei, elapsed)
+	tfuel := new(big.Int).Mul(amount.TFuelWei, elapsed)
+	return Coins{
+		ThetaWei: theta.Div(theta, duration),
+		TFuelWei: tfuel.Div(tfuel, duration),
+	}
+}
+
+// IsMatured returns whether the reward has fully vested by the given block height
+func (v *SlashRewardVesting) IsMatured(currentBlockHeight uint64) bool {
+	return currentBlockHeight >= v.EndBlockHeight
+}
+
+func (v *SlashRewardVesting) String() string {
+	if v == nil {
+		return "nil-SlashRewardVesting"
+	}
+	return fmt.Sprintf("SlashRewardVesting{%v %v %v %v %v}",
+		v.Beneficiary, v.Amount, v.Released, v.StartBlockHeight, v.EndBlockHeight)
+}
@@ -0,0 +1,55 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+)
+
+func TestSlashRewardVestingVestedAmount(t *testing.T) {
+	assert := assert.New(t)
+
+	vesting := &SlashRewardVesting{
+		Beneficiary:      common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab"),
+		Amount:           NewCoins(1000, 3000),
+		Released:         NewCoins(0, 0),
+		StartBlockHeight: 100,
+		EndBlockHeight:   200,
+	}
+
+	assert.True(vesting.VestedAmount(50).IsZero())
+	assert.True(vesting.VestedAmount(100).IsZero())
+	assert.True(NewCoins(250, 750).IsEqual(vesting.VestedAmount(125)))
+	assert.True(NewCoins(990, 2970).IsEqual(vesting.VestedAmount(199)))
+	assert.False(vesting.IsMatured(199))
+
+	assert.True(NewCoins(1000, 3000).IsEqual(vesting.VestedAmount(200)))
+	assert.True(NewCoins(1000, 3000).IsEqual(vesting.VestedAmount(300)))
+	assert.True(vesting.IsMatured(200))
+}
+
+func TestSlashRewardVestingJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	vesting := SlashRewardVesting{
+		Beneficiary:      common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab"),
+		Amount:           NewCoins(1000, 3000),
+		Released:         NewCoins(10, 30),
+		StartBlockHeight: 100,
+		EndBlockHeight:   200,
+	}
+
+	s, err := json.Marshal(vesting)
+	assert.Nil(err)
+
+	var d SlashRewardVesting
+	err = json.Unmarshal(s, &d)
+	assert.Nil(err)
+	assert.Equal(vesting.Beneficiary, d.Beneficiary)
+	assert.True(vesting.Amount.IsEqual(d.Amount))
+	assert.True(vesting.Released.IsEqual(d.Released))
+	assert.Equal(vesting.StartBlockHeight, d.StartBlockHeight)
+	assert.Equal(vesting.EndBlockHeight, d.EndBlockHeight)
+}
@@ -4,8 +4,10 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math/big"
 
+	"github.com/pkg/errors"
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/crypto"
@@ -26,6 +28,9 @@ Transaction Types:
  - DepositStakeTx       Deposit stake to a target address (e.g. a validator)
  - WithdrawStakeTx      Withdraw stake from a target address (e.g. a validator)
  - SmartContractTx      Execute smart contract
+ - SlashEvidenceTx      Submit slash evidence against a reserved fund
+ - BatchSlashTx         Transaction for slashing multiple dishonest users at once
+ - MultiFundSlashTx     Transaction for slashing multiple reserved funds of a dishonest user atomically
 */
 
 // Gas of regular transactions
@@ -38,6 +43,7 @@ const (
 	GasUpdateValidatorsTx uint64 = 10000
 	GasDepositStakeTx     uint64 = 10000
 	GasWidthdrawStakeTx   uint64 = 10000
+	GasSlashEvidenceTx    uint64 = 10000
 )
 
 type Tx interface {
@@ -47,6 +53,9 @@ type Tx interface {
 
 //-----------------------------------------------------------------------------
 
+// TxID is the hash of the sign bytes of the transaction, which are the target sign bytes for a service
+// payment. The sign bytes embed the transaction as encoded by TxToBytes, i.e. prefixed with its TxType, so
+// the IDs of different transaction types never collide, however similar their fields are
 func TxID(chainID string, tx Tx) common.Hash {
 	var signBytes []byte
 	switch tx.(type) {
@@ -255,11 +264,127 @@ func (tx *CoinbaseTx) String() string {
 
 //-----------------------------------------------------------------------------
 
+// SlashSnapshot identifies the state snapshot a slash proof is bound to
+type SlashSnapshot struct {
+	Height    uint64
+	StateRoot common.Hash
+}
+
+type SlashSnapshotJSON struct {
+	Height    common.JSONUint64 `json:"height"`
+	StateRoot common.Hash       `json:"state_root"`
+}
+
+func (a SlashSnapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(SlashSnapshotJSON{
+		Height:    common.JSONUint64(a.Height),
+		StateRoot: a.StateRoot,
+	})
+}
+
+func (a *SlashSnapshot) UnmarshalJSON(data []byte) error {
+	var b SlashSnapshotJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	a.Height = uint64(b.Height)
+	a.StateRoot = b.StateRoot
+	return nil
+}
+
 type SlashTx struct {
 	Proposer        TxInput
 	SlashedAddress  common.Address
 	ReserveSequence uint64
 	SlashProof      common.Bytes
+	Reason          SlashReason // the type of the slash proof must match the reason
+
+	// Snapshot is optional, it has at most one element, the state snapshot the proof was submitted
+	// against. It is encoded after the reason.
+	Snapshot []SlashSnapshot
+
+	// ServiceType is optional, if set only a reserved fund with this service type tag can be slashed. It
+	// is encoded after the snapshot, and only if set (see encodeTaggedTail)
+	ServiceType string
+}
+
+// slashTxRLP is the RLP layout of a SlashTx. The SlashTxs encoded before the reason was recorded have the
+// first four fields only, hence the Reason is an optional trailing field: it leads the tail, and is omitted
+// along with the rest of the tail if it is SlashReasonOverspending and the tail is empty, so that the
+// encoding of these SlashTxs, and thus their sign bytes and IDs, are unchanged
+type slashTxRLP struct {
+	Proposer        TxInput
+	SlashedAddress  common.Address
+	ReserveSequence uint64
+	SlashProof      common.Bytes
+	Tail            []rlp.RawValue `rlp:"tail"` // the reason, then the snapshot and the service type
+}
+
+// EncodeRLP implements rlp.Encoder.
+func (a SlashTx) EncodeRLP(w io.Writer) error {
+	var elems []interface{}
+	for _, snapshot := range a.Snapshot {
+		elems = append(elems, snapshot)
+	}
+	tail, err := encodeTaggedTail(elems, a.ServiceType)
+	if err != nil {
+		return err
+	}
+	if a.Reason != SlashReasonOverspending || len(tail) > 0 {
+		raw, err := rlp.EncodeToBytes(a.Reason)
+		if err != nil {
+			return err
+		}
+		tail = append([]rlp.RawValue{raw}, tail...)
+	}
+	return rlp.Encode(w, slashTxRLP{
+		Proposer:        a.Proposer,
+		SlashedAddress:  a.SlashedAddress,
+		ReserveSequence: a.ReserveSequence,
+		SlashProof:      a.SlashProof,
+		Tail:            tail,
+	})
+}
+
+// DecodeRLP implements rlp.Decoder.
+func (a *SlashTx) DecodeRLP(s *rlp.Stream) error {
+	var dec slashTxRLP
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+	reason := SlashReasonOverspending
+	tail := dec.Tail
+	if len(tail) > 0 {
+		if err := rlp.DecodeBytes(tail[0], &reason); err != nil {
+			return errors.Wrap(err, "rlp: invalid reason of the SlashTx")
+		}
+		tail = tail[1:]
+		if reason == SlashReasonOverspending && len(tail) == 0 {
+			return errors.New("rlp: trailing overspending reason, it must be omitted instead")
+		}
+	}
+	snapshot := make([]SlashSnapshot, 0, len(tail))
+	serviceType, err := decodeTaggedTail(tail, func(raw rlp.RawValue) error {
+		var elem SlashSnapshot
+		if err := rlp.DecodeBytes(raw, &elem); err != nil {
+			return err
+		}
+		snapshot = append(snapshot, elem)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*a = SlashTx{
+		Proposer:        dec.Proposer,
+		SlashedAddress:  dec.SlashedAddress,
+		ReserveSequence: dec.ReserveSequence,
+		SlashProof:      dec.SlashProof,
+		Reason:          reason,
+		Snapshot:        snapshot,
+		ServiceType:     serviceType,
+	}
+	return nil
 }
 
 type SlashTxJSON struct {
@@ -267,6 +392,9 @@ type SlashTxJSON struct {
 	SlashedAddress  common.Address    `json:"slashed_address"`
 	ReserveSequence common.JSONUint64 `json:"reserved_sequence"`
 	SlashProof      common.Bytes      `json:"slash_proof"`
+	Reason          SlashReason       `json:"reason"`
+	Snapshot        []SlashSnapshot   `json:"snapshot,omitempty"`
+	ServiceType     string            `json:"service_type,omitempty"`
 }
 
 func NewSlashTxJSON(a SlashTx) SlashTxJSON {
@@ -275,6 +403,9 @@ func NewSlashTxJSON(a SlashTx) SlashTxJSON {
 		SlashedAddress:  a.SlashedAddress,
 		ReserveSequence: common.JSONUint64(a.ReserveSequence),
 		SlashProof:      a.SlashProof,
+		Reason:          a.Reason,
+		Snapshot:        a.Snapshot,
+		ServiceType:     a.ServiceType,
 	}
 }
 
@@ -284,7 +415,24 @@ func (a SlashTxJSON) SlashTx() SlashTx {
 		SlashedAddress:  a.SlashedAddress,
 		ReserveSequence: uint64(a.ReserveSequence),
 		SlashProof:      a.SlashProof,
+		Reason:          a.Reason,
+		Snapshot:        a.Snapshot,
+		ServiceType:     a.ServiceType,
+	}
+}
+
+// BindSnapshot binds the slash proof to the state snapshot at the given height, so that the proof is
+// verified against that snapshot instead of the current state
+func (tx *SlashTx) BindSnapshot(height uint64, stateRoot common.Hash) {
+	tx.Snapshot = []SlashSnapshot{SlashSnapshot{Height: height, StateRoot: stateRoot}}
+}
+
+// GetSnapshot returns the state snapshot the slash proof is bound to, or nil if the proof is not bound
+func (tx *SlashTx) GetSnapshot() *SlashSnapshot {
+	if len(tx.Snapshot) == 0 {
+		return nil
 	}
+	return &tx.Snapshot[0]
 }
 
 func (a SlashTx) MarshalJSON() ([]byte, error) {
@@ -314,6 +462,12 @@ func (tx *SlashTx) SignBytes(chainID string) []byte {
 	return signBytes
 }
 
+// ID returns the hash the chain assigns to the slash transaction. It does not depend on the proposer
+// signature, hence it can be computed before the transaction is signed and broadcasted
+func (tx *SlashTx) ID(chainID string) common.Hash {
+	return TxID(chainID, tx)
+}
+
 func (tx *SlashTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
 	if tx.Proposer.Address == addr {
 		tx.Proposer.Signature = sig
@@ -323,9 +477,9 @@ func (tx *SlashTx) SetSignature(addr common.Address, sig *crypto.Signature) bool
 }
 
 func (tx *SlashTx) String() string {
-	return fmt.Sprintf("SlashTx{%v->%v, reserve_sequence: %v, slash_proof: %v}",
+	return fmt.Sprintf("SlashTx{%v->%v, reserve_sequence: %v, reason: %v, slash_proof: %v}",
 		tx.SlashedAddress.Hex(), tx.Proposer.Address[:],
-		tx.ReserveSequence, hex.EncodeToString(tx.SlashProof))
+		tx.ReserveSequence, tx.Reason, hex.EncodeToString(tx.SlashProof))
 }
 
 //-----------------------------------------------------------------------------
@@ -372,38 +526,98 @@ func (tx *SendTx) String() string {
 //-----------------------------------------------------------------------------
 
 type ReserveFundTx struct {
-	Fee         Coins    // Fee
-	Source      TxInput  // Source account
-	Collateral  Coins    // Collateral for the micropayment pool
-	ResourceIDs []string // List of resource ID
+	Fee          Coins    // Fee
+	Source       TxInput  // Source account
+	Collateral   Coins    // Collateral for the micropayment pool
+	ResourceIDs  []string // List of resource ID
+	Duration     uint64
+	ResourceCaps []ResourceCap // Optional per-resource spending caps, a trailing field
+	ServiceType  string        // Optional tag of the type of service the fund is reserved for, encoded after the caps
+}
+
+// reserveFundTxRLP is the RLP layout of a ReserveFundTx
+type reserveFundTxRLP struct {
+	Fee         Coins
+	Source      TxInput
+	Collateral  Coins
+	ResourceIDs []string
 	Duration    uint64
+	Tail        []rlp.RawValue `rlp:"tail"` // the resource caps, then the service type
+}
+
+// EncodeRLP implements rlp.Encoder.
+func (a ReserveFundTx) EncodeRLP(w io.Writer) error {
+	var elems []interface{}
+	for _, resourceCap := range a.ResourceCaps {
+		elems = append(elems, resourceCap)
+	}
+	tail, err := encodeTaggedTail(elems, a.ServiceType)
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(w, reserveFundTxRLP{
+		Fee:         a.Fee,
+		Source:      a.Source,
+		Collateral:  a.Collateral,
+		ResourceIDs: a.ResourceIDs,
+		Duration:    a.Duration,
+		Tail:        tail,
+	})
+}
+
+// DecodeRLP implements rlp.Decoder.
+func (a *ReserveFundTx) DecodeRLP(s *rlp.Stream) error {
+	var dec reserveFundTxRLP
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+	resourceCaps, serviceType, err := decodeResourceCapsTail(dec.Tail)
+	if err != nil {
+		return err
+	}
+	*a = ReserveFundTx{
+		Fee:          dec.Fee,
+		Source:       dec.Source,
+		Collateral:   dec.Collateral,
+		ResourceIDs:  dec.ResourceIDs,
+		Duration:     dec.Duration,
+		ResourceCaps: resourceCaps,
+		ServiceType:  serviceType,
+	}
+	return nil
 }
 
 type ReserveFundTxJSON struct {
-	Fee         Coins             `json:"fee"`          // Fee
-	Source      TxInput           `json:"source"`       // Source account
-	Collateral  Coins             `json:"collateral"`   // Collateral for the micropayment pool
-	ResourceIDs []string          `json:"resource_ids"` // List of resource ID
-	Duration    common.JSONUint64 `json:"duration"`
+	Fee          Coins             `json:"fee"`          // Fee
+	Source       TxInput           `json:"source"`       // Source account
+	Collateral   Coins             `json:"collateral"`   // Collateral for the micropayment pool
+	ResourceIDs  []string          `json:"resource_ids"` // List of resource ID
+	Duration     common.JSONUint64 `json:"duration"`
+	ResourceCaps []ResourceCap     `json:"resource_caps,omitempty"` // Optional per-resource spending caps
+	ServiceType  string            `json:"service_type,omitempty"`  // Optional tag of the type of service the fund is reserved for
 }
 
 func NewReserveFundTxJSON(a ReserveFundTx) ReserveFundTxJSON {
 	return ReserveFundTxJSON{
-		Fee:         a.Fee,
-		Source:      a.Source,
-		Collateral:  a.Collateral,
-		ResourceIDs: a.ResourceIDs,
-		Duration:    common.JSONUint64(a.Duration),
+		Fee:          a.Fee,
+		Source:       a.Source,
+		Collateral:   a.Collateral,
+		ResourceIDs:  a.ResourceIDs,
+		Duration:     common.JSONUint64(a.Duration),
+		ResourceCaps: a.ResourceCaps,
+		ServiceType:  a.ServiceType,
 	}
 }
 
 func (a ReserveFundTxJSON) ReserveFundTx() ReserveFundTx {
 	return ReserveFundTx{
-		Fee:         a.Fee,
-		Source:      a.Source,
-		Collateral:  a.Collateral,
-		ResourceIDs: a.ResourceIDs,
-		Duration:    uint64(a.Duration),
+		Fee:          a.Fee,
+		Source:       a.Source,
+		Collateral:   a.Collateral,
+		ResourceIDs:  a.ResourceIDs,
+		Duration:     uint64(a.Duration),
+		ResourceCaps: a.ResourceCaps,
+		ServiceType:  a.ServiceType,
 	}
 }
 
@@ -443,8 +657,8 @@ func (tx *ReserveFundTx) SetSignature(addr common.Address, sig *crypto.Signature
 }
 
 func (tx *ReserveFundTx) String() string {
-	return fmt.Sprintf("ReserveFundTx{fee: %v, source: %v, collateral: %v, resource_ids: %v, duration: %v}",
-		tx.Fee, tx.Source, tx.Collateral, tx.ResourceIDs, tx.Duration)
+	return fmt.Sprintf("ReserveFundTx{fee: %v, source: %v, collateral: %v, resource_ids: %v, duration: %v, resource_caps: %v}",
+		tx.Fee, tx.Source, tx.Collateral, tx.ResourceIDs, tx.Duration, tx.ResourceCaps)
 }
 
 //-----------------------------------------------------------------------------
@@ -866,6 +1080,213 @@ func (tx *WithdrawStakeTx) String() string {
 		tx.Source.Address, tx.Holder.Address, tx.Source.Coins.ThetaWei, tx.Purpose)
 }
 
+//-----------------------------------------------------------------------------
+
+type SlashEvidenceTx struct {
+	Fee             Coins              // Fee
+	Submitter       TxInput            // Submitter of the evidence, e.g. a watchtower
+	SlashedAddress  common.Address     // Address of the account that overspent its reserved fund
+	ReserveSequence uint64             // ReserveSequence to locate the ReservedFund
+	ServicePayments []ServicePaymentTx // Service payments signed by the slashed account
+}
+
+type SlashEvidenceTxJSON struct {
+	Fee             Coins              `json:"fee"`
+	Submitter       TxInput            `json:"submitter"`
+	SlashedAddress  common.Address     `json:"slashed_address"`
+	ReserveSequence common.JSONUint64  `json:"reserve_sequence"`
+	ServicePayments []ServicePaymentTx `json:"service_payments"`
+}
+
+func NewSlashEvidenceTxJSON(a SlashEvidenceTx) SlashEvidenceTxJSON {
+	return SlashEvidenceTxJSON{
+		Fee:             a.Fee,
+		Submitter:       a.Submitter,
+		SlashedAddress:  a.SlashedAddress,
+		ReserveSequence: common.JSONUint64(a.ReserveSequence),
+		ServicePayments: a.ServicePayments,
+	}
+}
+
+func (a SlashEvidenceTxJSON) SlashEvidenceTx() SlashEvidenceTx {
+	return SlashEvidenceTx{
+		Fee:             a.Fee,
+		Submitter:       a.Submitter,
+		SlashedAddress:  a.SlashedAddress,
+		ReserveSequence: uint64(a.ReserveSequence),
+		ServicePayments: a.ServicePayments,
+	}
+}
+
+func (a SlashEvidenceTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewSlashEvidenceTxJSON(a))
+}
+
+func (a *SlashEvidenceTx) UnmarshalJSON(data []byte) error {
+	var b SlashEvidenceTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.SlashEvidenceTx()
+	return nil
+}
+
+func (_ *SlashEvidenceTx) AssertIsTx() {}
+
+func (tx *SlashEvidenceTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Submitter.Signature
+	tx.Submitter.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Submitter.Signature = sig
+	return signBytes
+}
+
+func (tx *SlashEvidenceTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Submitter.Address == addr {
+		tx.Submitter.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *SlashEvidenceTx) String() string {
+	return fmt.Sprintf("SlashEvidenceTx{%v, slashed_address: %v, reserve_sequence: %v, service_payments: %v}",
+		tx.Submitter, tx.SlashedAddress, tx.ReserveSequence, len(tx.ServicePayments))
+}
+
+//-----------------------------------------------------------------------------
+
+// SlashEntry is an independent slash in a BatchSlashTx
+type SlashEntry struct {
+	SlashedAddress  common.Address
+	ReserveSequence uint64
+	SlashProof      common.Bytes
+	Reason          SlashReason
+}
+
+type SlashEntryJSON struct {
+	SlashedAddress  common.Address    `json:"slashed_address"`
+	ReserveSequence common.JSONUint64 `json:"reserved_sequence"`
+	SlashProof      common.Bytes      `json:"slash_proof"`
+	Reason          SlashReason       `json:"reason"`
+}
+
+func NewSlashEntryJSON(a SlashEntry) SlashEntryJSON {
+	return SlashEntryJSON{
+		SlashedAddress:  a.SlashedAddress,
+		ReserveSequence: common.JSONUint64(a.ReserveSequence),
+		SlashProof:      a.SlashProof,
+		Reason:          a.Reason,
+	}
+}
+
+func (a SlashEntryJSON) SlashEntry() SlashEntry {
+	return SlashEntry{
+		SlashedAddress:  a.SlashedAddress,
+		ReserveSequence: uint64(a.ReserveSequence),
+		SlashProof:      a.SlashProof,
+		Reason:          a.Reason,
+	}
+}
+
+func (a SlashEntry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewSlashEntryJSON(a))
+}
+
+func (a *SlashEntry) UnmarshalJSON(data []byte) error {
+	var b SlashEntryJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.SlashEntry()
+	return nil
+}
+
+// BatchSlashTx submits multiple independent slashes, e.g. by a watchtower that detected several
+// overspenders. Each entry succeeds or fails on its own, a failed entry does not revert the others.
+type BatchSlashTx struct {
+	Proposer TxInput
+	Entries  []SlashEntry
+}
+
+type BatchSlashTxJSON struct {
+	Proposer TxInput      `json:"proposer"`
+	Entries  []SlashEntry `json:"entries"`
+}
+
+func NewBatchSlashTxJSON(a BatchSlashTx) BatchSlashTxJSON {
+	return BatchSlashTxJSON{
+		Proposer: a.Proposer,
+		Entries:  a.Entries,
+	}
+}
+
+func (a BatchSlashTxJSON) BatchSlashTx() BatchSlashTx {
+	return BatchSlashTx{
+		Proposer: a.Proposer,
+		Entries:  a.Entries,
+	}
+}
+
+func (a BatchSlashTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewBatchSlashTxJSON(a))
+}
+
+func (a *BatchSlashTx) UnmarshalJSON(data []byte) error {
+	var b BatchSlashTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.BatchSlashTx()
+	return nil
+}
+
+func (_ *BatchSlashTx) AssertIsTx() {}
+
+func (tx *BatchSlashTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Proposer.Signature
+	tx.Proposer.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Proposer.Signature = sig
+	return signBytes
+}
+
+func (tx *BatchSlashTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Proposer.Address == addr {
+		tx.Proposer.Signature = sig
+		return true
+	}
+	return false
+}
+
+// SlashTx returns the SlashTx equivalent to the entry at the given index. The SlashTx carries no
+// signature, since the proposer signs the BatchSlashTx as a whole.
+func (tx *BatchSlashTx) SlashTx(idx int) *SlashTx {
+	entry := tx.Entries[idx]
+	return &SlashTx{
+		Proposer: TxInput{
+			Address:  tx.Proposer.Address,
+			Sequence: tx.Proposer.Sequence,
+		},
+		SlashedAddress:  entry.SlashedAddress,
+		ReserveSequence: entry.ReserveSequence,
+		SlashProof:      entry.SlashProof,
+		Reason:          entry.Reason,
+	}
+}
+
+func (tx *BatchSlashTx) String() string {
+	return fmt.Sprintf("BatchSlashTx{%v, entries: %v}", tx.Proposer.Address.Hex(), len(tx.Entries))
+}
+
 // --------------- Utils --------------- //
 
 // Need to add the following prefix to the tx signbytes to be compatible with
@@ -883,3 +1304,133 @@ func addPrefixForSignBytes(signBytes common.Bytes) common.Bytes {
 	}
 	return signBytes
 }
+
+//-----------------------------------------------------------------------------
+
+// SlashedFund is a reserved fund slashed by a MultiFundSlashTx
+type SlashedFund struct {
+	ReserveSequence uint64
+	SlashProof      common.Bytes
+	Reason          SlashReason
+}
+
+type SlashedFundJSON struct {
+	ReserveSequence common.JSONUint64 `json:"reserve_sequence"`
+	SlashProof      common.Bytes      `json:"slash_proof"`
+	Reason          SlashReason       `json:"reason"`
+}
+
+func NewSlashedFundJSON(a SlashedFund) SlashedFundJSON {
+	return SlashedFundJSON{
+		ReserveSequence: common.JSONUint64(a.ReserveSequence),
+		SlashProof:      a.SlashProof,
+		Reason:          a.Reason,
+	}
+}
+
+func (a SlashedFundJSON) SlashedFund() SlashedFund {
+	return SlashedFund{
+		ReserveSequence: uint64(a.ReserveSequence),
+		SlashProof:      a.SlashProof,
+		Reason:          a.Reason,
+	}
+}
+
+func (a SlashedFund) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewSlashedFundJSON(a))
+}
+
+func (a *SlashedFund) UnmarshalJSON(data []byte) error {
+	var b SlashedFundJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.SlashedFund()
+	return nil
+}
+
+// MultiFundSlashTx slashes several reserved funds of an account that overspent across them. Unlike a
+// BatchSlashTx, it is atomic: either all the reserved funds are slashed, or none of them is.
+type MultiFundSlashTx struct {
+	Proposer       TxInput
+	SlashedAddress common.Address
+	Funds          []SlashedFund
+}
+
+type MultiFundSlashTxJSON struct {
+	Proposer       TxInput        `json:"proposer"`
+	SlashedAddress common.Address `json:"slashed_address"`
+	Funds          []SlashedFund  `json:"funds"`
+}
+
+func NewMultiFundSlashTxJSON(a MultiFundSlashTx) MultiFundSlashTxJSON {
+	return MultiFundSlashTxJSON{
+		Proposer:       a.Proposer,
+		SlashedAddress: a.SlashedAddress,
+		Funds:          a.Funds,
+	}
+}
+
+func (a MultiFundSlashTxJSON) MultiFundSlashTx() MultiFundSlashTx {
+	return MultiFundSlashTx{
+		Proposer:       a.Proposer,
+		SlashedAddress: a.SlashedAddress,
+		Funds:          a.Funds,
+	}
+}
+
+func (a MultiFundSlashTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewMultiFundSlashTxJSON(a))
+}
+
+func (a *MultiFundSlashTx) UnmarshalJSON(data []byte) error {
+	var b MultiFundSlashTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.MultiFundSlashTx()
+	return nil
+}
+
+func (_ *MultiFundSlashTx) AssertIsTx() {}
+
+func (tx *MultiFundSlashTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Proposer.Signature
+	tx.Proposer.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Proposer.Signature = sig
+	return signBytes
+}
+
+func (tx *MultiFundSlashTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Proposer.Address == addr {
+		tx.Proposer.Signature = sig
+		return true
+	}
+	return false
+}
+
+// SlashTx returns the SlashTx equivalent to the slash of the reserved fund at the given index. The SlashTx
+// carries no signature, since the proposer signs the MultiFundSlashTx as a whole.
+func (tx *MultiFundSlashTx) SlashTx(idx int) *SlashTx {
+	fund := tx.Funds[idx]
+	return &SlashTx{
+		Proposer: TxInput{
+			Address:  tx.Proposer.Address,
+			Sequence: tx.Proposer.Sequence,
+		},
+		SlashedAddress:  tx.SlashedAddress,
+		ReserveSequence: fund.ReserveSequence,
+		SlashProof:      fund.SlashProof,
+		Reason:          fund.Reason,
+	}
+}
+
+func (tx *MultiFundSlashTx) String() string {
+	return fmt.Sprintf("MultiFundSlashTx{%v -> %v, funds: %v}", tx.Proposer.Address.Hex(),
+		tx.SlashedAddress.Hex(), len(tx.Funds))
+}
@@ -705,6 +705,35 @@ func TestSlashTxJSON(t *testing.T) {
 	assert.Equal(uint64(math.MaxUint64), d.ReserveSequence)
 }
 
+func TestSlashTxReasonJSON(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	a := SlashTx{
+		Reason: SlashReasonChannelRevocation,
+	}
+	s, err := json.Marshal(a)
+	require.Nil(err)
+	assert.Contains(string(s), `"reason":"channel_revocation"`)
+
+	var d SlashTx
+	err = json.Unmarshal(s, &d)
+	require.Nil(err)
+	assert.Equal(SlashReasonChannelRevocation, d.Reason)
+
+	// SlashTxs without a reason are overspending slashes
+	var e SlashTx
+	err = json.Unmarshal([]byte(`{"reserved_sequence":"1"}`), &e)
+	require.Nil(err)
+	assert.Equal(SlashReasonOverspending, e.Reason)
+
+	err = json.Unmarshal([]byte(`{"reason":"bribery"}`), &e)
+	assert.NotNil(err)
+
+	_, err = json.Marshal(SlashTx{Reason: SlashReason(100)})
+	assert.NotNil(err)
+}
+
 func TestReserveFundTxJSON(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -769,6 +798,105 @@ func TestSplitRuleTxJSON(t *testing.T) {
 	assert.Equal(uint64(math.MaxUint64), d.Duration)
 }
 
+func TestSlashEvidenceTxJSON(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	a := SlashEvidenceTx{
+		ReserveSequence: math.MaxUint64,
+		ServicePayments: []ServicePaymentTx{ServicePaymentTx{PaymentSequence: math.MaxUint64}},
+	}
+	s, err := json.Marshal(a)
+	require.Nil(err)
+
+	var d SlashEvidenceTx
+	err = json.Unmarshal(s, &d)
+	require.Nil(err)
+	assert.Equal(uint64(math.MaxUint64), d.ReserveSequence)
+	assert.Equal(uint64(math.MaxUint64), d.ServicePayments[0].PaymentSequence)
+
+	raw, err := TxToBytes(&a)
+	require.Nil(err)
+	tx, err := TxFromBytes(raw)
+	require.Nil(err)
+	evidenceTx, ok := tx.(*SlashEvidenceTx)
+	require.True(ok)
+	assert.Equal(uint64(math.MaxUint64), evidenceTx.ReserveSequence)
+}
+
+func TestBatchSlashTxJSON(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	a := BatchSlashTx{
+		Proposer: TxInput{Sequence: math.MaxUint64},
+		Entries: []SlashEntry{
+			SlashEntry{ReserveSequence: math.MaxUint64, SlashProof: common.Bytes("proof1")},
+			SlashEntry{ReserveSequence: 1, SlashProof: common.Bytes("proof2")},
+		},
+	}
+	s, err := json.Marshal(a)
+	require.Nil(err)
+
+	var d BatchSlashTx
+	err = json.Unmarshal(s, &d)
+	require.Nil(err)
+	require.Equal(2, len(d.Entries))
+	assert.Equal(uint64(math.MaxUint64), d.Entries[0].ReserveSequence)
+	assert.Equal(common.Bytes("proof2"), d.Entries[1].SlashProof)
+
+	raw, err := TxToBytes(&a)
+	require.Nil(err)
+	tx, err := TxFromBytes(raw)
+	require.Nil(err)
+	batchSlashTx, ok := tx.(*BatchSlashTx)
+	require.True(ok)
+	assert.Equal(uint64(math.MaxUint64), batchSlashTx.Proposer.Sequence)
+
+	slashTx := batchSlashTx.SlashTx(1)
+	assert.Equal(uint64(1), slashTx.ReserveSequence)
+	assert.Equal(common.Bytes("proof2"), slashTx.SlashProof)
+	assert.Nil(slashTx.Proposer.Signature)
+}
+
+func TestMultiFundSlashTxJSON(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	a := MultiFundSlashTx{
+		Proposer:       TxInput{Sequence: math.MaxUint64},
+		SlashedAddress: common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab"),
+		Funds: []SlashedFund{
+			SlashedFund{ReserveSequence: math.MaxUint64, SlashProof: common.Bytes("proof1")},
+			SlashedFund{ReserveSequence: 1, SlashProof: common.Bytes("proof2")},
+		},
+	}
+	s, err := json.Marshal(a)
+	require.Nil(err)
+
+	var d MultiFundSlashTx
+	err = json.Unmarshal(s, &d)
+	require.Nil(err)
+	require.Equal(2, len(d.Funds))
+	assert.Equal(a.SlashedAddress, d.SlashedAddress)
+	assert.Equal(uint64(math.MaxUint64), d.Funds[0].ReserveSequence)
+	assert.Equal(common.Bytes("proof2"), d.Funds[1].SlashProof)
+
+	raw, err := TxToBytes(&a)
+	require.Nil(err)
+	tx, err := TxFromBytes(raw)
+	require.Nil(err)
+	multiFundSlashTx, ok := tx.(*MultiFundSlashTx)
+	require.True(ok)
+	assert.Equal(uint64(math.MaxUint64), multiFundSlashTx.Proposer.Sequence)
+
+	slashTx := multiFundSlashTx.SlashTx(1)
+	assert.Equal(a.SlashedAddress, slashTx.SlashedAddress)
+	assert.Equal(uint64(1), slashTx.ReserveSequence)
+	assert.Equal(common.Bytes("proof2"), slashTx.SlashProof)
+	assert.Nil(slashTx.Proposer.Signature)
+}
+
 func TestSmartContractTxJSON(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -787,3 +915,179 @@ func TestSmartContractTxJSON(t *testing.T) {
 	assert.Equal(uint64(math.MaxUint64), d.GasLimit)
 	assert.Equal(0, gasPrice.Cmp(d.GasPrice))
 }
+
+func TestSlashTxSnapshot(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	a := &SlashTx{ReserveSequence: 1, SlashProof: common.Bytes("proof")}
+	assert.Nil(a.GetSnapshot())
+	unboundSignBytes := a.SignBytes("test_chain_id")
+
+	stateRoot := common.BytesToHash([]byte("state_root"))
+	a.BindSnapshot(math.MaxUint64, stateRoot)
+	require.NotNil(a.GetSnapshot())
+	assert.NotEqual(unboundSignBytes, a.SignBytes("test_chain_id"))
+
+	raw, err := TxToBytes(a)
+	require.Nil(err)
+	tx, err := TxFromBytes(raw)
+	require.Nil(err)
+	snapshot := tx.(*SlashTx).GetSnapshot()
+	require.NotNil(snapshot)
+	assert.Equal(uint64(math.MaxUint64), snapshot.Height)
+	assert.Equal(stateRoot, snapshot.StateRoot)
+
+	s, err := json.Marshal(a)
+	require.Nil(err)
+	var d SlashTx
+	require.Nil(json.Unmarshal(s, &d))
+	require.NotNil(d.GetSnapshot())
+	assert.Equal(uint64(math.MaxUint64), d.GetSnapshot().Height)
+	assert.Equal(stateRoot, d.GetSnapshot().StateRoot)
+
+	// An unbound SlashTx keeps its encoding
+	a.Snapshot = nil
+	assert.Equal(unboundSignBytes, a.SignBytes("test_chain_id"))
+}
+
+func TestTxServiceType(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Untagged txs keep their encoding, the tag trails the snapshot or the resource caps
+	slashTx := &SlashTx{ReserveSequence: 1, SlashProof: common.Bytes("proof")}
+	slashTx.BindSnapshot(1, common.BytesToHash([]byte("state_root")))
+	untaggedSignBytes := slashTx.SignBytes("test_chain_id")
+	slashTx.ServiceType = "video"
+	assert.NotEqual(untaggedSignBytes, slashTx.SignBytes("test_chain_id"))
+
+	raw, err := TxToBytes(slashTx)
+	require.Nil(err)
+	tx, err := TxFromBytes(raw)
+	require.Nil(err)
+	assert.Equal("video", tx.(*SlashTx).ServiceType)
+	require.NotNil(tx.(*SlashTx).GetSnapshot())
+	assert.Equal(uint64(1), tx.(*SlashTx).GetSnapshot().Height)
+
+	reserveFundTx := &ReserveFundTx{
+		Fee:          NewCoins(0, 111),
+		Collateral:   NewCoins(0, 22897),
+		ResourceIDs:  []string{"rid00123"},
+		Duration:     uint64(999),
+		ResourceCaps: []ResourceCap{{ResourceID: "rid00123", Cap: NewCoins(0, 300)}},
+	}
+	untaggedSignBytes = reserveFundTx.SignBytes("test_chain_id")
+	reserveFundTx.ServiceType = "video"
+	assert.NotEqual(untaggedSignBytes, reserveFundTx.SignBytes("test_chain_id"))
+
+	raw, err = TxToBytes(reserveFundTx)
+	require.Nil(err)
+	tx, err = TxFromBytes(raw)
+	require.Nil(err)
+	assert.Equal("video", tx.(*ReserveFundTx).ServiceType)
+	assert.Equal(1, len(tx.(*ReserveFundTx).ResourceCaps))
+
+	s, err := json.Marshal(reserveFundTx)
+	require.Nil(err)
+	var d ReserveFundTx
+	require.Nil(json.Unmarshal(s, &d))
+	assert.Equal("video", d.ServiceType)
+}
+
+func TestSlashTxLegacyLayout(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// A SlashTx encoded before the reason was recorded, i.e. with the Proposer, SlashedAddress,
+	// ReserveSequence and SlashProof fields only, along with its sign bytes for "test_chain_id"
+	legacyRaw, err := hex.DecodeString("F839DA942E833968E5BB786AE419C4D13189FB081CC43BABC28080018094" +
+		"0000000000000000000000000000000000014FAB018732333435414243")
+	require.Nil(err)
+	legacySignBytes, err := hex.DecodeString("F86580808094000000000000000000000000000000000000000080B84A8D" +
+		"746573745F636861696E5F696401F839DA942E833968E5BB786AE419C4D13189FB081CC43BABC28080018094" +
+		"0000000000000000000000000000000000014FAB018732333435414243")
+	require.Nil(err)
+
+	var decoded SlashTx
+	require.Nil(rlp.DecodeBytes(legacyRaw, &decoded))
+	assert.Equal(SlashReasonOverspending, decoded.Reason)
+	assert.Equal(common.HexToAddress("0x014FAB"), decoded.SlashedAddress)
+	assert.Equal(uint64(1), decoded.ReserveSequence)
+	assert.Equal(common.Bytes("2345ABC"), decoded.SlashProof)
+	assert.Nil(decoded.GetSnapshot())
+
+	// The overspending SlashTxs keep their encoding, hence their sign bytes and IDs
+	raw, err := rlp.EncodeToBytes(&decoded)
+	require.Nil(err)
+	assert.Equal(legacyRaw, raw)
+	assert.Equal(legacySignBytes, decoded.SignBytes("test_chain_id"))
+
+	// The reason trails the legacy fields
+	decoded.Reason = SlashReasonChannelRevocation
+	raw, err = rlp.EncodeToBytes(&decoded)
+	require.Nil(err)
+	assert.NotEqual(legacyRaw, raw)
+	var revocation SlashTx
+	require.Nil(rlp.DecodeBytes(raw, &revocation))
+	assert.Equal(SlashReasonChannelRevocation, revocation.Reason)
+
+	// An overspending reason leads the tail only if the tail is not empty, otherwise it must be omitted
+	decoded.Reason = SlashReasonOverspending
+	decoded.BindSnapshot(1, common.BytesToHash([]byte("state_root")))
+	raw, err = rlp.EncodeToBytes(&decoded)
+	require.Nil(err)
+	var bound SlashTx
+	require.Nil(rlp.DecodeBytes(raw, &bound))
+	assert.Equal(SlashReasonOverspending, bound.Reason)
+	require.NotNil(bound.GetSnapshot())
+	assert.Equal(uint64(1), bound.GetSnapshot().Height)
+
+	trailingReason := append([]byte{0xF8, 0x3A}, legacyRaw[2:]...)
+	trailingReason = append(trailingReason, 0x80)
+	assert.NotNil(rlp.DecodeBytes(trailingReason, &bound))
+}
+
+func TestSlashTxID(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	a := &SlashTx{ReserveSequence: 1, SlashProof: common.Bytes("proof")}
+	id := a.ID("test_chain_id")
+	assert.Equal(TxID("test_chain_id", a), id)
+	assert.NotEqual(id, a.ID("other_chain_id"))
+
+	// The ID is not affected by the signature
+	privKey, _, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+	a.Proposer.Address = privKey.PublicKey().Address()
+	id = a.ID("test_chain_id")
+	a.Proposer.Signature, err = privKey.Sign(a.SignBytes("test_chain_id"))
+	require.Nil(err)
+	assert.Equal(id, a.ID("test_chain_id"))
+
+	raw, err := TxToBytes(a)
+	require.Nil(err)
+	tx, err := TxFromBytes(raw)
+	require.Nil(err)
+	assert.Equal(id, tx.(*SlashTx).ID("test_chain_id"))
+}
+
+func TestTxIDDomainSeparation(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// The deposit and the withdrawal of a stake have the same fields, hence the same RLP encoding
+	source := TxInput{Address: common.HexToAddress("0x1"), Coins: NewCoins(1000, 0), Sequence: 1}
+	holder := TxOutput{Address: common.HexToAddress("0x2")}
+	depositStakeTx := &DepositStakeTx{Fee: NewCoins(0, 1), Source: source, Holder: holder, Purpose: 1}
+	withdrawStakeTx := &WithdrawStakeTx{Fee: NewCoins(0, 1), Source: source, Holder: holder, Purpose: 1}
+	depositBytes, err := rlp.EncodeToBytes(depositStakeTx)
+	require.Nil(err)
+	withdrawBytes, err := rlp.EncodeToBytes(withdrawStakeTx)
+	require.Nil(err)
+	assert.Equal(depositBytes, withdrawBytes)
+
+	// The TxType tells their IDs apart
+	assert.NotEqual(TxID(chainID, depositStakeTx), TxID(chainID, withdrawStakeTx))
+}
@@ -296,6 +296,22 @@ func (mp *Mempool) ReapUnsafe(maxNumTxs int) []common.Bytes {
 	return txs
 }
 
+// RequeueUnsafe puts a reaped transaction back into the transaction candidate list, e.g. if it could not be
+// included in the proposed block but remains valid. The transaction is not screened or gossiped again.
+// Caller must call Mempool.Lock() before calling this method.
+func (mp *Mempool) RequeueUnsafe(rawTx common.Bytes, txInfo *core.TxInfo) {
+	txGroup, ok := mp.addressToTxGroup[txInfo.Address]
+	if ok {
+		txGroup.AddTx(rawTx, txInfo)
+		mp.candidateTxs.Remove(txGroup.index) // Need to re-insert txGroup into queue since its priority could change.
+	} else {
+		txGroup = createMempoolTransactionGroup(rawTx, txInfo)
+		mp.addressToTxGroup[txInfo.Address] = txGroup
+	}
+	mp.candidateTxs.Push(txGroup)
+	mp.size++
+}
+
 // Update removes the committed transactions from the transaction candidate list
 // RUNTIME COMPLEXITY: O(k + n), where k is the number committed raw transactions,
 // and n is the number of transactions in the candidate pool.
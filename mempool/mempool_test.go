@@ -197,6 +197,36 @@ func TestMempoolReapOrder(t *testing.T) {
 	assert.Equal("tx3", string(reapedRawTxs[9][:]))  // gasPrice: 32, address: A3, seq: 2012
 }
 
+func TestMempoolRequeue(t *testing.T) {
+	assert := assert.New(t)
+
+	p2psimnet := p2psim.NewSimnetWithHandler(nil)
+	mempool, _ := newTestMempool("peer0", p2psimnet)
+
+	tx1 := createTestRawTx("tx1")
+	tx2 := createTestRawTx("tx2")
+	assert.Nil(mempool.InsertTransaction(tx1))
+	assert.Nil(mempool.InsertTransaction(tx2))
+
+	reapedRawTxs := mempool.Reap(-1)
+	assert.Equal(2, len(reapedRawTxs))
+	assert.Equal(0, mempool.Size())
+
+	// A requeued transaction can be reaped again
+	txInfo := &core.TxInfo{
+		EffectiveGasPrice: new(big.Int).SetUint64(78),
+		Address:           common.HexToAddress("A1"),
+		Sequence:          1023,
+	}
+	mempool.RequeueUnsafe(tx1, txInfo)
+	assert.Equal(1, mempool.Size())
+
+	reapedRawTxs = mempool.Reap(-1)
+	assert.Equal(1, len(reapedRawTxs))
+	assert.Equal("tx1", string(reapedRawTxs[0][:]))
+	assert.Equal(0, mempool.Size())
+}
+
 func TestMempoolUpdate(t *testing.T) {
 	assert := assert.New(t)
 
@@ -468,11 +498,11 @@ func (tl *TestLedger) ScreenTx(rawTx common.Bytes) (*core.TxInfo, result.Result)
 	return txInfo, result.OK
 }
 
-func (tl *TestLedger) ProposeBlockTxs() (stateRootHash common.Hash, blockRawTxs []common.Bytes, res result.Result) {
+func (tl *TestLedger) ProposeBlockTxs(block *core.Block) (stateRootHash common.Hash, blockRawTxs []common.Bytes, res result.Result) {
 	return common.Hash{}, []common.Bytes{}, result.OK
 }
 
-func (tl *TestLedger) ApplyBlockTxs(blockRawTxs []common.Bytes, expectedStateRoot common.Hash) result.Result {
+func (tl *TestLedger) ApplyBlockTxs(block *core.Block) result.Result {
 	return result.OK
 }
 
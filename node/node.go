@@ -68,7 +68,10 @@ func NewNode(params *Params) *Node {
 
 	syncMgr := netsync.NewSyncManager(chain, consensus, params.Network, dispatcher, consensus)
 	mempool := mp.CreateMempool(dispatcher)
-	ledger := ld.NewLedger(params.ChainID, params.DB, chain, consensus, validatorManager, mempool)
+	ledger, err := ld.NewLedger(params.ChainID, params.DB, chain, consensus, validatorManager, mempool)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create the ledger: %v", err))
+	}
 	validatorManager.SetConsensusEngine(consensus)
 	consensus.SetLedger(ledger)
 	mempool.SetLedger(ledger)
@@ -1,14 +1,17 @@
 package rpc
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
 	"time"
 
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
 	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/execution"
 	"github.com/thetatoken/theta/ledger/state"
 	"github.com/thetatoken/theta/ledger/types"
 	"github.com/thetatoken/theta/version"
@@ -191,6 +194,9 @@ const (
 	TxTypeSmartContract
 	TxTypeDepositStake
 	TxTypeWithdrawStake
+	TxTypeSlashEvidence
+	TxTypeBatchSlash
+	TxTypeMultiFundSlash
 )
 
 func (t *ThetaRPCService) GetBlock(args *GetBlockArgs, result *GetBlockResult) (err error) {
@@ -373,6 +379,84 @@ func (t *ThetaRPCService) GetVcpByHeight(args *GetVcpByHeightArgs, result *GetVc
 	return nil
 }
 
+// ------------------------------ DecodeSlashProof -----------------------------------
+
+type DecodeSlashProofArgs struct {
+	TxBytes string `json:"tx_bytes"`
+}
+
+type DecodeSlashProofResult struct {
+	*types.OverspendingProof
+}
+
+// DecodeSlashProof decodes the overspending proof of a (e.g. pending) SlashTx for inspection, the tx is not executed
+func (t *ThetaRPCService) DecodeSlashProof(args *DecodeSlashProofArgs, result *DecodeSlashProofResult) (err error) {
+	txBytes, err := hex.DecodeString(args.TxBytes)
+	if err != nil {
+		return err
+	}
+	tx, err := types.TxFromBytes(txBytes)
+	if err != nil {
+		return err
+	}
+	slashTx, ok := tx.(*types.SlashTx)
+	if !ok {
+		return errors.New("Not a SlashTx")
+	}
+	result.OverspendingProof, err = execution.DecodeSlashProof(slashTx)
+	return err
+}
+
+// ------------------------------ SimulateTx -----------------------------------
+
+type SimulateTxArgs struct {
+	TxBytes string `json:"tx_bytes"`
+}
+
+type SimulateTxResult struct {
+	TxHash        common.Hash              `json:"hash"`
+	Code          result.ErrorCode         `json:"code"`
+	Message       string                   `json:"message"`
+	BalanceDeltas []execution.BalanceDelta `json:"balance_deltas"`
+}
+
+// SimulateTx predicts whether the (e.g. SlashTx) transaction would succeed against the current state, and
+// how it would change the account balances. The transaction is not committed nor broadcast.
+func (t *ThetaRPCService) SimulateTx(args *SimulateTxArgs, result *SimulateTxResult) (err error) {
+	txBytes, err := hex.DecodeString(args.TxBytes)
+	if err != nil {
+		return err
+	}
+	simResult, err := t.ledger.SimulateTx(txBytes)
+	if err != nil {
+		return err
+	}
+	result.TxHash = simResult.TxHash
+	result.Code = simResult.Result.Code
+	result.Message = simResult.Result.Message
+	result.BalanceDeltas = simResult.BalanceDeltas
+	return nil
+}
+
+// ------------------------------ GetSlashBurntSupply -----------------------------------
+
+type GetSlashBurntSupplyArgs struct{}
+
+type GetSlashBurntSupplyResult struct {
+	BurntSupply types.Coins `json:"burnt_supply"`
+}
+
+// GetSlashBurntSupply returns the total amount burnt by slashing as of the last finalized block, by which
+// slashing has decreased the circulating supply
+func (t *ThetaRPCService) GetSlashBurntSupply(args *GetSlashBurntSupplyArgs, result *GetSlashBurntSupplyResult) (err error) {
+	ledgerState, err := t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return err
+	}
+	result.BurntSupply = ledgerState.GetSlashBurntSupply()
+	return nil
+}
+
 // ------------------------------ Utils ------------------------------
 
 func getTxType(tx types.Tx) byte {
@@ -398,6 +482,12 @@ func getTxType(tx types.Tx) byte {
 		t = TxTypeDepositStake
 	case *types.WithdrawStakeTx:
 		t = TxTypeWithdrawStake
+	case *types.SlashEvidenceTx:
+		t = TxTypeSlashEvidence
+	case *types.BatchSlashTx:
+		t = TxTypeBatchSlash
+	case *types.MultiFundSlashTx:
+		t = TxTypeMultiFundSlash
 	}
 
 	return t